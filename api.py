@@ -1,6 +1,7 @@
 # api.py
 
-from fastapi import FastAPI, HTTPException
+import hashlib
+from fastapi import FastAPI, HTTPException, Request, Response
 from pydantic import BaseModel
 from typing import Optional
 from passlib.hash import bcrypt
@@ -35,6 +36,22 @@ recommendations_repo = RecommendationsRepository(db)
 user_manager = UserManager()
 book_manager = BookManager()
 
+# ---------- Caching ----------
+
+@app.middleware("http")
+async def etag(request: Request, call_next):
+    """Tags GET answers so clients can revalidate them with If-None-Match."""
+    response = await call_next(request)
+    if request.method != "GET" or response.status_code != 200:
+        return response
+    body = b"".join([chunk async for chunk in response.body_iterator])
+    tag = '"' + hashlib.sha1(body).hexdigest() + '"'
+    if request.headers.get("if-none-match") == tag:
+        return Response(status_code=304, headers={"etag": tag})
+    headers = dict(response.headers)
+    headers["etag"] = tag
+    return Response(content=body, status_code=200, headers=headers)
+
 # ---------- Startup ----------
 
 @app.on_event("startup")
@@ -149,6 +166,13 @@ class RecommendRequest(BaseModel):
     book_id: int
     message: Optional[str] = None
 
+# ---------- Health ----------
+
+@app.get("/health")
+def health():
+    """Cheap liveness probe for clients, touches no state."""
+    return {"status": "ok"}
+
 # ---------- Users ----------
 
 @app.post("/register")
@@ -248,7 +272,11 @@ def remove_friend(u: str, v: str):
 # ---------- Books ----------
 
 @app.get("/books")
-def list_books():
+def list_books(response: Response, offset: int = 0, limit: Optional[int] = None):
+    books = book_manager.all_books()
+    response.headers["X-Total-Count"] = str(len(books))
+    if limit is not None:
+        books = books[offset:offset + limit]
     return [
         {
             "id": b.id,
@@ -260,7 +288,7 @@ def list_books():
             "pages": b.total_pages,
             "avg_rating": b.average_rating
         }
-        for b in book_manager.all_books()
+        for b in books
     ]
 
 @app.get("/books/{book_id}")
@@ -413,6 +441,7 @@ def list_libraries(username: str):
         raise HTTPException(404)
     return [
         {
+            "id": lib.id,
             "name": lib.name,
             "shelves": {
                 k: [b.id for b in v]
@@ -0,0 +1,74 @@
+package api
+
+import (
+    "errors"
+    "net/http"
+    "sync"
+
+    "tui/types"
+)
+
+// lookups is how many book requests GetBooks keeps in flight at once.
+const lookups = 8
+
+// GetBooks looks up books by ID, a few at a time, and returns them in the
+// order asked for. IDs the backend doesn't know are left out, any other
+// error fails the whole lookup.
+func GetBooks(s Service, ids []int) ([]types.Book, error) {
+    books := make([]types.Book, len(ids))
+    found := make([]bool, len(ids))
+    errs := make([]error, len(ids))
+
+    var wg sync.WaitGroup
+    next := make(chan int)
+    for w := 0; w < min(lookups, len(ids)); w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := range next {
+                book, err := s.GetBook(ids[i])
+                var apiErr *APIError
+                switch {
+                case err == nil:
+                    books[i], found[i] = book, true
+                case errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound:
+                default:
+                    errs[i] = err
+                }
+            }
+        }()
+    }
+    for i := range ids {
+        next <- i
+    }
+    close(next)
+    wg.Wait()
+
+    out := make([]types.Book, 0, len(ids))
+    for i := range ids {
+        if errs[i] != nil {
+            return nil, errs[i]
+        }
+        if found[i] {
+            out = append(out, books[i])
+        }
+    }
+    return out, nil
+}
+
+// AllBooks reads the whole catalog, size books per request. A page shorter
+// than asked for is the last one, whatever total the backend reported.
+func AllBooks(s Service, size int) ([]types.Book, error) {
+    size = max(size, 1)
+    var books []types.Book
+    for {
+        page, err := s.ListBooksPage(len(books), size)
+        if err != nil {
+            return nil, err
+        }
+        books = append(books, page.Books...)
+        if len(page.Books) < size || page.Total >= 0 && len(books) >= page.Total {
+            return books, nil
+        }
+    }
+}
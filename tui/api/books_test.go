@@ -0,0 +1,150 @@
+package api
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "sync"
+    "testing"
+
+    "tui/types"
+)
+
+// The paging contract every backend keeps: pages in ID order, covering the
+// catalog once, with its size as the total and nothing past the end.
+func TestListBooksPage(t *testing.T) {
+    for _, s := range []Service{NewFake(), openLocal(t)} {
+        name := fmt.Sprintf("%T", s)
+        var ids []int
+        for offset := 0; ; offset += 7 {
+            page, err := s.ListBooksPage(offset, 7)
+            if err != nil {
+                t.Fatalf("%s: ListBooksPage(%d, 7): %v", name, offset, err)
+            }
+            if page.Offset != offset || page.Total != len(SeedBooks) {
+                t.Errorf("%s: page at %d says offset %d, total %d", name, offset, page.Offset, page.Total)
+            }
+            for _, b := range page.Books {
+                ids = append(ids, b.ID)
+            }
+            if len(page.Books) < 7 {
+                break
+            }
+        }
+        if len(ids) != len(SeedBooks) {
+            t.Fatalf("%s: pages held %d books, want %d", name, len(ids), len(SeedBooks))
+        }
+        for i, id := range ids {
+            if id != i+1 {
+                t.Fatalf("%s: book %d of the pages is %d", name, i, id)
+            }
+        }
+
+        for _, tt := range []struct{ offset, limit int }{{len(SeedBooks), 5}, {1000, 5}, {0, 0}} {
+            page, err := s.ListBooksPage(tt.offset, tt.limit)
+            if err != nil || len(page.Books) != 0 {
+                t.Errorf("%s: ListBooksPage(%d, %d) = %d books, %v", name, tt.offset, tt.limit, len(page.Books), err)
+            }
+        }
+    }
+}
+
+// pager serves a catalog of n books, reporting total (-1 for none) and
+// counting the requests.
+type pager struct {
+    Service
+    n, total int
+    requests int
+}
+
+func (p *pager) ListBooksPage(offset, limit int) (types.BookPage, error) {
+    p.requests++
+    books := make([]types.Book, p.n)
+    for i := range books {
+        books[i] = types.Book{ID: i + 1}
+    }
+    page := PageOf(books, offset, limit)
+    page.Total = p.total
+    return page, nil
+}
+
+func TestAllBooks(t *testing.T) {
+    tests := []struct {
+        name     string
+        n, total int
+        size     int
+        requests int
+    }{
+        {"short last page", 25, 25, 10, 3},
+        {"exact pages, total known", 30, 30, 10, 3},
+        // Without a total only a short page says the catalog ended
+        {"exact pages, no total", 30, -1, 10, 4},
+        {"no total", 25, -1, 10, 3},
+        {"empty", 0, 0, 10, 1},
+        {"size below one", 3, -1, 0, 4},
+    }
+    for _, tt := range tests {
+        p := &pager{n: tt.n, total: tt.total}
+        books, err := AllBooks(p, tt.size)
+        if err != nil || len(books) != tt.n {
+            t.Errorf("%s: AllBooks = %d books, %v, want %d", tt.name, len(books), err, tt.n)
+        }
+        for i, b := range books {
+            if b.ID != i+1 {
+                t.Errorf("%s: book %d is %d", tt.name, i, b.ID)
+                break
+            }
+        }
+        if p.requests != tt.requests {
+            t.Errorf("%s: %d requests, want %d", tt.name, p.requests, tt.requests)
+        }
+    }
+}
+
+// lookup answers GetBook from a fixed set of books, failing for some ids.
+type lookup struct {
+    Service
+    mu    sync.Mutex
+    asked []int
+    fail  map[int]error
+}
+
+func (l *lookup) GetBook(id int) (types.Book, error) {
+    l.mu.Lock()
+    l.asked = append(l.asked, id)
+    l.mu.Unlock()
+    if err := l.fail[id]; err != nil {
+        return types.Book{}, err
+    }
+    return types.Book{ID: id, Name: fmt.Sprintf("Book %d", id)}, nil
+}
+
+func TestGetBooks(t *testing.T) {
+    missing := &APIError{Endpoint: "GET /books/{id}", Status: http.StatusNotFound}
+    down := errors.New("connection refused")
+
+    ids := make([]int, 40)
+    for i := range ids {
+        ids[i] = 100 - i
+    }
+    l := &lookup{fail: map[int]error{90: missing}}
+    books, err := GetBooks(l, ids)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // In the order asked for, without the book the backend doesn't have
+    if len(books) != 39 || books[0].ID != 100 || books[38].ID != 61 || books[10].ID != 89 {
+        t.Fatalf("GetBooks = %d books: %v", len(books), books)
+    }
+    if len(l.asked) != len(ids) {
+        t.Errorf("asked for %d books, want %d", len(l.asked), len(ids))
+    }
+
+    l = &lookup{fail: map[int]error{70: down}}
+    if _, err := GetBooks(l, ids); !errors.Is(err, down) {
+        t.Errorf("GetBooks with the backend down = %v", err)
+    }
+    if books, err := GetBooks(&lookup{}, nil); err != nil || len(books) != 0 {
+        t.Errorf("GetBooks of nothing = %v, %v", books, err)
+    }
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+    "bytes"
+    "container/list"
+    "io"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Cache stores GET responses for the Client. MemoryCache is the stock one;
+// anything else, on disk or shared between processes, only has to
+// implement these four methods.
+type Cache interface {
+    Get(key string) (CacheEntry, bool)
+    Set(key string, entry CacheEntry)
+    Delete(key string)
+    Keys() []string
+}
+
+// CacheEntry is one stored response.
+type CacheEntry struct {
+    Route  string // e.g. "GET /users/{u}", what TTLs and invalidation go by
+    Status int
+    Header http.Header
+    Body   []byte
+    Stored time.Time // zero forces a revalidation on the next request
+}
+
+func (e CacheEntry) response() *http.Response {
+    return &http.Response{
+        StatusCode: e.Status,
+        Status:     http.StatusText(e.Status),
+        Header:     e.Header.Clone(),
+        Body:       io.NopCloser(bytes.NewReader(e.Body)),
+    }
+}
+
+// DefaultTTLs say how long each route's answers are used without asking
+// the backend again. Routes missing here are never cached. GET /books is
+// left out on purpose: its pages are decoded as they stream in, and a
+// cached answer has to be read whole first.
+var DefaultTTLs = map[string]time.Duration{
+    "GET /books/{id}":                 time.Minute,
+    "GET /me":                         time.Minute,
+    "GET /users/{u}":                  30 * time.Second,
+    "GET /users/{u}/libraries":        30 * time.Second,
+    "GET /users/{u}/reading":          10 * time.Second,
+    "GET /users/{u}/recommendations":  30 * time.Second,
+}
+
+// Invalidates lists the cached routes a successful mutation makes stale.
+var Invalidates = map[string][]string{
+    "POST /books/{id}/reviews":    {"GET /books/{id}"},
+    "POST /libraries":             {"GET /users/{u}/libraries", "GET /users/{u}", "GET /me"},
+    "POST /libraries/{id}/books":  {"GET /users/{u}/libraries"},
+    "POST /libraries/{id}/move":   {"GET /users/{u}/libraries"},
+    "POST /reading/start":         {"GET /users/{u}/reading"},
+    "POST /reading/turn":          {"GET /users/{u}/reading"},
+    "POST /users/{u}/friends/{v}": {"GET /users/{u}", "GET /me"},
+    "POST /recommend":             {"GET /users/{u}/recommendations"},
+}
+
+// DefaultCacheBytes is how much the Client's MemoryCache keeps.
+const DefaultCacheBytes = 8 << 20
+
+// MemoryCache keeps responses in memory for the life of the process, up to
+// a number of bytes. Past that the least recently used answers go first.
+type MemoryCache struct {
+    mu       sync.Mutex
+    maxBytes int
+    size     int
+    order    *list.List // of *memoryEntry, most recently used first
+    entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+    key   string
+    entry CacheEntry
+    size  int
+}
+
+func NewMemoryCache(maxBytes int) *MemoryCache {
+    return &MemoryCache{maxBytes: maxBytes, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    el, ok := c.entries[key]
+    if !ok {
+        return CacheEntry{}, false
+    }
+    c.order.MoveToFront(el)
+    return el.Value.(*memoryEntry).entry, true
+}
+
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.remove(key)
+
+    // Roughly what the entry holds on to, the body and its headers
+    size := len(key) + len(entry.Body)
+    for name, values := range entry.Header {
+        size += len(name)
+        for _, v := range values {
+            size += len(v)
+        }
+    }
+    if size > c.maxBytes {
+        return
+    }
+
+    c.entries[key] = c.order.PushFront(&memoryEntry{key: key, entry: entry, size: size})
+    c.size += size
+    for c.size > c.maxBytes {
+        c.remove(c.order.Back().Value.(*memoryEntry).key)
+    }
+}
+
+func (c *MemoryCache) Delete(key string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.remove(key)
+}
+
+func (c *MemoryCache) Keys() []string {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    keys := make([]string, 0, len(c.entries))
+    for key := range c.entries {
+        keys = append(keys, key)
+    }
+    return keys
+}
+
+// remove drops key, the caller holds c.mu.
+func (c *MemoryCache) remove(key string) {
+    if el, ok := c.entries[key]; ok {
+        c.size -= el.Value.(*memoryEntry).size
+        c.order.Remove(el)
+        delete(c.entries, key)
+    }
+}
+
+// cachedGet answers a GET from the cache while it is fresh, revalidates it
+// with If-None-Match / If-Modified-Since once it is stale, and otherwise
+// asks the backend. Identical requests in flight at the same time share one
+// round trip, so mashing refresh sends a single request.
+func (c *Client) cachedGet(route, endpoint string, ttl time.Duration) (*http.Response, error) {
+    key := c.Token + " " + endpoint
+
+    v, err, _ := c.flight.Do(key, func() (interface{}, error) {
+        cached, ok := c.Cache.Get(key)
+        if ok && time.Since(cached.Stored) < ttl {
+            return cached, nil
+        }
+
+        header := http.Header{}
+        if ok {
+            if etag := cached.Header.Get("ETag"); etag != "" {
+                header.Set("If-None-Match", etag)
+            }
+            if modified := cached.Header.Get("Last-Modified"); modified != "" {
+                header.Set("If-Modified-Since", modified)
+            }
+        }
+
+        resp, err := c.send("GET", endpoint, nil, header)
+        if err != nil {
+            return nil, err
+        }
+        if resp.StatusCode == http.StatusNotModified {
+            if ok {
+                resp.Body.Close()
+                cached.Stored = time.Now()
+                c.Cache.Set(key, cached)
+                return cached, nil
+            }
+            // Nothing to reuse, ask once more for the whole answer
+            resp.Body.Close()
+            if resp, err = c.send("GET", endpoint, nil, nil); err != nil {
+                return nil, err
+            }
+        }
+        defer resp.Body.Close()
+
+        body, err := io.ReadAll(resp.Body)
+        if err != nil {
+            return nil, err
+        }
+        entry := CacheEntry{Route: route, Status: resp.StatusCode, Header: resp.Header, Body: body, Stored: time.Now()}
+        // Only whole answers are kept, never a bodiless 304
+        if resp.StatusCode == http.StatusOK {
+            c.Cache.Set(key, entry)
+        }
+        return entry, nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return v.(CacheEntry).response(), nil
+}
+
+// invalidate drops the cached answers a mutation made stale.
+func (c *Client) invalidate(method, endpoint string) {
+    stale := map[string]bool{}
+    for route, routes := range Invalidates {
+        if matchRoute(route, method, endpoint) {
+            for _, r := range routes {
+                stale[r] = true
+            }
+        }
+    }
+    if len(stale) == 0 {
+        return
+    }
+    for _, key := range c.Cache.Keys() {
+        if e, ok := c.Cache.Get(key); ok && stale[e.Route] {
+            c.Cache.Delete(key)
+        }
+    }
+}
+
+// Refresh marks everything cached as stale. The next requests revalidate,
+// so unchanged answers still come back as a cheap 304.
+func (c *Client) Refresh() {
+    if c.Cache == nil {
+        return
+    }
+    for _, key := range c.Cache.Keys() {
+        if e, ok := c.Cache.Get(key); ok {
+            e.Stored = time.Time{}
+            c.Cache.Set(key, e)
+        }
+    }
+}
+
+// matchRoute reports whether a request to endpoint falls under route,
+// a pattern like "GET /users/{u}".
+func matchRoute(route, method, endpoint string) bool {
+    m, pattern, _ := strings.Cut(route, " ")
+    path, _, _ := strings.Cut(endpoint, "?")
+    parts := strings.Split(strings.Trim(pattern, "/"), "/")
+    segments := strings.Split(strings.Trim(path, "/"), "/")
+    if m != method || len(parts) != len(segments) {
+        return false
+    }
+    for i, part := range parts {
+        if !strings.HasPrefix(part, "{") && part != segments[i] {
+            return false
+        }
+    }
+    return true
+}
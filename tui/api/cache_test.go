@@ -0,0 +1,203 @@
+package api
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// bookServer answers GET /books/1 with an ETag and counts the requests.
+// notModified decides, per request number, to answer 304 whatever was sent.
+type bookServer struct {
+    hits        atomic.Int32
+    etag        string
+    delay       time.Duration
+    notModified func(n int32) bool
+}
+
+func (s *bookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    n := s.hits.Add(1)
+    time.Sleep(s.delay)
+    if r.Method == http.MethodPost {
+        w.Write([]byte(`{"status":"ok"}`))
+        return
+    }
+    w.Header().Set("ETag", s.etag)
+    if r.Header.Get("If-None-Match") == s.etag || (s.notModified != nil && s.notModified(n)) {
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+    w.Write([]byte(`{"id":1,"name":"The Silent Horizon","author":"Ava Mitchell","year":2012,"pages":384}`))
+}
+
+func newCachedClient(t *testing.T, srv http.Handler) *Client {
+    t.Helper()
+    ts := httptest.NewServer(srv)
+    t.Cleanup(ts.Close)
+    c := NewClient(ts.URL, time.Second)
+    c.Token = "demo"
+    return c
+}
+
+func TestCacheFreshAndRevalidated(t *testing.T) {
+    srv := &bookServer{etag: `"v1"`}
+    c := newCachedClient(t, srv)
+
+    for i := 0; i < 3; i++ {
+        if book, err := c.GetBook(1); err != nil || book.Name != "The Silent Horizon" {
+            t.Fatalf("GetBook = %+v, %v", book, err)
+        }
+    }
+    if n := srv.hits.Load(); n != 1 {
+        t.Fatalf("%d requests while fresh, want 1", n)
+    }
+
+    // Stale answers are revalidated, a 304 keeps the body we have
+    c.Refresh()
+    book, err := c.GetBook(1)
+    if err != nil || book.Name != "The Silent Horizon" || srv.hits.Load() != 2 {
+        t.Fatalf("GetBook after Refresh = %+v, %v, %d requests", book, err, srv.hits.Load())
+    }
+}
+
+// A 304 with nothing cached to reuse, from a proxy or a cache that dropped
+// the entry, is asked again without validators instead of being decoded.
+func TestCache304WithoutEntry(t *testing.T) {
+    srv := &bookServer{etag: `"v1"`, notModified: func(n int32) bool { return n == 1 }}
+    c := newCachedClient(t, srv)
+
+    book, err := c.GetBook(1)
+    if err != nil || book.ID != 1 {
+        t.Fatalf("GetBook = %+v, %v", book, err)
+    }
+    if n := srv.hits.Load(); n != 2 {
+        t.Fatalf("%d requests, want the 304 and one retry", n)
+    }
+    for _, key := range c.Cache.Keys() {
+        if e, _ := c.Cache.Get(key); e.Status != http.StatusOK || len(e.Body) == 0 {
+            t.Fatalf("cached %d with %d bytes", e.Status, len(e.Body))
+        }
+    }
+
+    // Always 304: the retry's answer is returned as an error, not cached
+    srv.notModified = func(int32) bool { return true }
+    c.Cache = NewMemoryCache(DefaultCacheBytes)
+    if _, err := c.GetBook(1); err == nil {
+        t.Fatal("GetBook succeeded on nothing but 304s")
+    }
+    if keys := c.Cache.Keys(); len(keys) != 0 {
+        t.Fatalf("a 304 was cached: %v", keys)
+    }
+}
+
+func TestCacheInvalidatedByMutation(t *testing.T) {
+    srv := &bookServer{etag: `"v1"`}
+    c := newCachedClient(t, srv)
+
+    c.GetBook(1)
+    if err := c.AddReview(1, "Good", 4); err != nil {
+        t.Fatal(err)
+    }
+    c.GetBook(1)
+    // GET, POST, then a GET again since the review made the book stale
+    if n := srv.hits.Load(); n != 3 {
+        t.Fatalf("%d requests, want 3", n)
+    }
+}
+
+func TestCacheSharesRequestsInFlight(t *testing.T) {
+    srv := &bookServer{etag: `"v1"`, delay: 50 * time.Millisecond}
+    c := newCachedClient(t, srv)
+
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := c.GetBook(1); err != nil {
+                t.Error(err)
+            }
+        }()
+    }
+    wg.Wait()
+    if n := srv.hits.Load(); n != 1 {
+        t.Fatalf("%d requests for 10 concurrent GetBook, want 1", n)
+    }
+}
+
+func TestMemoryCacheLimit(t *testing.T) {
+    entry := func(n int) CacheEntry { return CacheEntry{Body: make([]byte, n)} }
+    c := NewMemoryCache(100)
+
+    c.Set("a", entry(30)) // each key adds its one byte
+    c.Set("b", entry(30))
+    c.Set("c", entry(30))
+    c.Get("a") // a is used again, b is now the oldest
+    c.Set("d", entry(30))
+    for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+        if _, ok := c.Get(key); ok != want {
+            t.Errorf("%q cached = %v, want %v", key, ok, want)
+        }
+    }
+
+    // Replacing an entry counts only the new one
+    c.Set("a", entry(60))
+    if _, ok := c.Get("d"); !ok {
+        t.Error("replacing a evicted d")
+    }
+    if _, ok := c.Get("c"); ok {
+        t.Error("c outlived the bigger a")
+    }
+
+    // An answer bigger than the whole cache is not kept, nor does it evict
+    c.Set("huge", entry(200))
+    if _, ok := c.Get("huge"); ok || len(c.Keys()) != 2 {
+        t.Errorf("after a huge entry: %v", c.Keys())
+    }
+    c.Delete("a")
+    c.Set("e", entry(60))
+    if len(c.Keys()) != 2 {
+        t.Errorf("Delete did not free the room of a: %v", c.Keys())
+    }
+}
+
+// Catalog pages stream past the cache, every request reaches the backend.
+func TestBooksNotCached(t *testing.T) {
+    var hits atomic.Int32
+    c := newCachedClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        hits.Add(1)
+        w.Header().Set("ETag", `"v1"`)
+        w.Write([]byte(`[{"id":1,"name":"The Silent Horizon","author":"Ava Mitchell","year":2012,"pages":384}]`))
+    }))
+    for i := 0; i < 2; i++ {
+        if page, err := c.ListBooksPage(0, 20); err != nil || len(page.Books) != 1 {
+            t.Fatalf("ListBooksPage = %+v, %v", page, err)
+        }
+    }
+    if n := hits.Load(); n != 2 || len(c.Cache.Keys()) != 0 {
+        t.Fatalf("%d requests, %d cached", n, len(c.Cache.Keys()))
+    }
+}
+
+func TestMatchRoute(t *testing.T) {
+    tests := []struct {
+        route, method, endpoint string
+        want                    bool
+    }{
+        {"GET /books", "GET", "/books?offset=20&limit=20", true},
+        {"GET /books/{id}", "GET", "/books/12", true},
+        {"GET /books/{id}", "GET", "/books", false},
+        {"GET /books/{id}", "POST", "/books/12", false},
+        {"GET /users/{u}/reading", "GET", "/users/demo/reading", true},
+        {"GET /users/{u}", "GET", "/users/demo/reading", false},
+        {"POST /libraries/{id}/move", "POST", "/libraries/1/move?username=demo&book_id=3&shelf=read", true},
+    }
+    for _, tt := range tests {
+        if got := matchRoute(tt.route, tt.method, tt.endpoint); got != tt.want {
+            t.Errorf("matchRoute(%q, %s %s) = %v, want %v", tt.route, tt.method, tt.endpoint, got, tt.want)
+        }
+    }
+}
@@ -6,22 +6,43 @@ import (
     "fmt"
     "io"
     "net/http"
+    "net/url"
+    "strconv"
     "time"
     "tui/types"
+
+    "golang.org/x/sync/singleflight"
 )
 
 type Client struct {
     BaseURL    string
     Token      string
     HTTPClient *http.Client
+
+    // StrictDecode turns responses that drift from the wire types into
+    // ContractErrors instead of silently dropping or zeroing fields.
+    StrictDecode bool
+
+    // Cache holds GET responses for as long as TTLs says, per route.
+    // A nil Cache turns caching off.
+    Cache  Cache
+    TTLs   map[string]time.Duration
+    flight singleflight.Group
 }
 
-func NewClient(baseURL string) *Client {
+func NewClient(baseURL string, timeout time.Duration) *Client {
+    ttls := make(map[string]time.Duration, len(DefaultTTLs))
+    for route, ttl := range DefaultTTLs {
+        ttls[route] = ttl
+    }
+
     return &Client{
         BaseURL: baseURL,
         HTTPClient: &http.Client{
-            Timeout: 10 * time.Second,
+            Timeout: timeout,
         },
+        Cache: NewMemoryCache(DefaultCacheBytes),
+        TTLs:  ttls,
     }
 }
 
@@ -29,7 +50,45 @@ func (c *Client) SetToken(token string) {
     c.Token = token
 }
 
+// Ping checks that the backend is reachable and reports the round-trip time.
+// It asks /health, which touches no state. Any response below 500 counts as
+// reachable, so a backend from before /health still answers with a 404.
+func (c *Client) Ping() (time.Duration, error) {
+    start := time.Now()
+
+    resp, err := c.doRequest("GET", "/health", nil)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+
+    latency := time.Since(start)
+    if resp.StatusCode >= 500 {
+        return latency, fmt.Errorf("server error: %s", resp.Status)
+    }
+
+    return latency, nil
+}
+
 func (c *Client) doRequest(method, endpoint string, body interface{}) (*http.Response, error) {
+    if c.Cache != nil && method == "GET" {
+        for route, ttl := range c.TTLs {
+            if matchRoute(route, method, endpoint) {
+                return c.cachedGet(route, endpoint, ttl)
+            }
+        }
+    }
+
+    resp, err := c.send(method, endpoint, body, nil)
+    if err == nil && c.Cache != nil && method != "GET" && resp.StatusCode < 300 {
+        c.invalidate(method, endpoint)
+    }
+    return resp, err
+}
+
+// send makes one round trip, with extra headers on top of the usual ones.
+func (c *Client) send(method, endpoint string, body interface{}, header http.Header) (*http.Response, error) {
     var reqBody io.Reader
 
     if body != nil {
@@ -49,6 +108,9 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) (*http.Res
     if c.Token != "" {
         req.Header.Set("Authorization", "Bearer "+c.Token)
     }
+    for name, values := range header {
+        req.Header[name] = values
+    }
 
     return c.HTTPClient.Do(req)
 }
@@ -66,66 +128,113 @@ func (c *Client) Login(username, password string) (string, error) {
     }
     defer resp.Body.Close()
 
-    var result struct {
-        Token string `json:"token"`
+    var result wireLogin
+    if err := c.decode(resp, "POST /auth/login", &result); err != nil {
+        if apiErr, ok := err.(*APIError); ok && apiErr.Status == http.StatusUnauthorized {
+            return "", errWrongPassword
+        }
+        return "", err
     }
 
-    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-        return "", err
+    c.Token = result.Token
+    return result.Token, nil
+}
+
+// Register creates an account, with its "My Library", on the backend.
+func (c *Client) Register(username, displayName, password string) error {
+    data := map[string]string{
+        "username":     username,
+        "display_name": displayName,
+        "password":     password,
     }
 
-    if resp.StatusCode != 200 {
-        return "", fmt.Errorf("login failed")
+    resp, err := c.doRequest("POST", "/register", data)
+    if err != nil {
+        return err
     }
+    defer resp.Body.Close()
 
-    c.Token = result.Token
-    return result.Token, nil
+    var result map[string]interface{}
+    return c.decode(resp, "POST /register", &result)
 }
 
 func (c *Client) GetCurrentUser() (types.User, error) {
-    resp, err := c.doRequest("GET", "/me", nil)
+    resp, err := c.doRequest("GET", "/me?token="+url.QueryEscape(c.Token), nil)
     if err != nil {
         return types.User{}, err
     }
     defer resp.Body.Close()
 
-    var user types.User
-    if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+    var user wireUser
+    if err := c.decode(resp, "GET /me", &user); err != nil {
         return types.User{}, err
     }
 
-    return user, nil
+    return user.toUser(), nil
 }
 
 // Books endpoints
 func (c *Client) ListBooks() ([]types.Book, error) {
-    resp, err := c.doRequest("GET", "/books", nil)
+    page, err := c.listBooks("/books")
+    return page.Books, err
+}
+
+// ListBooksPage fetches limit books starting at offset.
+func (c *Client) ListBooksPage(offset, limit int) (types.BookPage, error) {
+    page, err := c.listBooks(fmt.Sprintf("/books?offset=%d&limit=%d", offset, limit))
+    page.Offset = offset
+    return page, err
+}
+
+func (c *Client) listBooks(endpoint string) (types.BookPage, error) {
+    resp, err := c.doRequest("GET", endpoint, nil)
     if err != nil {
-        return nil, err
+        return types.BookPage{}, err
     }
     defer resp.Body.Close()
 
-    var books []types.Book
-    if err := json.NewDecoder(resp.Body).Decode(&books); err != nil {
-        return nil, err
+    page := types.BookPage{Total: -1, Books: []types.Book{}}
+    if n, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+        page.Total = n
     }
 
-    return books, nil
+    var b wireBook
+    err = c.decodeEach(resp, "GET /books", &b, func() error {
+        page.Books = append(page.Books, b.toBook())
+        return nil
+    })
+    if err != nil {
+        return types.BookPage{}, err
+    }
+    return page, nil
 }
 
 func (c *Client) GetBook(bookID int) (types.Book, error) {
-    resp, err := c.doRequest("GET", fmt.Sprintf("/books/%d", bookID), nil)
+    details, err := c.GetBookDetails(bookID)
     if err != nil {
         return types.Book{}, err
     }
+    return details.Book, nil
+}
+
+// GetBookDetails returns a book together with its reviews.
+func (c *Client) GetBookDetails(bookID int) (types.BookData, error) {
+    resp, err := c.doRequest("GET", fmt.Sprintf("/books/%d", bookID), nil)
+    if err != nil {
+        return types.BookData{}, err
+    }
     defer resp.Body.Close()
 
-    var book types.Book
-    if err := json.NewDecoder(resp.Body).Decode(&book); err != nil {
-        return types.Book{}, err
+    var book wireBook
+    if err := c.decode(resp, "GET /books/{id}", &book); err != nil {
+        return types.BookData{}, err
     }
 
-    return book, nil
+    data := types.BookData{Book: book.toBook()}
+    for _, r := range book.Reviews {
+        data.Reviews = append(data.Reviews, r.toReview())
+    }
+    return data, nil
 }
 
 func (c *Client) AddReview(bookID int, text string, rating int) error {
@@ -156,32 +265,34 @@ func (c *Client) GetUserLibraries(username string) ([]types.Library, error) {
     }
     defer resp.Body.Close()
 
-    var libraries []types.Library
-    if err := json.NewDecoder(resp.Body).Decode(&libraries); err != nil {
+    var result []wireLibrary
+    if err := c.decode(resp, "GET /users/{u}/libraries", &result); err != nil {
         return nil, err
     }
 
+    libraries := make([]types.Library, 0, len(result))
+    for _, lib := range result {
+        libraries = append(libraries, lib.toLibrary())
+    }
     return libraries, nil
 }
 
+// CreateLibrary and the shelf endpoints below take their fields from the
+// query string, not a JSON body, as the backend declares them.
 func (c *Client) CreateLibrary(name string) (int, error) {
-    data := map[string]string{
-        "username": c.Token,
-        "name":     name,
+    query := url.Values{
+        "username": {c.Token},
+        "name":     {name},
     }
 
-    resp, err := c.doRequest("POST", "/libraries", data)
+    resp, err := c.doRequest("POST", "/libraries?"+query.Encode(), nil)
     if err != nil {
         return 0, err
     }
     defer resp.Body.Close()
 
-    var result struct {
-        ID   int    `json:"id"`
-        Name string `json:"name"`
-    }
-
-    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+    var result wireCreatedLibrary
+    if err := c.decode(resp, "POST /libraries", &result); err != nil {
         return 0, err
     }
 
@@ -189,13 +300,13 @@ func (c *Client) CreateLibrary(name string) (int, error) {
 }
 
 func (c *Client) AddBookToLibrary(libraryID, bookID int, shelf string) error {
-    data := map[string]interface{}{
-        "username": c.Token,
-        "book_id":  bookID,
-        "shelf":    shelf,
+    query := url.Values{
+        "username": {c.Token},
+        "book_id":  {strconv.Itoa(bookID)},
+        "shelf":    {shelf},
     }
 
-    resp, err := c.doRequest("POST", fmt.Sprintf("/libraries/%d/books", libraryID), data)
+    resp, err := c.doRequest("POST", fmt.Sprintf("/libraries/%d/books?%s", libraryID, query.Encode()), nil)
     if err != nil {
         return err
     }
@@ -208,6 +319,24 @@ func (c *Client) AddBookToLibrary(libraryID, bookID int, shelf string) error {
     return nil
 }
 
+// MoveBook takes a book that is already in the library to another shelf.
+func (c *Client) MoveBook(libraryID, bookID int, shelf string) error {
+    query := url.Values{
+        "username": {c.Token},
+        "book_id":  {strconv.Itoa(bookID)},
+        "shelf":    {shelf},
+    }
+
+    resp, err := c.doRequest("POST", fmt.Sprintf("/libraries/%d/move?%s", libraryID, query.Encode()), nil)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    var result map[string]interface{}
+    return c.decode(resp, "POST /libraries/{id}/move", &result)
+}
+
 // Reading endpoints
 func (c *Client) StartReading(bookID int) error {
     data := map[string]interface{}{
@@ -257,7 +386,7 @@ func (c *Client) GetActiveReading() ([]map[string]interface{}, error) {
     defer resp.Body.Close()
 
     var sessions []map[string]interface{}
-    if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+    if err := c.decode(resp, "GET /users/{u}/reading", &sessions); err != nil {
         return nil, err
     }
 
@@ -286,12 +415,12 @@ func (c *Client) GetUser(username string) (types.User, error) {
     }
     defer resp.Body.Close()
 
-    var user types.User
-    if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+    var user wireUser
+    if err := c.decode(resp, "GET /users/{u}", &user); err != nil {
         return types.User{}, err
     }
 
-    return user, nil
+    return user.toUser(), nil
 }
 
 // Recommendations endpoints
@@ -323,10 +452,14 @@ func (c *Client) GetRecommendations() ([]types.Recommendation, error) {
     }
     defer resp.Body.Close()
 
-    var recommendations []types.Recommendation
-    if err := json.NewDecoder(resp.Body).Decode(&recommendations); err != nil {
+    var result []wireRecommendation
+    if err := c.decode(resp, "GET /users/{u}/recommendations", &result); err != nil {
         return nil, err
     }
 
+    recommendations := make([]types.Recommendation, 0, len(result))
+    for _, r := range result {
+        recommendations = append(recommendations, r.toRecommendation())
+    }
     return recommendations, nil
 }
\ No newline at end of file
@@ -0,0 +1,227 @@
+package api
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "reflect"
+    "sort"
+    "strings"
+)
+
+// APIError is a non-2xx answer from the backend.
+type APIError struct {
+    Endpoint string
+    Status   int
+    Detail   string
+}
+
+func (e *APIError) Error() string {
+    if e.Detail != "" {
+        return fmt.Sprintf("%s: %d %s", e.Endpoint, e.Status, e.Detail)
+    }
+    return fmt.Sprintf("%s: %d %s", e.Endpoint, e.Status, http.StatusText(e.Status))
+}
+
+// errWrongPassword is what every backend answers a failed login with.
+var errWrongPassword = &APIError{Endpoint: "POST /auth/login", Status: http.StatusUnauthorized, Detail: "wrong username or password"}
+
+// ContractError reports where a response drifted from the wire types.
+// It is only returned when strict decoding is switched on.
+type ContractError struct {
+    Endpoint string
+    Unknown  []string // fields the backend sent that we don't know about
+    Missing  []string // fields we expect that the backend left out
+}
+
+func (e *ContractError) Error() string {
+    var parts []string
+    if len(e.Unknown) > 0 {
+        parts = append(parts, "unknown fields: "+strings.Join(e.Unknown, ", "))
+    }
+    if len(e.Missing) > 0 {
+        parts = append(parts, "missing fields: "+strings.Join(e.Missing, ", "))
+    }
+    return fmt.Sprintf("%s: contract mismatch (%s)", e.Endpoint, strings.Join(parts, "; "))
+}
+
+// decode checks the status code and unmarshals the body into v,
+// which must be a pointer to one of the wire types.
+func (c *Client) decode(resp *http.Response, endpoint string, v interface{}) error {
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return statusError(resp, endpoint)
+    }
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return err
+    }
+
+    if err := json.Unmarshal(body, v); err != nil {
+        return fmt.Errorf("%s: %w", endpoint, err)
+    }
+
+    if c.StrictDecode {
+        var raw interface{}
+        if err := json.Unmarshal(body, &raw); err != nil {
+            return fmt.Errorf("%s: %w", endpoint, err)
+        }
+
+        check := contractCheck{unknown: map[string]bool{}, missing: map[string]bool{}}
+        check.walk("", raw, reflect.TypeOf(v).Elem())
+        if len(check.unknown) > 0 || len(check.missing) > 0 {
+            return &ContractError{
+                Endpoint: endpoint,
+                Unknown:  sortedKeys(check.unknown),
+                Missing:  sortedKeys(check.missing),
+            }
+        }
+    }
+
+    return nil
+}
+
+// decodeEach reads a JSON array one element at a time into elem, a pointer
+// to a wire type, calling each after every element. Only one element is in
+// memory at once, so a catalog of 100k books starts arriving right away
+// instead of after the whole body was read.
+func (c *Client) decodeEach(resp *http.Response, endpoint string, elem interface{}, each func() error) error {
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return statusError(resp, endpoint)
+    }
+
+    dec := json.NewDecoder(resp.Body)
+    if tok, err := dec.Token(); err != nil {
+        return fmt.Errorf("%s: %w", endpoint, err)
+    } else if tok != json.Delim('[') {
+        return fmt.Errorf("%s: expected an array, got %v", endpoint, tok)
+    }
+
+    check := contractCheck{unknown: map[string]bool{}, missing: map[string]bool{}}
+    t := reflect.TypeOf(elem).Elem()
+    zero := reflect.Zero(t)
+    for dec.More() {
+        var raw json.RawMessage
+        if err := dec.Decode(&raw); err != nil {
+            return fmt.Errorf("%s: %w", endpoint, err)
+        }
+        reflect.ValueOf(elem).Elem().Set(zero)
+        if err := json.Unmarshal(raw, elem); err != nil {
+            return fmt.Errorf("%s: %w", endpoint, err)
+        }
+        if c.StrictDecode {
+            var data interface{}
+            if err := json.Unmarshal(raw, &data); err != nil {
+                return fmt.Errorf("%s: %w", endpoint, err)
+            }
+            check.walk("[]", data, t)
+        }
+        if err := each(); err != nil {
+            return err
+        }
+    }
+    if _, err := dec.Token(); err != nil {
+        return fmt.Errorf("%s: %w", endpoint, err)
+    }
+
+    if len(check.unknown) > 0 || len(check.missing) > 0 {
+        return &ContractError{
+            Endpoint: endpoint,
+            Unknown:  sortedKeys(check.unknown),
+            Missing:  sortedKeys(check.missing),
+        }
+    }
+    return nil
+}
+
+// statusError turns a non-2xx answer into an *APIError, with FastAPI's
+// "detail" when there is one.
+func statusError(resp *http.Response, endpoint string) error {
+    apiErr := &APIError{Endpoint: endpoint, Status: resp.StatusCode}
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return apiErr
+    }
+    var detail struct {
+        Detail interface{} `json:"detail"`
+    }
+    if json.Unmarshal(body, &detail) == nil && detail.Detail != nil {
+        apiErr.Detail = fmt.Sprint(detail.Detail)
+    }
+    return apiErr
+}
+
+// contractCheck walks a decoded JSON value alongside the Go type it was
+// decoded into. Array elements share one path ("[].name") so a catalog of
+// fifty books reports a drifted field once, not fifty times.
+type contractCheck struct {
+    unknown map[string]bool
+    missing map[string]bool
+}
+
+func (cc contractCheck) walk(path string, data interface{}, t reflect.Type) {
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    if data == nil {
+        return
+    }
+
+    switch t.Kind() {
+    case reflect.Slice:
+        items, ok := data.([]interface{})
+        if !ok {
+            return
+        }
+        for _, item := range items {
+            cc.walk(path+"[]", item, t.Elem())
+        }
+
+    case reflect.Struct:
+        object, ok := data.(map[string]interface{})
+        if !ok {
+            return
+        }
+
+        known := map[string]bool{}
+        for i := 0; i < t.NumField(); i++ {
+            field := t.Field(i)
+            name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+            if name == "" || name == "-" {
+                continue
+            }
+            known[name] = true
+
+            value, present := object[name]
+            switch {
+            case present:
+                cc.walk(join(path, name), value, field.Type)
+            case !strings.Contains(opts, "omitempty"):
+                cc.missing[join(path, name)] = true
+            }
+        }
+
+        for name := range object {
+            if !known[name] {
+                cc.unknown[join(path, name)] = true
+            }
+        }
+    }
+}
+
+func join(path, name string) string {
+    if path == "" {
+        return name
+    }
+    return path + "." + name
+}
+
+func sortedKeys(set map[string]bool) []string {
+    keys := make([]string, 0, len(set))
+    for key := range set {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func response(status int, body string) *http.Response {
+    return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+const bookJSON = `{"id": 1, "name": "Dune", "author": "Frank Herbert", "year": 1965, "language": "English",
+    "publisher": "Chilton Books", "pages": 412, "avg_rating": null%s}`
+
+func TestDecodeStrict(t *testing.T) {
+    tests := []struct {
+        name             string
+        body             string
+        unknown, missing string
+    }{
+        {"matches", strings.Replace(bookJSON, "%s", "", 1), "", ""},
+        {"optional field", strings.Replace(bookJSON, "%s", `, "reviews": []`, 1), "", ""},
+        {"new field", strings.Replace(bookJSON, "%s", `, "isbn": "x"`, 1), "isbn", ""},
+        {"dropped field", `{"id": 1, "name": "Dune", "author": "", "year": 0, "language": "", "publisher": "", "avg_rating": 4.5}`, "", "pages"},
+        {"nested", strings.Replace(bookJSON, "%s", `, "reviews": [{"user": "a", "rating": 5, "text": "", "likes": 0, "edited": true}, {"user": "b", "rating": 4, "text": "", "likes": 0, "edited": false}]`, 1), "reviews[].edited", ""},
+    }
+    for _, tt := range tests {
+        var book wireBook
+        c := &Client{StrictDecode: true}
+        err := c.decode(response(200, tt.body), "GET /books/{id}", &book)
+
+        var contract *ContractError
+        if tt.unknown == "" && tt.missing == "" {
+            if err != nil {
+                t.Errorf("%s: %v", tt.name, err)
+            }
+            continue
+        }
+        if !errors.As(err, &contract) || strings.Join(contract.Unknown, ",") != tt.unknown || strings.Join(contract.Missing, ",") != tt.missing {
+            t.Errorf("%s: err = %v, want unknown %q, missing %q", tt.name, err, tt.unknown, tt.missing)
+        }
+        if book.Name != "Dune" {
+            t.Errorf("%s: the book was not decoded: %+v", tt.name, book)
+        }
+
+        // Without strict mode drift goes unnoticed
+        c.StrictDecode = false
+        if err := c.decode(response(200, tt.body), "GET /books/{id}", &book); err != nil {
+            t.Errorf("%s: lenient decode: %v", tt.name, err)
+        }
+    }
+}
+
+// A drifted field in a list is reported once, not once per item.
+func TestDecodeStrictList(t *testing.T) {
+    c := &Client{StrictDecode: true}
+    var libraries []wireLibrary
+    err := c.decode(response(200, `[{"id": 1, "name": "a", "shelves": {}, "owner": "x"}, {"id": 2, "name": "b", "shelves": {}, "owner": "y"}]`), "GET /users/{u}/libraries", &libraries)
+    var contract *ContractError
+    if !errors.As(err, &contract) || strings.Join(contract.Unknown, ",") != "[].owner" || len(libraries) != 2 {
+        t.Fatalf("err = %v, libraries %+v", err, libraries)
+    }
+    if want := "GET /users/{u}/libraries: contract mismatch (unknown fields: [].owner)"; err.Error() != want {
+        t.Errorf("Error() = %q, want %q", err, want)
+    }
+}
+
+func TestDecodeErrors(t *testing.T) {
+    tests := []struct {
+        status int
+        body   string
+        want   string
+    }{
+        {404, `{"detail": "Book not found"}`, "GET /books/{id}: 404 Book not found"},
+        {401, `{"detail": "Invalid token"}`, "GET /books/{id}: 401 Invalid token"},
+        {500, `Internal Server Error`, "GET /books/{id}: 500 Internal Server Error"},
+        {422, `{"detail": [{"loc": ["body", "rating"]}]}`, "GET /books/{id}: 422 [map[loc:[body rating]]]"},
+    }
+    for _, tt := range tests {
+        var book wireBook
+        err := (&Client{}).decode(response(tt.status, tt.body), "GET /books/{id}", &book)
+        var apiErr *APIError
+        if !errors.As(err, &apiErr) || apiErr.Status != tt.status || err.Error() != tt.want {
+            t.Errorf("%d: err = %v, want %q", tt.status, err, tt.want)
+        }
+    }
+
+    var book wireBook
+    if err := (&Client{}).decode(response(200, `{"id": "one"}`), "GET /books/{id}", &book); err == nil || !strings.HasPrefix(err.Error(), "GET /books/{id}: ") {
+        t.Errorf("bad JSON: %v", err)
+    }
+}
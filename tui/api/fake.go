@@ -0,0 +1,518 @@
+package api
+
+import (
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "tui/types"
+)
+
+// Demo account seeded into every Fake.
+const (
+    DemoUsername = "demo"
+    DemoPassword = "demo"
+)
+
+// Fake is an in-memory Service for tests and --demo mode.
+// It follows the FastAPI backend's rules closely enough that the UI
+// can't tell the difference: the token is the username, reading past
+// the last page ends the session, errors are *APIError with real statuses.
+type Fake struct {
+    mu sync.Mutex
+
+    token       string
+    books       map[int]types.Book
+    reviews     map[int][]types.Review
+    users       map[string]*fakeUser
+    libraries   map[int]*fakeLibrary
+    nextLibrary int
+}
+
+type fakeUser struct {
+    user            types.User
+    password        string
+    libraries       []int
+    sessions        map[int]map[string]interface{}
+    recommendations []types.Recommendation
+}
+
+type fakeLibrary struct {
+    id      int
+    name    string
+    owner   string
+    shelves map[string][]int
+}
+
+// NewFake returns a Fake holding the seed catalog, the demo user with a
+// small library and a couple of friends to recommend books.
+func NewFake() *Fake {
+    f := &Fake{
+        books:       map[int]types.Book{},
+        reviews:     map[int][]types.Review{},
+        users:       map[string]*fakeUser{},
+        libraries:   map[int]*fakeLibrary{},
+        nextLibrary: 1,
+    }
+
+    for _, book := range SeedBooks {
+        f.books[book.ID] = book
+    }
+
+    f.addUser(DemoUsername, "Demo Reader", DemoPassword)
+    f.addUser("ada", "Ada Lovelace", "ada")
+    f.addUser("ben", "Ben Okafor", "ben")
+    f.befriend(DemoUsername, "ada")
+    f.befriend(DemoUsername, "ben")
+
+    f.addLibrary(DemoUsername, "My Library", map[string][]int{
+        "to_read":           {1, 5, 17},
+        "currently_reading": {3},
+        "read":              {7, 20},
+    })
+    f.users[DemoUsername].sessions[3] = f.newSession(DemoUsername, 3)
+    f.users[DemoUsername].sessions[3]["current_page"] = 112
+
+    f.reviews[7] = []types.Review{
+        {User: DemoUsername, Rating: 4, Text: "Quiet and clever."},
+        {User: "ada", Rating: 5, Text: "Read it twice in a week."},
+    }
+    f.reviews[20] = []types.Review{{User: "ben", Rating: 3, Text: "Beautiful, but slow."}}
+    f.users[DemoUsername].recommendations = []types.Recommendation{
+        {From: "ada", Book: f.books[33].Name, Message: "You'll love the footnotes", Date: "2024-03-02T10:15:00"},
+    }
+
+    return f
+}
+
+func (f *Fake) addUser(username, displayName, password string) {
+    f.users[username] = &fakeUser{
+        user:     types.User{Username: username, DisplayName: displayName},
+        password: password,
+        sessions: map[int]map[string]interface{}{},
+    }
+}
+
+func (f *Fake) befriend(a, b string) {
+    f.users[a].user.Friends = append(f.users[a].user.Friends, b)
+    f.users[b].user.Friends = append(f.users[b].user.Friends, a)
+}
+
+func (f *Fake) addLibrary(owner, name string, shelves map[string][]int) int {
+    id := f.nextLibrary
+    f.nextLibrary++
+
+    if shelves == nil {
+        shelves = map[string][]int{}
+    }
+    for _, shelf := range []string{"to_read", "currently_reading", "read"} {
+        if _, ok := shelves[shelf]; !ok {
+            shelves[shelf] = []int{}
+        }
+    }
+
+    f.libraries[id] = &fakeLibrary{id: id, name: name, owner: owner, shelves: shelves}
+    f.users[owner].libraries = append(f.users[owner].libraries, id)
+    return id
+}
+
+func (f *Fake) newSession(username string, bookID int) map[string]interface{} {
+    return map[string]interface{}{
+        "user":         username,
+        "book_id":      bookID,
+        "current_page": 1,
+        "started_at":   time.Now().Format("2006-01-02T15:04:05"),
+        "last_read_at": nil,
+    }
+}
+
+// currentUser resolves the token like require_user does on the backend.
+func (f *Fake) currentUser(endpoint string) (*fakeUser, error) {
+    user, ok := f.users[f.token]
+    if !ok {
+        return nil, &APIError{Endpoint: endpoint, Status: http.StatusUnauthorized}
+    }
+    return user, nil
+}
+
+func notFound(endpoint string) error {
+    return &APIError{Endpoint: endpoint, Status: http.StatusNotFound}
+}
+
+func (f *Fake) userView(u *fakeUser) types.User {
+    user := u.user
+    user.Friends = append([]string(nil), u.user.Friends...)
+    user.Libraries = nil
+    for _, id := range u.libraries {
+        user.Libraries = append(user.Libraries, types.Library{Name: f.libraries[id].name})
+    }
+    return user
+}
+
+func (f *Fake) bookView(id int) types.Book {
+    book := f.books[id]
+    if reviews := f.reviews[id]; len(reviews) > 0 {
+        total := 0
+        for _, r := range reviews {
+            total += r.Rating
+        }
+        book.Rating = float64(total) / float64(len(reviews))
+    }
+    return book
+}
+
+func (f *Fake) SetToken(token string) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.token = token
+}
+
+func (f *Fake) Ping() (time.Duration, error) {
+    return time.Millisecond, nil
+}
+
+// Auth
+
+func (f *Fake) Login(username, password string) (string, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, ok := f.users[username]
+    if !ok || user.password != password {
+        return "", errWrongPassword
+    }
+    f.token = username
+    return username, nil
+}
+
+func (f *Fake) GetCurrentUser() (types.User, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, err := f.currentUser("GET /me")
+    if err != nil {
+        return types.User{}, err
+    }
+    return f.userView(user), nil
+}
+
+// Books
+
+func (f *Fake) ListBooks() ([]types.Book, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    ids := make([]int, 0, len(f.books))
+    for id := range f.books {
+        ids = append(ids, id)
+    }
+    sort.Ints(ids)
+
+    books := make([]types.Book, 0, len(ids))
+    for _, id := range ids {
+        books = append(books, f.bookView(id))
+    }
+    return books, nil
+}
+
+func (f *Fake) ListBooksPage(offset, limit int) (types.BookPage, error) {
+    books, _ := f.ListBooks()
+    return PageOf(books, offset, limit), nil
+}
+
+// PageOf cuts limit books starting at offset out of a whole catalog.
+func PageOf(books []types.Book, offset, limit int) types.BookPage {
+    start := min(max(offset, 0), len(books))
+    end := min(start+max(limit, 0), len(books))
+    return types.BookPage{Books: books[start:end], Offset: offset, Total: len(books)}
+}
+
+func (f *Fake) GetBook(bookID int) (types.Book, error) {
+    data, err := f.GetBookDetails(bookID)
+    return data.Book, err
+}
+
+func (f *Fake) GetBookDetails(bookID int) (types.BookData, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if _, ok := f.books[bookID]; !ok {
+        return types.BookData{}, notFound("GET /books/{id}")
+    }
+    return types.BookData{
+        Book:    f.bookView(bookID),
+        Reviews: append([]types.Review(nil), f.reviews[bookID]...),
+    }, nil
+}
+
+func (f *Fake) AddReview(bookID int, text string, rating int) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, err := f.currentUser("POST /books/{id}/reviews")
+    if err != nil {
+        return err
+    }
+    if _, ok := f.books[bookID]; !ok {
+        return notFound("POST /books/{id}/reviews")
+    }
+    if rating < 1 || rating > 5 {
+        return &APIError{Endpoint: "POST /books/{id}/reviews", Status: http.StatusUnprocessableEntity, Detail: "rating must be between 1 and 5"}
+    }
+
+    // One review per user and book, like the UNIQUE constraint in reviews.sql
+    review := types.Review{User: user.user.Username, Rating: rating, Text: text}
+    for i, r := range f.reviews[bookID] {
+        if r.User == review.User {
+            f.reviews[bookID][i] = review
+            return nil
+        }
+    }
+    f.reviews[bookID] = append(f.reviews[bookID], review)
+    return nil
+}
+
+// Libraries
+
+func (f *Fake) GetUserLibraries(username string) ([]types.Library, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, ok := f.users[username]
+    if !ok {
+        return nil, notFound("GET /users/{u}/libraries")
+    }
+
+    libraries := make([]types.Library, 0, len(user.libraries))
+    for _, id := range user.libraries {
+        lib := f.libraries[id]
+        shelves := make(map[string][]int, len(lib.shelves))
+        for shelf, ids := range lib.shelves {
+            shelves[shelf] = append([]int(nil), ids...)
+        }
+        libraries = append(libraries, types.Library{ID: lib.id, Name: lib.name, Books: shelves})
+    }
+    return libraries, nil
+}
+
+func (f *Fake) CreateLibrary(name string) (int, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if _, err := f.currentUser("POST /libraries"); err != nil {
+        return 0, err
+    }
+    return f.addLibrary(f.token, name, nil), nil
+}
+
+func (f *Fake) AddBookToLibrary(libraryID, bookID int, shelf string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    lib, err := f.shelving("POST /libraries/{id}/books", libraryID, bookID, shelf)
+    if err != nil {
+        return err
+    }
+    f.place(lib, bookID, shelf)
+    return nil
+}
+
+func (f *Fake) MoveBook(libraryID, bookID int, shelf string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    endpoint := "POST /libraries/{id}/move"
+    lib, err := f.shelving(endpoint, libraryID, bookID, shelf)
+    if err != nil {
+        return err
+    }
+    // Like the backend, only books already in the library can move
+    onShelf := false
+    for _, ids := range lib.shelves {
+        for _, id := range ids {
+            onShelf = onShelf || id == bookID
+        }
+    }
+    if !onShelf {
+        return &APIError{Endpoint: endpoint, Status: http.StatusBadRequest}
+    }
+    f.place(lib, bookID, shelf)
+    return nil
+}
+
+// shelving checks a book may go on a shelf of one of the user's libraries.
+// The caller holds f.mu.
+func (f *Fake) shelving(endpoint string, libraryID, bookID int, shelf string) (*fakeLibrary, error) {
+    if _, err := f.currentUser(endpoint); err != nil {
+        return nil, err
+    }
+    lib, ok := f.libraries[libraryID]
+    if !ok || lib.owner != f.token {
+        return nil, notFound(endpoint)
+    }
+    if _, ok := f.books[bookID]; !ok {
+        return nil, notFound(endpoint)
+    }
+    if _, ok := lib.shelves[shelf]; !ok {
+        return nil, &APIError{Endpoint: endpoint, Status: http.StatusBadRequest, Detail: "unknown shelf " + shelf}
+    }
+    return lib, nil
+}
+
+// place puts a book on a shelf, taking it off the one it was on: a book
+// lives on one shelf per library.
+func (f *Fake) place(lib *fakeLibrary, bookID int, shelf string) {
+    for name, ids := range lib.shelves {
+        for i, id := range ids {
+            if id == bookID {
+                lib.shelves[name] = append(ids[:i:i], ids[i+1:]...)
+                break
+            }
+        }
+    }
+    lib.shelves[shelf] = append(lib.shelves[shelf], bookID)
+}
+
+// Reading
+
+func (f *Fake) StartReading(bookID int) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, err := f.currentUser("POST /reading/start")
+    if err != nil {
+        return err
+    }
+    if _, ok := f.books[bookID]; !ok {
+        return notFound("POST /reading/start")
+    }
+    if _, ok := user.sessions[bookID]; !ok {
+        user.sessions[bookID] = f.newSession(f.token, bookID)
+    }
+    return nil
+}
+
+func (f *Fake) TurnPage(bookID int, direction string, count int) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, err := f.currentUser("POST /reading/turn")
+    if err != nil {
+        return err
+    }
+    session, ok := user.sessions[bookID]
+    if !ok {
+        return &APIError{Endpoint: "POST /reading/turn", Status: http.StatusBadRequest}
+    }
+
+    total := f.books[bookID].Pages
+    page := session["current_page"].(int)
+    switch direction {
+    case "forward":
+        page = min(total, page+count)
+    case "back":
+        page = max(1, page-count)
+    }
+    session["current_page"] = page
+    session["last_read_at"] = time.Now().Format("2006-01-02T15:04:05")
+
+    // Finishing the book ends the session
+    if page >= total {
+        delete(user.sessions, bookID)
+    }
+    return nil
+}
+
+func (f *Fake) GetActiveReading() ([]map[string]interface{}, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, ok := f.users[f.token]
+    if !ok {
+        return nil, notFound("GET /users/{u}/reading")
+    }
+
+    ids := make([]int, 0, len(user.sessions))
+    for id := range user.sessions {
+        ids = append(ids, id)
+    }
+    sort.Ints(ids)
+
+    sessions := make([]map[string]interface{}, 0, len(ids))
+    for _, id := range ids {
+        session := make(map[string]interface{}, len(user.sessions[id]))
+        for k, v := range user.sessions[id] {
+            session[k] = v
+        }
+        sessions = append(sessions, session)
+    }
+    return sessions, nil
+}
+
+// Friends and recommendations
+
+func (f *Fake) AddFriend(friendUsername string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    endpoint := "POST /users/{u}/friends/{v}"
+    user, err := f.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+    if _, ok := f.users[friendUsername]; !ok || friendUsername == f.token {
+        return notFound(endpoint)
+    }
+    for _, name := range user.user.Friends {
+        if name == friendUsername {
+            return nil
+        }
+    }
+    f.befriend(f.token, friendUsername)
+    return nil
+}
+
+func (f *Fake) GetUser(username string) (types.User, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, ok := f.users[username]
+    if !ok {
+        return types.User{}, notFound("GET /users/{u}")
+    }
+    return f.userView(user), nil
+}
+
+func (f *Fake) RecommendBook(toUser string, bookID int, message string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if _, err := f.currentUser("POST /recommend"); err != nil {
+        return err
+    }
+    to, ok := f.users[toUser]
+    book, found := f.books[bookID]
+    if !ok || !found {
+        return notFound("POST /recommend")
+    }
+
+    to.recommendations = append(to.recommendations, types.Recommendation{
+        From:    f.token,
+        Book:    book.Name,
+        Message: message,
+        Date:    time.Now().Format("2006-01-02T15:04:05"),
+    })
+    return nil
+}
+
+func (f *Fake) GetRecommendations() ([]types.Recommendation, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    user, ok := f.users[f.token]
+    if !ok {
+        return nil, notFound("GET /users/{u}/recommendations")
+    }
+    return append([]types.Recommendation(nil), user.recommendations...), nil
+}
@@ -0,0 +1,900 @@
+package api
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "tui/types"
+
+    "golang.org/x/crypto/bcrypt"
+    _ "modernc.org/sqlite"
+)
+
+// Local is a Service that reads and writes app.db directly, for running
+// the TUI on one machine without the Python API. It uses the same tables
+// as repositories.py, so both can share a database file.
+//
+// Login checks passwords against the bcrypt hashes api.py stores, so an
+// account made through the API keeps its password here. Accounts without a
+// hash, from databases older than password_hash, can't log in until
+// Register gives them a password. Register creates accounts like /register
+// does, with a "My Library".
+type Local struct {
+    mu sync.Mutex
+
+    db    *sql.DB
+    token string
+}
+
+// sqliteTime matches how Python's sqlite3 adapter stores datetimes.
+const sqliteTime = "2006-01-02 15:04:05.000000"
+
+// OpenLocal opens (or creates) the SQLite database at path. Missing tables
+// are created and an empty catalog is filled with the seed books.
+func OpenLocal(path string) (*Local, error) {
+    db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+    if err != nil {
+        return nil, err
+    }
+    // One connection keeps writes ordered and avoids SQLITE_BUSY between our own goroutines
+    db.SetMaxOpenConns(1)
+
+    l := &Local{db: db}
+    if err := l.init(); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("open %s: %w", path, err)
+    }
+    return l, nil
+}
+
+func (l *Local) Close() error {
+    return l.db.Close()
+}
+
+func (l *Local) init() error {
+    if _, err := l.db.Exec(localSchema); err != nil {
+        return err
+    }
+
+    // users.password_hash is in sql/users.sql but missing from older databases
+    var n int
+    if err := l.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'password_hash'").Scan(&n); err != nil {
+        return err
+    }
+    if n == 0 {
+        if _, err := l.db.Exec("ALTER TABLE users ADD COLUMN password_hash TEXT"); err != nil {
+            return err
+        }
+    }
+
+    var count int
+    if err := l.db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count); err != nil {
+        return err
+    }
+    if count > 0 {
+        return nil
+    }
+
+    tx, err := l.db.Begin()
+    if err != nil {
+        return err
+    }
+    for _, b := range SeedBooks {
+        _, err := tx.Exec(
+            "INSERT INTO books (id, name, author, year, language, publisher, total_pages) VALUES (?, ?, ?, ?, ?, ?, ?)",
+            b.ID, b.Name, b.Author, b.Year, b.Language, b.Publisher, b.Pages,
+        )
+        if err != nil {
+            tx.Rollback()
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// dbError wraps failures of the database itself, as opposed to *APIError
+// which reports the same conditions the backend would.
+func dbError(endpoint string, err error) error {
+    return fmt.Errorf("%s: local database: %w", endpoint, err)
+}
+
+// currentUser checks the token names an existing user, like require_user.
+func (l *Local) currentUser(endpoint string) (string, error) {
+    exists, err := l.userExists(l.token)
+    if err != nil {
+        return "", dbError(endpoint, err)
+    }
+    if !exists {
+        return "", &APIError{Endpoint: endpoint, Status: http.StatusUnauthorized}
+    }
+    return l.token, nil
+}
+
+func (l *Local) userExists(username string) (bool, error) {
+    var n int
+    err := l.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", username).Scan(&n)
+    return n > 0, err
+}
+
+// passwordHash is the user's bcrypt hash, empty for accounts that have none.
+func (l *Local) passwordHash(username string) (string, error) {
+    var hash string
+    err := l.db.QueryRow("SELECT COALESCE(password_hash, '') FROM users WHERE username = ?", username).Scan(&hash)
+    return hash, err
+}
+
+func (l *Local) bookExists(bookID int) (bool, error) {
+    var n int
+    err := l.db.QueryRow("SELECT COUNT(*) FROM books WHERE id = ?", bookID).Scan(&n)
+    return n > 0, err
+}
+
+func (l *Local) createUser(username, displayName, passwordHash string) error {
+    now := time.Now().Format(sqliteTime)
+
+    tx, err := l.db.Begin()
+    if err != nil {
+        return err
+    }
+    _, err = tx.Exec("INSERT INTO users (username, display_name, password_hash, created_at) VALUES (?, ?, ?, ?)", username, displayName, passwordHash, now)
+    if err == nil {
+        _, err = tx.Exec("INSERT INTO libraries (name, owner) VALUES ('My Library', ?)", username)
+    }
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+func (l *Local) userView(username string) (types.User, bool, error) {
+    var user types.User
+    var created interface{}
+    err := l.db.QueryRow("SELECT username, display_name, created_at FROM users WHERE username = ?", username).
+        Scan(&user.Username, &user.DisplayName, &created)
+    if errors.Is(err, sql.ErrNoRows) {
+        return user, false, nil
+    }
+    if err != nil {
+        return user, false, err
+    }
+    user.JoinedDate = timestamp(created)
+
+    friends, err := l.strings("SELECT user2 FROM friends WHERE user1 = ? ORDER BY user2", username)
+    if err != nil {
+        return user, false, err
+    }
+    user.Friends = friends
+
+    names, err := l.strings("SELECT name FROM libraries WHERE owner = ? ORDER BY id", username)
+    if err != nil {
+        return user, false, err
+    }
+    for _, name := range names {
+        user.Libraries = append(user.Libraries, types.Library{Name: name})
+    }
+    return user, true, nil
+}
+
+func (l *Local) strings(query string, args ...interface{}) ([]string, error) {
+    rows, err := l.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    out := []string{}
+    for rows.Next() {
+        var s string
+        if err := rows.Scan(&s); err != nil {
+            return nil, err
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+const bookQuery = `
+    SELECT b.id, b.name, b.author, COALESCE(b.year, 0), COALESCE(b.language, ''),
+           COALESCE(b.publisher, ''), COALESCE(b.total_pages, 0), COALESCE(AVG(r.rating), 0)
+    FROM books b LEFT JOIN reviews r ON r.book_id = b.id`
+
+func scanBook(row interface{ Scan(...interface{}) error }) (types.Book, error) {
+    var b types.Book
+    err := row.Scan(&b.ID, &b.Name, &b.Author, &b.Year, &b.Language, &b.Publisher, &b.Pages, &b.Rating)
+    return b, err
+}
+
+// session returns the reading_sessions row in the shape the API sends.
+func (l *Local) session(username string, bookID int) (map[string]interface{}, error) {
+    var page int
+    var started, last interface{}
+    err := l.db.QueryRow(
+        "SELECT current_page, started_at, last_read_at FROM reading_sessions WHERE user = ? AND book_id = ?",
+        username, bookID,
+    ).Scan(&page, &started, &last)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    session := map[string]interface{}{
+        "user":         username,
+        "book_id":      bookID,
+        "current_page": page,
+        "started_at":   timestamp(started),
+        "last_read_at": nil,
+    }
+    if last != nil {
+        session["last_read_at"] = timestamp(last)
+    }
+    return session, nil
+}
+
+// timestamp renders a TIMESTAMP column whichever way the driver returned it.
+func timestamp(v interface{}) string {
+    switch t := v.(type) {
+    case time.Time:
+        return t.Format("2006-01-02T15:04:05")
+    case string:
+        return t
+    case []byte:
+        return string(t)
+    case nil:
+        return ""
+    default:
+        return fmt.Sprint(t)
+    }
+}
+
+func (l *Local) SetToken(token string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.token = token
+}
+
+func (l *Local) Ping() (time.Duration, error) {
+    start := time.Now()
+    err := l.db.Ping()
+    return time.Since(start), err
+}
+
+// Auth
+
+func (l *Local) Login(username, password string) (string, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /auth/login"
+    exists, err := l.userExists(username)
+    if err != nil {
+        return "", dbError(endpoint, err)
+    }
+    if !exists {
+        return "", errWrongPassword
+    }
+    hash, err := l.passwordHash(username)
+    if err != nil {
+        return "", dbError(endpoint, err)
+    }
+    if hash == "" {
+        return "", &APIError{Endpoint: endpoint, Status: http.StatusUnauthorized,
+            Detail: fmt.Sprintf("%s has no password yet, set one with: tui --backend local --user %s register", username, username)}
+    }
+    if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+        return "", errWrongPassword
+    }
+    l.token = username
+    return username, nil
+}
+
+// Register creates an account with a bcrypt hash of its password, the
+// hash api.py checks too, and its "My Library".
+func (l *Local) Register(username, displayName, password string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /register"
+    if username == "" {
+        return &APIError{Endpoint: endpoint, Status: http.StatusUnprocessableEntity, Detail: "username is required"}
+    }
+    exists, err := l.userExists(username)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    var old string
+    if exists {
+        if old, err = l.passwordHash(username); err != nil {
+            return dbError(endpoint, err)
+        }
+        if old != "" {
+            return &APIError{Endpoint: endpoint, Status: http.StatusBadRequest, Detail: "User already exists."}
+        }
+    }
+    hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+
+    // An account from before password_hash keeps its data and gets a password
+    if exists {
+        if _, err := l.db.Exec("UPDATE users SET password_hash = ? WHERE username = ?", string(hash), username); err != nil {
+            return dbError(endpoint, err)
+        }
+        return nil
+    }
+    if displayName == "" {
+        displayName = username
+    }
+    if err := l.createUser(username, displayName, string(hash)); err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) GetCurrentUser() (types.User, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    user, ok, err := l.userView(l.token)
+    if err != nil {
+        return user, dbError("GET /me", err)
+    }
+    if !ok {
+        return user, &APIError{Endpoint: "GET /me", Status: http.StatusUnauthorized}
+    }
+    return user, nil
+}
+
+// Books
+
+func (l *Local) ListBooks() ([]types.Book, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    rows, err := l.db.Query(bookQuery + " GROUP BY b.id ORDER BY b.id")
+    if err != nil {
+        return nil, dbError("GET /books", err)
+    }
+    defer rows.Close()
+
+    books := []types.Book{}
+    for rows.Next() {
+        book, err := scanBook(rows)
+        if err != nil {
+            return nil, dbError("GET /books", err)
+        }
+        books = append(books, book)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, dbError("GET /books", err)
+    }
+    return books, nil
+}
+
+func (l *Local) ListBooksPage(offset, limit int) (types.BookPage, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "GET /books"
+    page := types.BookPage{Offset: offset, Books: []types.Book{}}
+    if err := l.db.QueryRow("SELECT COUNT(*) FROM books").Scan(&page.Total); err != nil {
+        return page, dbError(endpoint, err)
+    }
+
+    rows, err := l.db.Query(bookQuery+" GROUP BY b.id ORDER BY b.id LIMIT ? OFFSET ?", limit, offset)
+    if err != nil {
+        return page, dbError(endpoint, err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        book, err := scanBook(rows)
+        if err != nil {
+            return page, dbError(endpoint, err)
+        }
+        page.Books = append(page.Books, book)
+    }
+    if err := rows.Err(); err != nil {
+        return page, dbError(endpoint, err)
+    }
+    return page, nil
+}
+
+func (l *Local) GetBook(bookID int) (types.Book, error) {
+    data, err := l.GetBookDetails(bookID)
+    return data.Book, err
+}
+
+func (l *Local) GetBookDetails(bookID int) (types.BookData, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "GET /books/{id}"
+    book, err := scanBook(l.db.QueryRow(bookQuery+" WHERE b.id = ? GROUP BY b.id", bookID))
+    if errors.Is(err, sql.ErrNoRows) {
+        return types.BookData{}, notFound(endpoint)
+    }
+    if err != nil {
+        return types.BookData{}, dbError(endpoint, err)
+    }
+
+    rows, err := l.db.Query("SELECT user, rating, text FROM reviews WHERE book_id = ? ORDER BY id", bookID)
+    if err != nil {
+        return types.BookData{}, dbError(endpoint, err)
+    }
+    defer rows.Close()
+
+    data := types.BookData{Book: book}
+    for rows.Next() {
+        var r types.Review
+        if err := rows.Scan(&r.User, &r.Rating, &r.Text); err != nil {
+            return types.BookData{}, dbError(endpoint, err)
+        }
+        data.Reviews = append(data.Reviews, r)
+    }
+    return data, rows.Err()
+}
+
+func (l *Local) AddReview(bookID int, text string, rating int) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /books/{id}/reviews"
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+    if ok, err := l.bookExists(bookID); err != nil {
+        return dbError(endpoint, err)
+    } else if !ok {
+        return notFound(endpoint)
+    }
+    if rating < 1 || rating > 5 {
+        return &APIError{Endpoint: endpoint, Status: http.StatusUnprocessableEntity, Detail: "rating must be between 1 and 5"}
+    }
+
+    // UNIQUE (user, book_id): a second review replaces the first, as in the Fake
+    _, err = l.db.Exec(`
+        INSERT INTO reviews (user, book_id, text, rating, created_at) VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (user, book_id) DO UPDATE SET text = excluded.text, rating = excluded.rating`,
+        username, bookID, text, rating, time.Now().Format(sqliteTime),
+    )
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+// Libraries
+
+func (l *Local) GetUserLibraries(username string) ([]types.Library, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "GET /users/{u}/libraries"
+    if ok, err := l.userExists(username); err != nil {
+        return nil, dbError(endpoint, err)
+    } else if !ok {
+        return nil, notFound(endpoint)
+    }
+
+    rows, err := l.db.Query(`
+        SELECT l.id, l.name, lb.book_id, lb.shelf
+        FROM libraries l LEFT JOIN library_books lb ON lb.library_id = l.id
+        WHERE l.owner = ?
+        ORDER BY l.id, lb.rowid`, username)
+    if err != nil {
+        return nil, dbError(endpoint, err)
+    }
+    defer rows.Close()
+
+    libraries := []types.Library{}
+    for rows.Next() {
+        var id int
+        var name string
+        var bookID sql.NullInt64
+        var shelf sql.NullString
+        if err := rows.Scan(&id, &name, &bookID, &shelf); err != nil {
+            return nil, dbError(endpoint, err)
+        }
+
+        if len(libraries) == 0 || libraries[len(libraries)-1].ID != id {
+            libraries = append(libraries, types.Library{ID: id, Name: name, Books: map[string][]int{
+                "to_read":           {},
+                "currently_reading": {},
+                "read":              {},
+            }})
+        }
+        if bookID.Valid {
+            lib := libraries[len(libraries)-1]
+            lib.Books[shelf.String] = append(lib.Books[shelf.String], int(bookID.Int64))
+        }
+    }
+    return libraries, rows.Err()
+}
+
+func (l *Local) CreateLibrary(name string) (int, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    username, err := l.currentUser("POST /libraries")
+    if err != nil {
+        return 0, err
+    }
+    res, err := l.db.Exec("INSERT INTO libraries (name, owner) VALUES (?, ?)", name, username)
+    if err != nil {
+        return 0, dbError("POST /libraries", err)
+    }
+    id, err := res.LastInsertId()
+    return int(id), err
+}
+
+func (l *Local) AddBookToLibrary(libraryID, bookID int, shelf string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /libraries/{id}/books"
+    if err := l.shelving(endpoint, libraryID, bookID, shelf); err != nil {
+        return err
+    }
+
+    // The (library_id, book_id) key keeps a book on one shelf per library
+    _, err := l.db.Exec("INSERT OR REPLACE INTO library_books (library_id, book_id, shelf) VALUES (?, ?, ?)", libraryID, bookID, shelf)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) MoveBook(libraryID, bookID int, shelf string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /libraries/{id}/move"
+    if err := l.shelving(endpoint, libraryID, bookID, shelf); err != nil {
+        return err
+    }
+
+    // One statement finds the book and moves it. Like the backend, only
+    // books already in the library can move.
+    res, err := l.db.Exec("UPDATE library_books SET shelf = ? WHERE library_id = ? AND book_id = ?", shelf, libraryID, bookID)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    if n, err := res.RowsAffected(); err != nil {
+        return dbError(endpoint, err)
+    } else if n == 0 {
+        return &APIError{Endpoint: endpoint, Status: http.StatusBadRequest}
+    }
+    return nil
+}
+
+// shelving checks a book may go on a shelf of one of the user's libraries.
+// The caller holds l.mu.
+func (l *Local) shelving(endpoint string, libraryID, bookID int, shelf string) error {
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+
+    var owner string
+    err = l.db.QueryRow("SELECT owner FROM libraries WHERE id = ?", libraryID).Scan(&owner)
+    if errors.Is(err, sql.ErrNoRows) || (err == nil && owner != username) {
+        return notFound(endpoint)
+    }
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    if ok, err := l.bookExists(bookID); err != nil {
+        return dbError(endpoint, err)
+    } else if !ok {
+        return notFound(endpoint)
+    }
+    switch shelf {
+    case "to_read", "currently_reading", "read":
+    default:
+        return &APIError{Endpoint: endpoint, Status: http.StatusBadRequest, Detail: "unknown shelf " + shelf}
+    }
+    return nil
+}
+
+// Reading
+
+func (l *Local) StartReading(bookID int) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /reading/start"
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+    if ok, err := l.bookExists(bookID); err != nil {
+        return dbError(endpoint, err)
+    } else if !ok {
+        return notFound(endpoint)
+    }
+
+    // Starting an existing session is a no-op, like User.start_reading
+    _, err = l.db.Exec(
+        "INSERT OR IGNORE INTO reading_sessions (user, book_id, current_page, started_at) VALUES (?, ?, 1, ?)",
+        username, bookID, time.Now().Format(sqliteTime),
+    )
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) TurnPage(bookID int, direction string, count int) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /reading/turn"
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+
+    var page, total int
+    err = l.db.QueryRow(`
+        SELECT s.current_page, COALESCE(b.total_pages, 0)
+        FROM reading_sessions s JOIN books b ON b.id = s.book_id
+        WHERE s.user = ? AND s.book_id = ?`, username, bookID).Scan(&page, &total)
+    if errors.Is(err, sql.ErrNoRows) {
+        return &APIError{Endpoint: endpoint, Status: http.StatusBadRequest}
+    }
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+
+    switch direction {
+    case "forward":
+        page += count
+        if total > 0 {
+            page = min(total, page)
+        }
+    case "back":
+        page = max(1, page-count)
+    }
+
+    // Finishing the book ends the session. Without a page count there is
+    // no last page, the session goes on.
+    if total > 0 && page >= total {
+        _, err = l.db.Exec("DELETE FROM reading_sessions WHERE user = ? AND book_id = ?", username, bookID)
+    } else {
+        _, err = l.db.Exec(
+            "UPDATE reading_sessions SET current_page = ?, last_read_at = ? WHERE user = ? AND book_id = ?",
+            page, time.Now().Format(sqliteTime), username, bookID,
+        )
+    }
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) GetActiveReading() ([]map[string]interface{}, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "GET /users/{u}/reading"
+    if ok, err := l.userExists(l.token); err != nil {
+        return nil, dbError(endpoint, err)
+    } else if !ok {
+        return nil, notFound(endpoint)
+    }
+
+    rows, err := l.db.Query("SELECT book_id FROM reading_sessions WHERE user = ? ORDER BY book_id", l.token)
+    if err != nil {
+        return nil, dbError(endpoint, err)
+    }
+    var ids []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            rows.Close()
+            return nil, dbError(endpoint, err)
+        }
+        ids = append(ids, id)
+    }
+    rows.Close()
+
+    sessions := make([]map[string]interface{}, 0, len(ids))
+    for _, id := range ids {
+        session, err := l.session(l.token, id)
+        if err != nil {
+            return nil, dbError(endpoint, err)
+        }
+        if session != nil {
+            sessions = append(sessions, session)
+        }
+    }
+    return sessions, nil
+}
+
+// Friends and recommendations
+
+func (l *Local) AddFriend(friendUsername string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /users/{u}/friends/{v}"
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+    if ok, err := l.userExists(friendUsername); err != nil {
+        return dbError(endpoint, err)
+    } else if !ok || friendUsername == username {
+        return notFound(endpoint)
+    }
+
+    // Friendship is stored in both directions, see FriendRepository.add
+    _, err = l.db.Exec("INSERT OR IGNORE INTO friends VALUES (?, ?), (?, ?)", username, friendUsername, friendUsername, username)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) GetUser(username string) (types.User, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    user, ok, err := l.userView(username)
+    if err != nil {
+        return user, dbError("GET /users/{u}", err)
+    }
+    if !ok {
+        return user, notFound("GET /users/{u}")
+    }
+    return user, nil
+}
+
+func (l *Local) RecommendBook(toUser string, bookID int, message string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "POST /recommend"
+    username, err := l.currentUser(endpoint)
+    if err != nil {
+        return err
+    }
+    userOK, err := l.userExists(toUser)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    bookOK, err := l.bookExists(bookID)
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    if !userOK || !bookOK {
+        return notFound(endpoint)
+    }
+
+    var note interface{}
+    if message != "" {
+        note = message
+    }
+    _, err = l.db.Exec(
+        "INSERT INTO recommendations (from_user, to_user, book_id, message, date) VALUES (?, ?, ?, ?, ?)",
+        username, toUser, bookID, note, time.Now().Format(sqliteTime),
+    )
+    if err != nil {
+        return dbError(endpoint, err)
+    }
+    return nil
+}
+
+func (l *Local) GetRecommendations() ([]types.Recommendation, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    endpoint := "GET /users/{u}/recommendations"
+    if ok, err := l.userExists(l.token); err != nil {
+        return nil, dbError(endpoint, err)
+    } else if !ok {
+        return nil, notFound(endpoint)
+    }
+
+    rows, err := l.db.Query(`
+        SELECT r.from_user, b.name, COALESCE(r.message, ''), r.date
+        FROM recommendations r JOIN books b ON b.id = r.book_id
+        WHERE r.to_user = ?
+        ORDER BY r.id`, l.token)
+    if err != nil {
+        return nil, dbError(endpoint, err)
+    }
+    defer rows.Close()
+
+    recommendations := []types.Recommendation{}
+    for rows.Next() {
+        var rec types.Recommendation
+        var date interface{}
+        if err := rows.Scan(&rec.From, &rec.Book, &rec.Message, &date); err != nil {
+            return nil, dbError(endpoint, err)
+        }
+        rec.Date = timestamp(date)
+        recommendations = append(recommendations, rec)
+    }
+    return recommendations, rows.Err()
+}
+
+// localSchema is sql/*.sql without the seed data, for starting from an empty file.
+const localSchema = `
+CREATE TABLE IF NOT EXISTS books (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    author TEXT NOT NULL,
+    year INTEGER,
+    language TEXT,
+    publisher TEXT,
+    total_pages INTEGER
+);
+CREATE TABLE IF NOT EXISTS users (
+    username TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS friends (
+    user1 TEXT NOT NULL,
+    user2 TEXT NOT NULL,
+    PRIMARY KEY (user1, user2),
+    FOREIGN KEY (user1) REFERENCES users(username),
+    FOREIGN KEY (user2) REFERENCES users(username)
+);
+CREATE TABLE IF NOT EXISTS libraries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    FOREIGN KEY (owner) REFERENCES users(username)
+);
+CREATE TABLE IF NOT EXISTS library_books (
+    library_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    shelf TEXT NOT NULL,
+    PRIMARY KEY (library_id, book_id),
+    FOREIGN KEY (library_id) REFERENCES libraries(id),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+CREATE TABLE IF NOT EXISTS reading_sessions (
+    user TEXT NOT NULL,
+    book_id INTEGER NOT NULL,
+    current_page INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (user, book_id),
+    FOREIGN KEY (user) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user TEXT NOT NULL,
+    book_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    UNIQUE (user, book_id)
+);
+CREATE TABLE IF NOT EXISTS recommendations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_user TEXT NOT NULL,
+    to_user TEXT NOT NULL,
+    book_id INTEGER NOT NULL,
+    message TEXT,
+    date TIMESTAMP NOT NULL,
+    FOREIGN KEY (from_user) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (to_user) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);`
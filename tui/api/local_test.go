@@ -0,0 +1,243 @@
+package api
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "golang.org/x/crypto/bcrypt"
+)
+
+func openLocal(t *testing.T) *Local {
+    t.Helper()
+    l, err := OpenLocal(filepath.Join(t.TempDir(), "app.db"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { l.Close() })
+    return l
+}
+
+func isStatus(err error, status int) bool {
+    var apiErr *APIError
+    return errors.As(err, &apiErr) && apiErr.Status == status
+}
+
+func TestLocalLogin(t *testing.T) {
+    l := openLocal(t)
+    if err := l.Register("alice", "Alice", "s3cret"); err != nil {
+        t.Fatal(err)
+    }
+    if err := l.Register("alice", "Alice", "again"); !isStatus(err, http.StatusBadRequest) {
+        t.Fatalf("second Register = %v, want a 400", err)
+    }
+
+    // passlib writes $2b$ hashes, the same algorithm as Go's $2a$
+    hash, _ := bcrypt.GenerateFromPassword([]byte("py"), bcrypt.MinCost)
+    passlib := strings.Replace(string(hash), "$2a$", "$2b$", 1)
+    if _, err := l.db.Exec("INSERT INTO users (username, display_name, password_hash, created_at) VALUES ('bob', 'Bob', ?, '')", passlib); err != nil {
+        t.Fatal(err)
+    }
+    // Accounts from before password_hash was filled in have none
+    if _, err := l.db.Exec("INSERT INTO users (username, display_name, password_hash, created_at) VALUES ('old', 'Old', '', '')"); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        username, password string
+        ok                 bool
+    }{
+        {"alice", "s3cret", true},
+        {"alice", "wrong", false},
+        {"alice", "", false},
+        {"bob", "py", true},
+        {"bob", "s3cret", false},
+        {"old", "anything", false},
+        {"old", "", false},
+        {"nobody", "s3cret", false},
+        {"", "", false},
+    }
+    for _, tt := range tests {
+        token, err := l.Login(tt.username, tt.password)
+        switch {
+        case tt.ok && (err != nil || token != tt.username):
+            t.Errorf("Login(%q, %q) = %q, %v, want a token", tt.username, tt.password, token, err)
+        case !tt.ok && !isStatus(err, http.StatusUnauthorized):
+            t.Errorf("Login(%q, %q) = %v, want a 401", tt.username, tt.password, err)
+        }
+    }
+
+    var n int
+    l.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = 'nobody'").Scan(&n)
+    if n != 0 {
+        t.Error("logging in as an unknown user created it")
+    }
+
+    // The account without a password is told how to get one, and gets it
+    if _, err := l.Login("old", "x"); err == nil || !strings.Contains(err.Error(), "register") {
+        t.Errorf("Login of an account without a password = %v", err)
+    }
+    if err := l.Register("old", "", "n3w"); err != nil {
+        t.Fatalf("Register of an account without a password: %v", err)
+    }
+    if _, err := l.Login("old", "n3w"); err != nil {
+        t.Errorf("Login with the new password: %v", err)
+    }
+    if err := l.Register("old", "", "again"); !isStatus(err, http.StatusBadRequest) {
+        t.Errorf("second Register of old = %v, want a 400", err)
+    }
+}
+
+// Databases from before password_hash get the column on open.
+func TestLocalAddsPasswordHash(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "old.db")
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, err = db.Exec(`CREATE TABLE users (username TEXT PRIMARY KEY, display_name TEXT NOT NULL, created_at TIMESTAMP NOT NULL);
+        INSERT INTO users VALUES ('old', 'Old', '');`)
+    db.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    l, err := OpenLocal(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer l.Close()
+    if _, err := l.Login("old", "anything"); !isStatus(err, http.StatusUnauthorized) {
+        t.Errorf("Login without a password = %v, want a 401", err)
+    }
+    if err := l.Register("old", "", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := l.Login("old", "pw"); err != nil {
+        t.Errorf("Login after setting a password: %v", err)
+    }
+}
+
+func TestLocalTurnPage(t *testing.T) {
+    l := openLocal(t)
+    if err := l.Register("alice", "Alice", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := l.Login("alice", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    // Book 1 has 384 pages, book 2 lost its page count
+    if _, err := l.db.Exec("UPDATE books SET total_pages = NULL WHERE id = 2"); err != nil {
+        t.Fatal(err)
+    }
+
+    page := func(bookID int) int {
+        sessions, err := l.GetActiveReading()
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, s := range sessions {
+            if s["book_id"] == bookID {
+                return s["current_page"].(int)
+            }
+        }
+        return 0
+    }
+
+    tests := []struct {
+        bookID    int
+        direction string
+        count     int
+        want      int // 0 when the session ended
+    }{
+        {2, "forward", 5, 6},
+        {2, "forward", 1000, 1006},
+        {2, "back", 2000, 1},
+        {1, "forward", 10, 11},
+        {1, "back", 3, 8},
+        {1, "forward", 1000, 0},
+    }
+    for _, bookID := range []int{1, 2} {
+        if err := l.StartReading(bookID); err != nil {
+            t.Fatal(err)
+        }
+    }
+    for _, tt := range tests {
+        if err := l.TurnPage(tt.bookID, tt.direction, tt.count); err != nil {
+            t.Fatalf("TurnPage(%d, %s, %d): %v", tt.bookID, tt.direction, tt.count, err)
+        }
+        if got := page(tt.bookID); got != tt.want {
+            t.Errorf("after TurnPage(%d, %s, %d) page = %d, want %d", tt.bookID, tt.direction, tt.count, got, tt.want)
+        }
+    }
+}
+
+func TestMoveBook(t *testing.T) {
+    l := openLocal(t)
+    if err := l.Register("alice", "Alice", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    backends := []struct {
+        name     string
+        service  Service
+        user, pw string
+        onShelf  int // a book in the user's library
+        offShelf int // one that is not
+    }{
+        {"fake", NewFake(), DemoUsername, DemoPassword, 1, 2},
+        {"local", l, "alice", "pw", 1, 2},
+    }
+    for _, b := range backends {
+        // Auth comes first, even for a book that could not move anyway
+        if err := b.service.MoveBook(1, b.offShelf, "read"); !isStatus(err, http.StatusUnauthorized) {
+            t.Errorf("%s: MoveBook before login = %v, want a 401", b.name, err)
+        }
+        if _, err := b.service.Login(b.user, b.pw); err != nil {
+            t.Fatal(err)
+        }
+        libs, err := b.service.GetUserLibraries(b.user)
+        if err != nil || len(libs) == 0 {
+            t.Fatalf("%s: libraries %v, %v", b.name, libs, err)
+        }
+        lib := libs[0].ID
+        if b.name == "local" {
+            if err := b.service.AddBookToLibrary(lib, b.onShelf, "to_read"); err != nil {
+                t.Fatal(err)
+            }
+        }
+
+        tests := []struct {
+            lib, book int
+            shelf     string
+            status    int
+        }{
+            {lib, b.offShelf, "read", http.StatusBadRequest},
+            {lib, b.onShelf, "shelf", http.StatusBadRequest},
+            {lib + 100, b.onShelf, "read", http.StatusNotFound},
+            {lib, 9999, "read", http.StatusNotFound},
+            {lib, b.onShelf, "read", 0},
+        }
+        for _, tt := range tests {
+            err := b.service.MoveBook(tt.lib, tt.book, tt.shelf)
+            if tt.status == 0 && err != nil || tt.status != 0 && !isStatus(err, tt.status) {
+                t.Errorf("%s: MoveBook(%d, %d, %q) = %v, want status %d", b.name, tt.lib, tt.book, tt.shelf, err, tt.status)
+            }
+        }
+
+        libs, _ = b.service.GetUserLibraries(b.user)
+        var shelves []string
+        for shelf, books := range libs[0].Books {
+            for _, book := range books {
+                if book == b.onShelf {
+                    shelves = append(shelves, shelf)
+                }
+            }
+        }
+        if len(shelves) != 1 || shelves[0] != "read" {
+            t.Errorf("%s: book %d is on %q after the move", b.name, b.onShelf, shelves)
+        }
+    }
+}
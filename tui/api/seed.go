@@ -0,0 +1,57 @@
+package api
+
+import "tui/types"
+
+// SeedBooks is the same catalog sql/books.sql loads into app.db.
+var SeedBooks = []types.Book{
+    {ID: 1, Name: "The Silent Horizon", Author: "Ava Mitchell", Year: 2012, Publisher: "Northwind Press", Language: "English", Pages: 384},
+    {ID: 2, Name: "Ashes of Tomorrow", Author: "Liam Carter", Year: 2015, Publisher: "Red Maple Books", Language: "English", Pages: 421},
+    {ID: 3, Name: "The Clockmaker’s Paradox", Author: "Eleanor Finch", Year: 2018, Publisher: "Ironleaf Publishing", Language: "English", Pages: 356},
+    {ID: 4, Name: "Beyond the Riverbend", Author: "Noah Whitaker", Year: 2010, Publisher: "Stonebridge House", Language: "English", Pages: 298},
+    {ID: 5, Name: "Fragments of Light", Author: "Isabella Moreau", Year: 2016, Publisher: "Étoile Éditions", Language: "French", Pages: 332},
+    {ID: 6, Name: "The Last Archivist", Author: "Marcus Hale", Year: 2020, Publisher: "Blackwell & Co.", Language: "English", Pages: 467},
+    {ID: 7, Name: "Paper Cities", Author: "Sofia Lindström", Year: 2014, Publisher: "Nordic Ink", Language: "Swedish", Pages: 289},
+    {ID: 8, Name: "A Theory of Forgotten Things", Author: "Julian Rowe", Year: 2019, Publisher: "Helix Press", Language: "English", Pages: 410},
+    {ID: 9, Name: "Winter Over Caldera", Author: "Hannah Brooks", Year: 2011, Publisher: "Summit Lane", Language: "English", Pages: 276},
+    {ID: 10, Name: "The Glass Orchard", Author: "Theo Alvarez", Year: 2017, Publisher: "Sunstone Publishing", Language: "Spanish", Pages: 345},
+    {ID: 11, Name: "Maps for the Unlost", Author: "Priya Nandakumar", Year: 2021, Publisher: "Lotus River Press", Language: "English", Pages: 392},
+    {ID: 12, Name: "Ink Beneath the Skin", Author: "Ronan Price", Year: 2013, Publisher: "Cinder House", Language: "English", Pages: 318},
+    {ID: 13, Name: "When Statues Dream", Author: "Alessandro Ricci", Year: 2009, Publisher: "Via Roma Books", Language: "Italian", Pages: 264},
+    {ID: 14, Name: "The Narrow Season", Author: "Emily Zhao", Year: 2018, Publisher: "Paper Crane Publishing", Language: "English", Pages: 351},
+    {ID: 15, Name: "Letters Never Sent", Author: "Marta Kowalska", Year: 2012, Publisher: "Baltic Words", Language: "Polish", Pages: 287},
+    {ID: 16, Name: "The Echo Cartographer", Author: "Samuel Keene", Year: 2022, Publisher: "Wayfinder Press", Language: "English", Pages: 455},
+    {ID: 17, Name: "Dust and Other Silences", Author: "Omar El-Tayeb", Year: 2016, Publisher: "Desert Palm Books", Language: "Arabic", Pages: 309},
+    {ID: 18, Name: "Anatomy of a Firefly", Author: "Clara Voss", Year: 2019, Publisher: "Moonwell Press", Language: "English", Pages: 334},
+    {ID: 19, Name: "The Long Province", Author: "Daniel Okoye", Year: 2011, Publisher: "Crosswind Publishing", Language: "English", Pages: 401},
+    {ID: 20, Name: "Blue Hours in Kyoto", Author: "Rei Nakamura", Year: 2015, Publisher: "Hoshino Books", Language: "Japanese", Pages: 258},
+    {ID: 21, Name: "The Sound of Distant Bells", Author: "Margaret Hill", Year: 2008, Publisher: "Elder Grove", Language: "English", Pages: 372},
+    {ID: 22, Name: "After the Cedar Falls", Author: "Jonah Peterson", Year: 2014, Publisher: "Timberline Press", Language: "English", Pages: 319},
+    {ID: 23, Name: "Saltwater Arithmetic", Author: "Nina Calder", Year: 2020, Publisher: "Driftwood Editions", Language: "English", Pages: 386},
+    {ID: 24, Name: "The Unfinished Atlas", Author: "Victor Laurent", Year: 2017, Publisher: "Meridian House", Language: "French", Pages: 428},
+    {ID: 25, Name: "A Study in Hollow Places", Author: "Beatrice Young", Year: 2021, Publisher: "Ravencrest Press", Language: "English", Pages: 399},
+    {ID: 26, Name: "How Mountains Remember", Author: "Lucas Fernández", Year: 2013, Publisher: "Andes Ink", Language: "Spanish", Pages: 344},
+    {ID: 27, Name: "The Color of Returning", Author: "Yara Haddad", Year: 2019, Publisher: "Olive Branch Books", Language: "Arabic", Pages: 361},
+    {ID: 28, Name: "Small Gods of Concrete", Author: "Patrick Doyle", Year: 2016, Publisher: "Iron Alley Press", Language: "English", Pages: 295},
+    {ID: 29, Name: "The Third Weather", Author: "Helena Novak", Year: 2018, Publisher: "Silver Birch Publishing", Language: "Czech", Pages: 327},
+    {ID: 30, Name: "Notes from a Vanishing Shore", Author: "Caleb Morris", Year: 2012, Publisher: "Low Tide Press", Language: "English", Pages: 282},
+    {ID: 31, Name: "The City That Waited", Author: "Irene Park", Year: 2022, Publisher: "Neon Harbor", Language: "English", Pages: 447},
+    {ID: 32, Name: "What the Moss Keeps", Author: "Frederik Olsen", Year: 2010, Publisher: "Green Fjord Press", Language: "Danish", Pages: 271},
+    {ID: 33, Name: "The Second Library of Babel", Author: "Arthur Klein", Year: 2019, Publisher: "Parallax Books", Language: "English", Pages: 503},
+    {ID: 34, Name: "A Brief History of Falling", Author: "Lydia Chen", Year: 2015, Publisher: "Skybound Press", Language: "English", Pages: 318},
+    {ID: 35, Name: "Wind, Stone, Threshold", Author: "Mikhail Petrov", Year: 2007, Publisher: "Volga House", Language: "Russian", Pages: 389},
+    {ID: 36, Name: "The Shape of Abandoned Roads", Author: "Oliver Grant", Year: 2018, Publisher: "Farway Publishing", Language: "English", Pages: 362},
+    {ID: 37, Name: "The Sea Is Not a Mirror", Author: "Lucía Morales", Year: 2020, Publisher: "Azul Mar Editions", Language: "Spanish", Pages: 341},
+    {ID: 38, Name: "Rooms Without North", Author: "Anika Bose", Year: 2016, Publisher: "Eastline Press", Language: "English", Pages: 304},
+    {ID: 39, Name: "The Persistence of Smoke", Author: "Thomas Reed", Year: 2011, Publisher: "Ashfall Publishing", Language: "English", Pages: 376},
+    {ID: 40, Name: "Beneath Quiet Signals", Author: "Jonas Weber", Year: 2014, Publisher: "Rhinegold Books", Language: "German", Pages: 333},
+    {ID: 41, Name: "The Last Season of Maps", Author: "Naomi Feldman", Year: 2021, Publisher: "Compass Rose Press", Language: "English", Pages: 412},
+    {ID: 42, Name: "If Stones Could Speak", Author: "Eamon Walsh", Year: 2009, Publisher: "Cloverfield House", Language: "English", Pages: 291},
+    {ID: 43, Name: "A Manual for Temporary Lives", Author: "Sanjay Rao", Year: 2017, Publisher: "Open Palm Press", Language: "English", Pages: 359},
+    {ID: 44, Name: "Night Letters to the Coast", Author: "Phoebe Lang", Year: 2013, Publisher: "Lighthouse Ink", Language: "English", Pages: 274},
+    {ID: 45, Name: "The Orchard After Snow", Author: "Katerina Ivanova", Year: 2018, Publisher: "White Ember Press", Language: "Russian", Pages: 336},
+    {ID: 46, Name: "Distances We Inherit", Author: "Michael Osei", Year: 2020, Publisher: "New Meridian Press", Language: "English", Pages: 398},
+    {ID: 47, Name: "The Grammar of Tides", Author: "Elena Rossi", Year: 2016, Publisher: "Blue Current Publishing", Language: "Italian", Pages: 321},
+    {ID: 48, Name: "Cities Built of Breath", Author: "Farah Suleiman", Year: 2022, Publisher: "Minaret Books", Language: "Arabic", Pages: 452},
+    {ID: 49, Name: "An Inventory of Absences", Author: "George Whitman", Year: 2011, Publisher: "Fieldnote Press", Language: "English", Pages: 365},
+    {ID: 50, Name: "The Year We Learned to Wait", Author: "Amara Singh", Year: 2019, Publisher: "Stillwater Press", Language: "English", Pages: 387},
+}
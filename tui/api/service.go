@@ -0,0 +1,65 @@
+package api
+
+import (
+    "time"
+
+    "tui/types"
+)
+
+// Service is everything the TUI and CLI need from a backend.
+// *Client talks to the FastAPI server over HTTP, *Local to app.db directly;
+// *Fake keeps it all in memory.
+type Service interface {
+    SetToken(token string)
+    Ping() (time.Duration, error)
+
+    // Auth
+    Login(username, password string) (string, error)
+    GetCurrentUser() (types.User, error)
+
+    // Books
+    ListBooks() ([]types.Book, error)
+    ListBooksPage(offset, limit int) (types.BookPage, error)
+    GetBook(bookID int) (types.Book, error)
+    GetBookDetails(bookID int) (types.BookData, error)
+    AddReview(bookID int, text string, rating int) error
+
+    // Libraries
+    GetUserLibraries(username string) ([]types.Library, error)
+    CreateLibrary(name string) (int, error)
+    AddBookToLibrary(libraryID, bookID int, shelf string) error
+    MoveBook(libraryID, bookID int, shelf string) error
+
+    // Reading
+    StartReading(bookID int) error
+    TurnPage(bookID int, direction string, count int) error
+    GetActiveReading() ([]map[string]interface{}, error)
+
+    // Friends and recommendations
+    AddFriend(friendUsername string) error
+    GetUser(username string) (types.User, error)
+    RecommendBook(toUser string, bookID int, message string) error
+    GetRecommendations() ([]types.Recommendation, error)
+}
+
+// Refresher is implemented by backends that keep answers around. Refresh
+// makes the next requests check with the backend again.
+type Refresher interface {
+    Refresh()
+}
+
+// Registerer is implemented by backends that can create accounts, as the
+// API's /register does.
+type Registerer interface {
+    Register(username, displayName, password string) error
+}
+
+var (
+    _ Refresher  = (*Client)(nil)
+    _ Registerer = (*Client)(nil)
+    _ Registerer = (*Local)(nil)
+
+    _ Service = (*Client)(nil)
+    _ Service = (*Fake)(nil)
+    _ Service = (*Local)(nil)
+)
@@ -0,0 +1,113 @@
+package api
+
+import "tui/types"
+
+// Wire types mirror the JSON the FastAPI backend sends, field for field.
+// They stay private to this package; callers only ever see the types.* structs.
+
+type wireLogin struct {
+    Token string `json:"token"`
+}
+
+type wireBook struct {
+    ID        int          `json:"id"`
+    Name      string       `json:"name"`
+    Author    string       `json:"author"`
+    Year      int          `json:"year"`
+    Language  string       `json:"language"`
+    Publisher string       `json:"publisher"`
+    Pages     int          `json:"pages"`
+    AvgRating *float64     `json:"avg_rating"` // null until the book has reviews
+    Reviews   []wireReview `json:"reviews,omitempty"` // only on /books/{id}
+}
+
+type wireReview struct {
+    User   string `json:"user"`
+    Rating int    `json:"rating"`
+    Text   string `json:"text"`
+    Likes  int    `json:"likes"`
+}
+
+// wireUser is returned by /me and /users/{username}.
+// Libraries are only names here, their contents come from /users/{u}/libraries.
+type wireUser struct {
+    Username    string   `json:"username"`
+    DisplayName string   `json:"display_name"`
+    Friends     []string `json:"friends"`
+    Libraries   []string `json:"libraries"`
+}
+
+type wireLibrary struct {
+    ID      int              `json:"id"`
+    Name    string           `json:"name"`
+    Shelves map[string][]int `json:"shelves"`
+}
+
+type wireCreatedLibrary struct {
+    ID   int    `json:"id"`
+    Name string `json:"name"`
+}
+
+type wireRecommendation struct {
+    From    string  `json:"from"`
+    Book    string  `json:"book"`
+    Message *string `json:"message"`
+    Date    string  `json:"date"`
+}
+
+func (w wireBook) toBook() types.Book {
+    book := types.Book{
+        ID:        w.ID,
+        Name:      w.Name,
+        Author:    w.Author,
+        Year:      w.Year,
+        Pages:     w.Pages,
+        Language:  w.Language,
+        Publisher: w.Publisher,
+    }
+    if w.AvgRating != nil {
+        book.Rating = *w.AvgRating
+    }
+    return book
+}
+
+func (w wireReview) toReview() types.Review {
+    return types.Review{
+        User:   w.User,
+        Rating: w.Rating,
+        Text:   w.Text,
+        Likes:  w.Likes,
+    }
+}
+
+func (w wireUser) toUser() types.User {
+    user := types.User{
+        Username:    w.Username,
+        DisplayName: w.DisplayName,
+        Friends:     w.Friends,
+    }
+    for _, name := range w.Libraries {
+        user.Libraries = append(user.Libraries, types.Library{Name: name})
+    }
+    return user
+}
+
+func (w wireLibrary) toLibrary() types.Library {
+    return types.Library{
+        ID:    w.ID,
+        Name:  w.Name,
+        Books: w.Shelves,
+    }
+}
+
+func (w wireRecommendation) toRecommendation() types.Recommendation {
+    rec := types.Recommendation{
+        From: w.From,
+        Book: w.Book,
+        Date: w.Date,
+    }
+    if w.Message != nil {
+        rec.Message = *w.Message
+    }
+    return rec
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/export"
+    "tui/types"
+)
+
+// exportAll writes the user's data in every format into the export
+// directory and reports the files in the footer.
+func (m Model) exportAll() (Model, tea.Cmd) {
+    m.notice = "Exporting..."
+    dir, username := expandHome(m.config.ExportDir), m.username
+    return m, func() tea.Msg {
+        snap, err := export.Collect(m.api, username)
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        if err := os.MkdirAll(dir, 0o755); err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+
+        for _, format := range export.Formats {
+            path := filepath.Join(dir, export.FileName(snap, format))
+            if err := writeExport(path, format, snap); err != nil {
+                return types.ErrorMsg{Message: err.Error()}
+            }
+        }
+        return types.NoticeMsg{Text: fmt.Sprintf("Exported to %s.{%s}",
+            filepath.Join(dir, export.BaseName(snap)), strings.Join(export.Formats, ","))}
+    }
+}
+
+func writeExport(path, format string, snap export.Snapshot) error {
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if err := export.Write(f, format, snap); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
+
+// clearNoticeAfter hides the notice later, unless another one replaced it.
+func (m Model) clearNoticeAfter(seconds int, text string) tea.Cmd {
+    return tea.Tick(time.Second*time.Duration(seconds), func(time.Time) tea.Msg {
+        return types.ClearNoticeMsg{Text: text}
+    })
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+    "fmt"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/types"
+)
+
+const (
+    healthCheckInterval = 5 * time.Second
+    // Responses slower than this mark the connection as degraded
+    degradedLatency = 800 * time.Millisecond
+    // Consecutive failed probes before we call the backend offline
+    offlineAfterFailures = 3
+)
+
+func (m Model) checkHealth() tea.Cmd {
+    return func() tea.Msg {
+        latency, err := m.api.Ping()
+        return types.HealthCheckMsg{Latency: latency, Err: err}
+    }
+}
+
+func (m Model) scheduleHealthCheck() tea.Cmd {
+    return tea.Tick(healthCheckInterval, func(t time.Time) tea.Msg {
+        return types.HealthTickMsg{}
+    })
+}
+
+func (m Model) handleHealthCheck(msg types.HealthCheckMsg) (Model, tea.Cmd) {
+    previous := m.connStatus
+
+    if msg.Err != nil {
+        m.failedChecks++
+        m.latency = 0
+        if m.failedChecks >= offlineAfterFailures || previous == types.ConnectionOffline {
+            m.connStatus = types.ConnectionOffline
+        } else {
+            // Fewer failures in a row than that are treated as hiccups
+            m.connStatus = types.ConnectionDegraded
+        }
+    } else {
+        m.failedChecks = 0
+        m.latency = msg.Latency
+        if msg.Latency > degradedLatency {
+            m.connStatus = types.ConnectionDegraded
+        } else {
+            m.connStatus = types.ConnectionOnline
+        }
+    }
+
+    cmds := []tea.Cmd{m.scheduleHealthCheck()}
+
+    // Backend came back: reload whatever may have gone stale while it was down
+    if previous == types.ConnectionOffline && m.connStatus != types.ConnectionOffline && m.loggedIn {
+        cmds = append(cmds, m.refreshData())
+    }
+
+    return m, tea.Batch(cmds...)
+}
+
+func (m Model) connectionLabel() string {
+    switch m.connStatus {
+    case types.ConnectionOnline:
+        return fmt.Sprintf("🟢 Online %s", formatLatency(m.latency))
+    case types.ConnectionDegraded:
+        if m.latency > 0 {
+            return fmt.Sprintf("🟡 Degraded %s", formatLatency(m.latency))
+        }
+        return "🟡 Degraded"
+    case types.ConnectionOffline:
+        return "🔴 Offline"
+    default:
+        return "⚪ Connecting..."
+    }
+}
+
+func formatLatency(d time.Duration) string {
+    if d < time.Second {
+        return fmt.Sprintf("(%dms)", d.Milliseconds())
+    }
+    return fmt.Sprintf("(%.1fs)", d.Seconds())
+}
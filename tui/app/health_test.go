@@ -0,0 +1,65 @@
+package app
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/types"
+)
+
+func TestHealthTransitions(t *testing.T) {
+    const (
+        on   = types.ConnectionOnline
+        slow = types.ConnectionDegraded
+        off  = types.ConnectionOffline
+    )
+    ok := types.HealthCheckMsg{Latency: 20 * time.Millisecond}
+    late := types.HealthCheckMsg{Latency: 2 * time.Second}
+    down := types.HealthCheckMsg{Err: errors.New("connection refused")}
+
+    tests := []struct {
+        name   string
+        probes []types.HealthCheckMsg
+        want   []types.ConnectionStatus // after each probe
+    }{
+        {"online", []types.HealthCheckMsg{ok, ok}, []types.ConnectionStatus{on, on}},
+        {"slow answers", []types.HealthCheckMsg{ok, late, ok}, []types.ConnectionStatus{on, slow, on}},
+        {"hiccup", []types.HealthCheckMsg{ok, down, ok}, []types.ConnectionStatus{on, slow, on}},
+        {"goes down", []types.HealthCheckMsg{ok, down, down, down, down}, []types.ConnectionStatus{on, slow, slow, off, off}},
+        // A failure while degraded by latency is not enough on its own
+        {"slow then failing", []types.HealthCheckMsg{late, down, down, down}, []types.ConnectionStatus{slow, slow, slow, off}},
+        {"down from the start", []types.HealthCheckMsg{down, down, down}, []types.ConnectionStatus{slow, slow, off}},
+        {"failures not in a row", []types.HealthCheckMsg{down, down, ok, down, down}, []types.ConnectionStatus{slow, slow, on, slow, slow}},
+        {"comes back", []types.HealthCheckMsg{down, down, down, late, ok}, []types.ConnectionStatus{slow, slow, off, slow, on}},
+    }
+    for _, tt := range tests {
+        m := newTestModel(t)
+        for i, probe := range tt.probes {
+            m, _ = m.handleHealthCheck(probe)
+            if m.connStatus != tt.want[i] {
+                t.Errorf("%s: after probe %d status %v, want %v", tt.name, i+1, m.connStatus, tt.want[i])
+            }
+        }
+    }
+}
+
+// Coming back from offline reloads the data of a logged in user.
+func TestHealthRecoveryRefreshes(t *testing.T) {
+    down := types.HealthCheckMsg{Err: errors.New("connection refused")}
+    for _, loggedIn := range []bool{false, true} {
+        m := newTestModel(t)
+        m.loggedIn = loggedIn
+        for i := 0; i < offlineAfterFailures; i++ {
+            m, _ = m.handleHealthCheck(down)
+        }
+        _, cmd := m.handleHealthCheck(types.HealthCheckMsg{Latency: time.Millisecond})
+
+        // The next probe is always scheduled, a refresh only when logged in
+        batch, _ := cmd().(tea.BatchMsg)
+        if want := map[bool]int{false: 1, true: 2}[loggedIn]; len(batch) != want {
+            t.Errorf("logged in %v: %d commands after recovery, want %d", loggedIn, len(batch), want)
+        }
+    }
+}
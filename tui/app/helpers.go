@@ -1,5 +1,11 @@
 package app
 
+import (
+    "sort"
+    "strings"
+    "tui/types"
+)
+
 func clamp(val, min, max int) int {
     if val < min {
         return min
@@ -9,3 +15,88 @@ func clamp(val, min, max int) int {
     }
     return val
 }
+
+// indexBooks keys the catalog by book ID, so a library of thousands
+// doesn't scan the catalog once per book.
+func indexBooks(books []types.Book) map[int]types.Book {
+    index := make(map[int]types.Book, len(books))
+    for _, book := range books {
+        index[book.ID] = book
+    }
+    return index
+}
+
+// withBooks returns known with books added, as a new map: commands may
+// still be reading the old one.
+func withBooks(known map[int]types.Book, books []types.Book) map[int]types.Book {
+    if len(books) == 0 {
+        return known
+    }
+    out := make(map[int]types.Book, len(known)+len(books))
+    for id, book := range known {
+        out[id] = book
+    }
+    for _, book := range books {
+        out[book.ID] = book
+    }
+    return out
+}
+
+// knownBooks lists the books looked up so far, by ID.
+func (m Model) knownBooks() []types.Book {
+    books := make([]types.Book, 0, len(m.books))
+    for _, book := range m.books {
+        books = append(books, book)
+    }
+    sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+    return books
+}
+
+// missingBooks are the ids of books not looked up yet, each once.
+func (m Model) missingBooks(ids []int) []int {
+    var missing []int
+    seen := map[int]bool{}
+    for _, id := range ids {
+        if _, ok := m.books[id]; !ok && !seen[id] {
+            seen[id] = true
+            missing = append(missing, id)
+        }
+    }
+    return missing
+}
+
+// intField reads a number from a decoded JSON row. The HTTP client yields
+// float64 and the in-process backends int.
+func intField(row map[string]interface{}, key string) int {
+    switch v := row[key].(type) {
+    case int:
+        return v
+    case float64:
+        return int(v)
+    }
+    return 0
+}
+
+func stringField(row map[string]interface{}, key string) string {
+    s, _ := row[key].(string)
+    return s
+}
+
+// searchBooks returns the books whose title or author contains the query.
+// The search bar looks through the books looked up so far: the library,
+// the pages of Discover and the books opened.
+func searchBooks(books []types.Book, query string) []types.Book {
+    query = strings.ToLower(strings.TrimSpace(query))
+    if query == "" {
+        return nil
+    }
+
+    var results []types.Book
+    for _, book := range books {
+        if strings.Contains(strings.ToLower(book.Name), query) ||
+            strings.Contains(strings.ToLower(book.Author), query) {
+            results = append(results, book)
+        }
+    }
+    return results
+}
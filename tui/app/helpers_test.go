@@ -0,0 +1,44 @@
+package app
+
+import (
+    "testing"
+
+    "tui/types"
+)
+
+// BenchmarkShelveLibrary looks up the 10k books of a library in a catalog
+// of 10k, by scanning the catalog per book as loadLibraryData did before
+// indexBooks, and through the index.
+func BenchmarkShelveLibrary(b *testing.B) {
+    books := make([]types.Book, 10000)
+    ids := make([]int, len(books))
+    for i := range books {
+        books[i] = types.Book{ID: i + 1}
+        ids[i] = len(books) - i
+    }
+
+    b.Run("scan", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            var shelf []types.Book
+            for _, id := range ids {
+                for _, book := range books {
+                    if book.ID == id {
+                        shelf = append(shelf, book)
+                        break
+                    }
+                }
+            }
+        }
+    })
+    b.Run("index", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            var shelf []types.Book
+            index := indexBooks(books)
+            for _, id := range ids {
+                if book, ok := index[id]; ok {
+                    shelf = append(shelf, book)
+                }
+            }
+        }
+    })
+}
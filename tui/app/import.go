@@ -0,0 +1,479 @@
+package app
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/importer"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
+    "tui/types"
+    "tui/views"
+)
+
+// importStep is where the import wizard is.
+type importStep int
+
+const (
+    stepPath     importStep = iota // typing the path of the export
+    stepShelves                    // mapping shelves we don't know
+    stepResolve                    // picking the book for ambiguous rows
+    stepConfirm                    // last look before writing anything
+    stepRunning
+    stepDone
+)
+
+// importSource is the kind of file being imported, told by its path.
+type importSource int
+
+const (
+    sourceGoodreads importSource = iota // a CSV export
+    sourceKindle                        // a "My Clippings.txt"
+    sourceCalibre                       // a library folder, metadata.db or .opf
+)
+
+// sourceOf guesses the source from the path: clippings are text files,
+// Calibre is a folder or its metadata, anything else is taken for a CSV.
+func sourceOf(path string) importSource {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".txt":
+        return sourceKindle
+    case ".db", ".opf":
+        return sourceCalibre
+    }
+    if info, err := os.Stat(path); err == nil && info.IsDir() {
+        return sourceCalibre
+    }
+    return sourceGoodreads
+}
+
+// importPageSize is how many books of the catalog each request brings
+// while matching an import.
+const importPageSize = 500
+
+// shelfChoices are what an unknown source shelf can be mapped to, "" leaves
+// its books out.
+var shelfChoices = []string{"", "to_read", "currently_reading", "read"}
+
+var (
+    errNoLibrary = errors.New("no library to import into")
+    errNoStore   = errors.New("no place to keep highlights, see data_dir")
+)
+
+// importWizard walks through an import: file, shelves, ambiguous matches,
+// confirmation and the summary. A Goodreads CSV fills the library, a Kindle
+// clippings file only adds highlights and has no shelves to map, a Calibre
+// library goes onto the one shelf picked for it.
+type importWizard struct {
+    step    importStep
+    path    string
+    source  importSource
+    items   []importer.Item
+    clipped [][]importer.Clipping // the clippings of each item, Kindle only
+    added   int                   // highlights that were new, Kindle only
+    shelves []string       // source shelves that had no mapping
+    mapped  map[string]int // source shelf -> index in shelfChoices
+    current int            // item being resolved, or shelf being mapped
+    cursor  int            // candidate under the cursor, len(Candidates) is "skip"
+    summary importer.Summary
+    err     string
+}
+
+// The import messages carry importer types, which types can't import.
+type importPlannedMsg struct {
+    source  importSource
+    items   []importer.Item
+    clipped [][]importer.Clipping
+}
+
+type importFailedMsg struct {
+    err error
+}
+
+type importDoneMsg struct {
+    summary importer.Summary
+    added   int
+}
+
+// startImport opens the wizard.
+func (m Model) startImport() (Model, tea.Cmd) {
+    m.importWizard = importWizard{}
+    m.currentView = types.ViewImport
+    return m, nil
+}
+
+func (m Model) updateImport(msg tea.Msg) (tea.Model, tea.Cmd) {
+    w := &m.importWizard
+
+    switch msg := msg.(type) {
+    case importPlannedMsg:
+        w.items = msg.items
+        w.clipped = msg.clipped
+        w.source = msg.source
+        w.shelves = importer.Unmapped(w.items)
+        w.mapped = map[string]int{}
+        if w.source == sourceCalibre {
+            // Calibre has no shelves of its own, offer to_read for all of it
+            w.mapped[importer.CalibreShelf] = 1
+        }
+        w.current, w.cursor = 0, 0
+        w.step = stepShelves
+        m.importWizard = w.advance()
+        return m, nil
+    case importFailedMsg:
+        w.err = msg.err.Error()
+        if w.step == stepRunning {
+            w.step = stepConfirm
+        } else {
+            w.step = stepPath
+        }
+        return m, nil
+    case importDoneMsg:
+        w.summary = msg.summary
+        w.added = msg.added
+        w.step = stepDone
+        if w.source == sourceKindle {
+            return m, nil
+        }
+        return m, m.loadLibraryData()
+    }
+
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+    mode, ok := importModes[w.step]
+    if !ok {
+        return m, nil
+    }
+    action := keymap.None
+    if w.step != stepPath || !types.IsTextKey(key) {
+        action = m.keys.MatchMode(mode, key.String())
+    }
+    if action == keymap.Cancel {
+        m.currentView = types.ViewLibrary
+        return m, nil
+    }
+
+    switch w.step {
+    case stepPath:
+        if action == keymap.Submit && strings.TrimSpace(w.path) != "" {
+            w.err = ""
+            return m, m.planImport(strings.TrimSpace(w.path))
+        }
+        w.path = types.EditText(w.path, msg)
+
+    case stepShelves:
+        shelf := w.shelves[w.current]
+        switch action {
+        case keymap.ChoicePrev:
+            w.mapped[shelf] = (w.mapped[shelf] + len(shelfChoices) - 1) % len(shelfChoices)
+        case keymap.ChoiceNext:
+            w.mapped[shelf] = (w.mapped[shelf] + 1) % len(shelfChoices)
+        case keymap.ItemPrev:
+            w.current = max(w.current-1, 0)
+        case keymap.ItemNext:
+            w.current = min(w.current+1, len(w.shelves)-1)
+        case keymap.Submit:
+            for _, shelf := range w.shelves {
+                importer.Remap(w.items, shelf, shelfChoices[w.mapped[shelf]])
+            }
+            w.step, w.current, w.cursor = stepResolve, 0, 0
+            m.importWizard = w.advance()
+        }
+
+    case stepResolve:
+        item := &w.items[w.current]
+        switch action {
+        case keymap.ItemPrev:
+            w.cursor = max(w.cursor-1, 0)
+        case keymap.ItemNext:
+            w.cursor = min(w.cursor+1, len(item.Candidates))
+        case keymap.Skip:
+            w.cursor = len(item.Candidates)
+            fallthrough
+        case keymap.Submit:
+            if w.cursor < len(item.Candidates) {
+                item.Resolve(item.Candidates[w.cursor].Book)
+            } else {
+                item.Skip = true
+            }
+            w.current, w.cursor = w.current+1, 0
+            m.importWizard = w.advance()
+        }
+
+    case stepConfirm:
+        if action == keymap.Submit {
+            w.step = stepRunning
+            if w.source == sourceKindle {
+                return m, m.applyKindle(w.items, w.clipped)
+            }
+            return m, m.applyImport(w.items)
+        }
+
+    case stepDone:
+        if action == keymap.Submit {
+            m.currentView = types.ViewLibrary
+        }
+    }
+    return m, nil
+}
+
+// importModes are the keys of each step, the import runs without any.
+var importModes = map[importStep]keymap.Mode{
+    stepPath:    keymap.ModeImportPath,
+    stepShelves: keymap.ModeImportShelves,
+    stepResolve: keymap.ModeImportResolve,
+    stepConfirm: keymap.ModeImportConfirm,
+    stepDone:    keymap.ModeImportDone,
+}
+
+// advance skips steps with nothing to ask: no unknown shelves, no more
+// ambiguous rows.
+func (w importWizard) advance() importWizard {
+    if w.step == stepShelves && len(w.shelves) == 0 {
+        w.step = stepResolve
+    }
+    if w.step == stepResolve {
+        for w.current < len(w.items) && w.items[w.current].Status != importer.Ambiguous {
+            w.current++
+        }
+        if w.current == len(w.items) {
+            w.step = stepConfirm
+        }
+    }
+    return w
+}
+
+// planImport reads the file and matches it against the catalog, see
+// sourceOf for what is taken for what.
+func (m Model) planImport(path string) tea.Cmd {
+    return func() tea.Msg {
+        path := expandHome(path)
+        msg := importPlannedMsg{source: sourceOf(path)}
+        rows, shelves, err := readImport(path, &msg)
+        if err != nil {
+            return importFailedMsg{err: err}
+        }
+        // Rows can match any book, so the whole catalog is read, page by page
+        catalog, err := api.AllBooks(m.api, importPageSize)
+        if err != nil {
+            return importFailedMsg{err: err}
+        }
+        msg.items = importer.Plan(rows, catalog, shelves)
+        return msg
+    }
+}
+
+// readImport reads the rows of the file, and the shelf mapping they need.
+// Kindle clippings are kept in msg.
+func readImport(path string, msg *importPlannedMsg) ([]importer.Row, map[string]string, error) {
+    if msg.source == sourceCalibre {
+        books, err := importer.ReadCalibre(path, importer.DefaultPagesColumn)
+        if err != nil {
+            return nil, nil, err
+        }
+        // Unmapped, so the shelves step asks where the books go
+        return importer.CalibreRows(books), nil, nil
+    }
+
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer f.Close()
+
+    if msg.source == sourceKindle {
+        clippings, err := importer.ParseKindle(f)
+        if err != nil {
+            return nil, nil, err
+        }
+        var rows []importer.Row
+        rows, msg.clipped = importer.KindleBooks(clippings)
+        return rows, nil, nil
+    }
+    rows, err := importer.ParseGoodreads(f)
+    return rows, importer.GoodreadsShelves, err
+}
+
+func (m Model) applyImport(items []importer.Item) tea.Cmd {
+    libraryID := m.libraryData.LibraryID
+    return func() tea.Msg {
+        if libraryID == 0 {
+            return importFailedMsg{err: errNoLibrary}
+        }
+        return importDoneMsg{summary: importer.Apply(m.api, libraryID, items)}
+    }
+}
+
+// applyKindle keeps the highlights of the matched books in the store.
+func (m Model) applyKindle(items []importer.Item, clipped [][]importer.Clipping) tea.Cmd {
+    st := m.store
+    return func() tea.Msg {
+        if st == nil {
+            return importFailedMsg{err: errNoStore}
+        }
+        highlights, skipped := importer.KindleHighlights(items, clipped)
+        added, err := st.AddHighlights(highlights)
+        if err != nil {
+            return importFailedMsg{err: err}
+        }
+        return importDoneMsg{
+            summary: importer.Summary{Imported: len(items) - len(skipped), Skipped: skipped},
+            added:   added,
+        }
+    }
+}
+
+// expandHome lets the path start with "~/", the shell isn't there to do it.
+func expandHome(path string) string {
+    if rest, ok := strings.CutPrefix(path, "~/"); ok {
+        if home, err := os.UserHomeDir(); err == nil {
+            return home + "/" + rest
+        }
+    }
+    return path
+}
+
+func (m Model) renderImportView() string {
+    w := m.importWizard
+    lines := []string{styles.TitleStyle.Render("📥 Import from Goodreads, Kindle or Calibre")}
+
+    switch w.step {
+    case stepPath:
+        lines = append(lines,
+            "Export your library at goodreads.com → My Books → Import and export,",
+            "then give the path of the CSV file. For highlights, give the",
+            "\"My Clippings.txt\" from the documents folder of your Kindle;",
+            "for ebooks, the folder of your Calibre library:",
+            "",
+            styles.InputStyle.Render(w.path+"█"),
+        )
+
+    case stepShelves:
+        if w.source == sourceCalibre {
+            lines = append(lines, "Calibre has no shelves, where should the books that match go?", "")
+        } else {
+            lines = append(lines, "These Goodreads shelves have no match here, where should their books go?", "")
+        }
+        for i, shelf := range w.shelves {
+            target := shelfChoices[w.mapped[shelf]]
+            if target == "" {
+                target = "leave out"
+            }
+            line := fmt.Sprintf("%-24s → ‹ %s ›", shelf, target)
+            if i == w.current {
+                lines = append(lines, styles.SelectedStyle.Render("▸ "+line))
+            } else {
+                lines = append(lines, "  "+line)
+            }
+        }
+
+    case stepResolve:
+        item := w.items[w.current]
+        lines = append(lines,
+            fmt.Sprintf("Line %d: %s by %s", item.Row.Line, item.Row.Title, item.Row.Author),
+            styles.HintStyle.Render("Several books could be this one, which is it?"),
+            "",
+        )
+        for i, c := range item.Candidates {
+            line := fmt.Sprintf("%s by %s (%d)  %.0f%%", c.Book.Name, c.Book.Author, c.Book.Year, c.Score*100)
+            if i == w.cursor {
+                lines = append(lines, styles.SelectedStyle.Render("▸ "+line))
+            } else {
+                lines = append(lines, "  "+line)
+            }
+        }
+        if w.cursor == len(item.Candidates) {
+            lines = append(lines, styles.SelectedStyle.Render("▸ None of these, skip it"))
+        } else {
+            lines = append(lines, "  None of these, skip it")
+        }
+
+    case stepConfirm, stepRunning:
+        if w.source == sourceKindle {
+            lines = append(lines, m.kindleCounts()...)
+            if w.step == stepRunning {
+                lines = append(lines, "", styles.LoadingStyle.Render("Importing..."))
+            }
+            break
+        }
+        counts := map[string]int{}
+        skipped := 0
+        for _, item := range w.items {
+            if item.Status == importer.Matched && !item.Skip && item.Shelf != "" {
+                counts[item.Shelf]++
+            } else {
+                skipped++
+            }
+        }
+        lines = append(lines,
+            fmt.Sprintf("%d rows read from %s", len(w.items), w.path), "",
+            fmt.Sprintf("  to_read            %d", counts["to_read"]),
+            fmt.Sprintf("  currently_reading  %d", counts["currently_reading"]),
+            fmt.Sprintf("  read               %d", counts["read"]),
+            fmt.Sprintf("  skipped            %d", skipped),
+        )
+        if w.step == stepRunning {
+            lines = append(lines, "", styles.LoadingStyle.Render("Importing..."))
+        }
+
+    case stepDone:
+        s := w.summary
+        done := fmt.Sprintf("Imported %d book(s), %d with a rating; %d unchanged; skipped %d", s.Imported, s.Reviewed, s.Unchanged, len(s.Skipped))
+        switch w.source {
+        case sourceKindle:
+            done = fmt.Sprintf("Stored %d new highlight(s) for %d book(s); skipped %d", w.added, s.Imported, len(s.Skipped))
+        case sourceCalibre:
+            done = fmt.Sprintf("Put %d book(s) on %s; %d unchanged; skipped %d", s.Imported, shelfChoices[w.mapped[importer.CalibreShelf]], s.Unchanged, len(s.Skipped))
+        }
+        lines = append(lines, styles.SuccessStyle.Render(done))
+        // Leave room for the header, nav and footer
+        room := 10
+        if m.height > 0 {
+            room = max(m.height-16, 3)
+        }
+        for i, skip := range s.Skipped {
+            if i == room {
+                lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("  … and %d more", len(s.Skipped)-room)))
+                break
+            }
+            lines = append(lines, fmt.Sprintf("  line %d  %s — %s", skip.Row.Line, views.Truncate(skip.Row.Title, 40), skip.Reason))
+        }
+    }
+
+    if w.err != "" {
+        lines = append(lines, "", styles.ErrorStyle.Render(w.err))
+    }
+    return styles.ContentStyle.Render(strings.Join(lines, "\n"))
+}
+
+// kindleCounts sums up a clippings file before it is stored.
+func (m Model) kindleCounts() []string {
+    w := m.importWizard
+    counts := map[string]int{}
+    books, skipped := 0, 0
+    for i, item := range w.items {
+        if item.Status != importer.Matched || item.Skip {
+            skipped++
+            continue
+        }
+        books++
+        for _, c := range w.clipped[i] {
+            counts[c.Kind]++
+        }
+    }
+    return []string{
+        fmt.Sprintf("%d books read from %s", len(w.items), w.path), "",
+        fmt.Sprintf("  highlights  %d", counts[store.KindHighlight]),
+        fmt.Sprintf("  notes       %d", counts[store.KindNote]),
+        fmt.Sprintf("  bookmarks   %d", counts[store.KindBookmark]),
+        fmt.Sprintf("  in %d book(s), %d skipped", books, skipped),
+    }
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+    "strings"
+    "testing"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/config"
+    "tui/keymap"
+    "tui/types"
+)
+
+// key turns a key name as the key map writes it into a key press.
+func key(name string) tea.KeyMsg {
+    switch name {
+    case "esc":
+        return tea.KeyMsg{Type: tea.KeyEsc}
+    case "enter":
+        return tea.KeyMsg{Type: tea.KeyEnter}
+    case "tab":
+        return tea.KeyMsg{Type: tea.KeyTab}
+    case "backspace":
+        return tea.KeyMsg{Type: tea.KeyBackspace}
+    case "ctrl+c":
+        return tea.KeyMsg{Type: tea.KeyCtrlC}
+    case "ctrl+g":
+        return tea.KeyMsg{Type: tea.KeyCtrlG}
+    case "ctrl+s":
+        return tea.KeyMsg{Type: tea.KeyCtrlS}
+    case " ":
+        return tea.KeyMsg{Type: tea.KeySpace}
+    }
+    return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// press sends keys to the model one at a time and returns it with the
+// command of the last one.
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+    var cmd tea.Cmd
+    for _, k := range keys {
+        var next tea.Model
+        next, cmd = m.Update(key(k))
+        m = next.(Model)
+    }
+    return m, cmd
+}
+
+func quits(cmd tea.Cmd) bool {
+    if cmd == nil {
+        return false
+    }
+    _, ok := cmd().(tea.QuitMsg)
+    return ok
+}
+
+func libraryModel() Model {
+    m := NewModel(config.Defaults(), api.NewFake())
+    m.currentView = types.ViewLibrary
+    m.loggedIn = true
+    m.books = withBooks(nil, []types.Book{
+        {ID: 1, Name: "Dune", Author: "Frank Herbert"},
+        {ID: 2, Name: "Quiet", Author: "Susan Cain"},
+    })
+    return m
+}
+
+func TestTypingIntoInputs(t *testing.T) {
+    tests := []struct {
+        name  string
+        view  types.View
+        setup func(*Model)
+        keys  []string
+        check func(Model) bool
+    }{
+        {"login takes q", types.ViewLogin, nil, []string{"b", "o", "b", "q"},
+            func(m Model) bool { return m.loginForm.Username == "bobq" }},
+        {"login tab changes field", types.ViewLogin, nil, []string{"a", "tab", "p", "w"},
+            func(m Model) bool { return m.loginForm.Username == "a" && m.loginForm.Password == "pw" }},
+        {"search takes q and ?", types.ViewLibrary, nil, []string{"s", "q", "u", "?", "backspace"},
+            func(m Model) bool { return m.searchBar.Active && m.searchBar.Query == "qu" && !m.showHelp }},
+        {"search finds as you type", types.ViewLibrary, nil, []string{"s", "q", "u", "i"},
+            func(m Model) bool { return len(m.searchBar.Results) == 1 && m.searchBar.Results[0].ID == 2 }},
+        {"search tab is not navigation", types.ViewLibrary, nil, []string{"s", "tab"},
+            func(m Model) bool { return m.searchBar.Active && m.selectedNav == 0 }},
+        {"esc leaves search", types.ViewLibrary, nil, []string{"s", "d", "esc"},
+            func(m Model) bool { return !m.searchBar.Active && m.currentView == types.ViewLibrary }},
+        {"enter opens the result", types.ViewLibrary, nil, []string{"s", "d", "u", "enter"},
+            func(m Model) bool { return m.currentView == types.ViewBookDetails && m.selectedBookID == 1 }},
+        {"review takes q and space", types.ViewBookDetails, func(m *Model) { m.selectedBookID = 1 }, []string{"a", "q", " ", "r"},
+            func(m Model) bool { return m.reviewForm.Text == "q r" }},
+        {"review rating keys", types.ViewBookDetails, func(m *Model) { m.selectedBookID = 1 }, []string{"a", "tab", "2"},
+            func(m Model) bool { return m.reviewForm.Rating == 2 && m.reviewForm.Text == "" }},
+    }
+    for _, tt := range tests {
+        m := libraryModel()
+        m.currentView = tt.view
+        if tt.setup != nil {
+            tt.setup(&m)
+        }
+        m, cmd := press(m, tt.keys...)
+        if quits(cmd) || !tt.check(m) {
+            t.Errorf("%s: model after %q: view %v, search %+v, review %+v, login %+v",
+                tt.name, tt.keys, m.currentView, m.searchBar, m.reviewForm, m.loginForm)
+        }
+    }
+}
+
+// Search bars and forms follow the key map, and their footer says so.
+func TestRemappedInputs(t *testing.T) {
+    keys, err := keymap.New("vim", map[string]string{"cancel": "ctrl+g", "submit": "ctrl+s"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        name   string
+        view   types.View
+        keys   []string
+        check  func(Model) bool
+        footer string
+    }{
+        {"search cancel", types.ViewLibrary, []string{"s", "d", "ctrl+g"},
+            func(m Model) bool { return !m.searchBar.Active }, ""},
+        {"esc no longer cancels", types.ViewLibrary, []string{"s", "d", "esc"},
+            func(m Model) bool { return m.searchBar.Active && m.searchBar.Query == "d" }, "ctrl+g: Close search"},
+        {"vim keys are typed", types.ViewLibrary, []string{"s", "j", "k"},
+            func(m Model) bool { return m.searchBar.Query == "jk" }, "↑/k: Previous result"},
+        {"review submit", types.ViewBookDetails, []string{"a", "o", "k", "enter"},
+            func(m Model) bool { return m.reviewForm.Active && m.reviewForm.Text == "ok" }, "ctrl+s: Submit"},
+        {"review cancel", types.ViewBookDetails, []string{"a", "o", "k", "ctrl+g"},
+            func(m Model) bool { return !m.reviewForm.Active }, ""},
+        {"note cancel", types.ViewBookDetails, []string{"n", "hi", "ctrl+g"},
+            func(m Model) bool { return !m.noteForm.Active }, ""},
+        {"notes search done", types.ViewNotes, []string{"/", "x", "ctrl+s"},
+            func(m Model) bool { return !m.notesView.Searching && m.notesView.Query == "x" }, ""},
+    }
+    for _, tt := range tests {
+        m := libraryModel()
+        m.keys = keys
+        m.currentView = tt.view
+        m.selectedBookID = 1
+        m, _ = press(m, tt.keys...)
+        if !tt.check(m) {
+            t.Errorf("%s: model after %q: search %+v, review %+v, note %+v", tt.name, tt.keys, m.searchBar, m.reviewForm, m.noteForm)
+        }
+        if footer := m.renderFooter(); !strings.Contains(footer, tt.footer) {
+            t.Errorf("%s: footer %q", tt.name, footer)
+        }
+    }
+}
+
+func TestHelpOverlayHint(t *testing.T) {
+    m := libraryModel()
+    m.width, m.height = 120, 60
+    m.keys, _ = keymap.New("", map[string]string{"help": "f1", "back": "ctrl+g"})
+    if hint := "Press f1 or ctrl+g to close"; !strings.Contains(m.renderHelpOverlay(), hint) {
+        t.Errorf("help overlay does not say %q", hint)
+    }
+}
+
+func TestQuit(t *testing.T) {
+    m := libraryModel()
+    if _, cmd := press(m, "q"); !quits(cmd) {
+        t.Error("q outside an input did not quit")
+    }
+    if _, cmd := press(m, "s", "ctrl+c"); !quits(cmd) {
+        t.Error("ctrl+c in the search bar did not quit")
+    }
+
+    // A review being written is not lost to a quit key
+    m.currentView = types.ViewBookDetails
+    m.selectedBookID = 1
+    m, cmd := press(m, "a", "h", "i", "ctrl+c")
+    if quits(cmd) || !m.confirmQuit {
+        t.Fatal("quit with a review being written did not ask first")
+    }
+    m, cmd = press(m, "n")
+    if quits(cmd) || m.confirmQuit || m.reviewForm.Text != "hi" {
+        t.Fatalf("n did not go back to the review: %+v", m.reviewForm)
+    }
+    if _, cmd = press(m, "ctrl+c", "y"); !quits(cmd) {
+        t.Error("y did not quit")
+    }
+}
+
+func TestEditText(t *testing.T) {
+    tests := []struct {
+        value string
+        msg   tea.Msg
+        want  string
+    }{
+        {"Du", key("n"), "Dun"},
+        {"Du", key(" "), "Du "},
+        {"Café", key("backspace"), "Caf"},
+        {"", key("backspace"), ""},
+        {"Dune", tea.KeyMsg{Type: tea.KeyCtrlU}, ""},
+        {"Dune", key("enter"), "Dune"},
+        {"Dune", tea.WindowSizeMsg{}, "Dune"},
+    }
+    for _, tt := range tests {
+        if got := types.EditText(tt.value, tt.msg); got != tt.want {
+            t.Errorf("EditText(%q, %v) = %q, want %q", tt.value, tt.msg, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "tui/types"
+)
+
+// resize sends a window size to the model.
+func resize(m Model, width, height int) Model {
+    next, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+    return next.(Model)
+}
+
+// shelvedModel is a library with more books on each shelf than fit a line.
+func shelvedModel() Model {
+    m := libraryModel()
+    m.shelfView.Shelves = map[string][]types.Book{}
+    for i := 1; i <= 60; i++ {
+        shelf := []string{"currently_reading", "to_read", "read"}[i%3]
+        book := types.Book{ID: i, Name: fmt.Sprintf("Book number %d", i), Author: "Ava Mitchell", Pages: i * 10}
+        m.shelfView.Shelves[shelf] = append(m.shelfView.Shelves[shelf], book)
+    }
+    m.libraryData.Shelves = m.shelfView.Shelves
+    return m
+}
+
+func TestShelvesFitTheTerminal(t *testing.T) {
+    for _, width := range []int{60, 80, 100, 160} {
+        for _, bookcase := range []bool{false, true} {
+            m := resize(shelvedModel(), width, 40)
+            m.shelfView.Bookcase = bookcase
+            for _, line := range strings.Split(m.View(), "\n") {
+                if w := lipgloss.Width(line); w > width {
+                    t.Errorf("width %d, bookcase %v: a line is %d columns wide:\n%s", width, bookcase, w, line)
+                    break
+                }
+            }
+        }
+    }
+}
+
+func TestSidebarFolds(t *testing.T) {
+    tests := []struct {
+        name   string
+        widths []int
+        keys   []string
+        open   bool
+    }{
+        {"wide", []int{120}, nil, true},
+        {"narrow", []int{80}, nil, false},
+        {"narrowed", []int{120, 80}, nil, false},
+        {"widened stays folded", []int{80, 120}, nil, false},
+        {"opened by hand", []int{80}, []string{"b"}, true},
+        // Only becoming narrow folds it, resizing within narrow widths doesn't
+        {"opened by hand, resized", []int{80, 90}, []string{"b"}, true},
+        {"closed by hand", []int{120}, []string{"b"}, false},
+    }
+    for _, tt := range tests {
+        m := resize(libraryModel(), tt.widths[0], 40)
+        m, _ = press(m, tt.keys...)
+        for _, w := range tt.widths[1:] {
+            m = resize(m, w, 40)
+        }
+        if m.sidebarOpen != tt.open {
+            t.Errorf("%s: sidebar open = %v, want %v", tt.name, m.sidebarOpen, tt.open)
+        }
+    }
+}
+
+func TestTooSmall(t *testing.T) {
+    tests := []struct {
+        width, height int
+        small         bool
+    }{
+        {0, 0, false}, // no size yet
+        {59, 40, true},
+        {80, 19, true},
+        {60, 20, false},
+    }
+    for _, tt := range tests {
+        m := libraryModel()
+        if tt.width > 0 {
+            m = resize(m, tt.width, tt.height)
+        }
+        if got := strings.Contains(m.View(), "Terminal too small"); got != tt.small {
+            t.Errorf("%dx%d: too small notice = %v, want %v", tt.width, tt.height, got, tt.small)
+        }
+    }
+}
@@ -3,7 +3,13 @@ package app
 import (
     tea "github.com/charmbracelet/bubbletea"
     "tui/api"
+    "tui/config"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
     "tui/types"
+    "tui/views"
+    "tui/zone"
     "time"
 )
 
@@ -11,17 +17,30 @@ type Model struct {
     width  int
     height int
 
-    // API client
-    api *api.Client
+    // Backend, a real HTTP client or an in-memory fake
+    api api.Service
+
+    // Effective configuration
+    config config.Config
 
     // App state
     currentView types.View
     loading     bool
     errorMsg    string
+    notice      string // shown in the footer for a few seconds
+
+    // Connection health
+    connStatus   types.ConnectionStatus
+    latency      time.Duration
+    failedChecks int
 
     // Navigation
     navItems    []types.NavItem
     selectedNav int
+    keys        keymap.KeyMap
+    showHelp    bool
+    confirmQuit bool
+    sidebarOpen bool
 
     // Authentication
     username string
@@ -31,6 +50,7 @@ type Model struct {
     // Data
     libraryData      types.LibraryData
     bookData         types.BookData
+    books            map[int]types.Book // books looked up so far, never changed in place
     profileData      types.ProfileData
     friendsData      []types.Friend
     recommendations  []types.Recommendation
@@ -41,32 +61,72 @@ type Model struct {
     searchBar   types.SearchBar
     bookList    types.BookList
     shelfView   types.ShelfView
+    reviewForm  types.ReviewForm
+    noteForm    types.NoteForm
     readingView types.ReadingView
     profileView types.ProfileView
+    notesView   types.NotesView
 
     // Selected book for details view
     selectedBookID int
+    reviewScroll   int
+    notesOrder     store.Order
+    detailsFrom    types.View // where Back from the details goes
+
+    // Book being dragged with the mouse, and the zones it was hit tested in
+    drag  drag
+    zones zone.Map
+
+    // Page turns waiting for the debounce window to close
+    turns pendingTurns
+
+    // Reading going on in the reading view, and the timeline of a book
+    sitting      sitting
+    timelineView types.TimelineView
+    timelineFrom types.View // where Back from the timeline goes
+
+    // Goodreads or Kindle import in progress
+    importWizard importWizard
+
+    // What the backend has no place for, like highlights; nil until login
+    store *store.Store
 }
 
-func NewModel(apiURL string) Model {
-    apiClient := api.NewClient(apiURL)
+func NewModel(cfg config.Config, service api.Service) Model {
+    // The config was validated already, fall back to the defaults just in case
+    keys, err := keymap.New(cfg.KeymapPreset, cfg.Keymap)
+    if err != nil {
+        keys = keymap.Default()
+    }
+
+    theme, err := styles.Resolve(cfg.Theme)
+    if err != nil {
+        theme = styles.DarkPalette.Theme("dark")
+    }
+    styles.Use(theme)
+    views.Bidi = cfg.Bidi
+    views.WrapShelves = cfg.ShelfLayout == "wrap"
 
     navItems := []types.NavItem{
         {ID: "library", Label: "📚 My Library", View: types.ViewLibrary},
-        {ID: "discover", Label: "🔍 Discover", View: types.ViewLibrary},
+        {ID: "discover", Label: "🔍 Discover", View: types.ViewDiscover},
         {ID: "reading", Label: "📖 Reading", View: types.ViewReading},
         {ID: "friends", Label: "👥 Friends", View: types.ViewFriends},
         {ID: "recommendations", Label: "💡 Recommendations", View: types.ViewRecommendations},
+        {ID: "notes", Label: "📝 Notes", View: types.ViewNotes},
         {ID: "profile", Label: "👤 Profile", View: types.ViewProfile},
     }
 
     return Model{
-        api:          apiClient,
+        api:          service,
+        config:       cfg,
         currentView:  types.ViewLogin,
         navItems:     navItems,
         selectedNav:  0,
+        keys:         keys,
+        sidebarOpen:  true,
         loginForm: types.LoginForm{
-            Username: "",
+            Username: cfg.Username,
             Password: "",
             Focused:  "username",
         },
@@ -74,13 +134,18 @@ func NewModel(apiURL string) Model {
             Shelves: make(map[string][]types.Book),
         },
         shelfView: types.ShelfView{
-            Shelves: make(map[string][]types.Book),
+            Shelves:  make(map[string][]types.Book),
+            Bookcase: cfg.ShelfStyle == "bookcase",
+        },
+        bookList: types.BookList{
+            PageSize: cfg.PageSize,
+            Total:    -1,
         },
     }
 }
 
 func (m Model) Init() tea.Cmd {
-    return nil
+    return m.checkHealth()
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -98,10 +163,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         m.api.SetToken(msg.Token)
 
         // Load initial data
-        return m, tea.Batch(
-            m.loadLibraryData(),
-            m.loadProfileData(),
-        )
+        cmds := []tea.Cmd{m.loadLibraryData(), m.loadProfileData()}
+        st, err := store.Open(m.config.DataDir, msg.Username)
+        if err != nil {
+            cmds = append(cmds, func() tea.Msg { return types.ErrorMsg{Message: "Highlights: " + err.Error()} })
+        }
+        m.store = st
+        return m, tea.Batch(cmds...)
 
     case types.LoginErrorMsg:
         m.loading = false
@@ -110,8 +178,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
     case types.LoadLibraryMsg:
         m.loading = false
+        m.books = withBooks(m.books, msg.Books)
         m.libraryData.Shelves = msg.Shelves
+        m.libraryData.LibraryID = msg.LibraryID
         m.shelfView.Shelves = msg.Shelves
+        m.shelfView.Version++
         return m, nil
 
     case types.LoadUserMsg:
@@ -119,24 +190,108 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         m.profileData.User = msg.User
         return m, nil
 
+    case types.LoadBookDetailsMsg:
+        m.books = withBooks(m.books, []types.Book{msg.Data.Book})
+        if m.selectedBookID == msg.Data.Book.ID {
+            m.bookData = msg.Data
+        }
+        return m, nil
+
+    case types.ReviewSubmittedMsg:
+        if m.bookData.Book.ID == msg.BookID {
+            m.bookData.Reviews = append(m.bookData.Reviews, msg.Review)
+        }
+        return m, nil
+
+    case types.LoadBookPageMsg:
+        m.bookList.Loading = false
+        m.bookList.Books = append(m.bookList.Books, msg.Page.Books...)
+        m.books = withBooks(m.books, msg.Page.Books)
+        m.bookList.Page++
+        m.bookList.Total = msg.Page.Total
+        if len(msg.Page.Books) < m.bookList.PageSize {
+            // A short page is the last one, whatever the total said
+            m.bookList.Total = len(m.bookList.Books)
+        }
+        return m, nil
+
+    case types.LoadReadingSessionsMsg:
+        m.books = withBooks(m.books, msg.Books)
+        m.readingView.Sessions = m.readingSessions(msg.Sessions)
+        if m.readingView.Selected() < 0 && len(m.readingView.Sessions) > 0 {
+            m.readingView.BookID = m.readingView.Sessions[0].Book.ID
+        }
+        return m, nil
+
+    case types.SwitchToReadingMsg:
+        for i, item := range m.navItems {
+            if item.View == types.ViewReading {
+                m.selectedNav = i
+            }
+        }
+        m.currentView = types.ViewReading
+        m.readingView.BookID = msg.BookID
+        m.sitting.opened = time.Now()
+        return m, m.loadReadingSessions()
+
+    case types.FlushTurnsMsg:
+        if msg.Seq != m.turns.seq {
+            return m, nil
+        }
+        return m.flushTurns()
+
+    case types.PagesTurnedMsg:
+        if msg.Finished {
+            return m, m.loadReadingSessions()
+        }
+        return m, nil
+
     case types.ErrorMsg:
         m.loading = false
+        m.notice = ""
+        m.bookList.Loading = false
         m.errorMsg = msg.Message
         return m, m.clearErrorAfter(3)
 
     case types.ClearErrorMsg:
         m.errorMsg = ""
         return m, nil
+
+    case types.NoticeMsg:
+        m.notice = msg.Text
+        return m, m.clearNoticeAfter(5, msg.Text)
+
+    case types.ClearNoticeMsg:
+        if m.notice == msg.Text {
+            m.notice = ""
+        }
+        return m, nil
+
+    case types.HealthTickMsg:
+        return m, m.checkHealth()
+
+    case types.HealthCheckMsg:
+        return m.handleHealthCheck(msg)
     }
 
     switch msg := msg.(type) {
     case tea.WindowSizeMsg:
+        // Fold the sidebar away when the terminal becomes narrow, the
+        // shelves need the room more. It can still be opened by hand.
+        if msg.Width < narrowWidth && (m.width == 0 || m.width >= narrowWidth) {
+            m.sidebarOpen = false
+        }
         m.width = msg.Width
         m.height = msg.Height
         return m, nil
 
     case tea.KeyMsg:
-        cmd = m.handleKeyPress(msg)
+        if handled, next, keyCmd := m.handleKeyPress(msg); handled {
+            return next, keyCmd
+        }
+
+    case tea.MouseMsg:
+        return m.handleMouse(msg)
     }
 
     // Delegate to specific view handlers
@@ -149,30 +304,156 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         return m.updateBookDetails(msg)
     case types.ViewReading:
         return m.updateReading(msg)
+    case types.ViewDiscover:
+        return m.updateDiscover(msg)
+    case types.ViewImport:
+        return m.updateImport(msg)
+    case types.ViewNotes:
+        return m.updateNotes(msg)
+    case types.ViewTimeline:
+        return m.updateTimeline(msg)
     case types.ViewProfile:
         return m.updateProfile(msg)
     default:
+        if key, ok := msg.(tea.KeyMsg); ok && m.keys.Match(m.currentView, key.String()) == keymap.Back {
+            m.currentView = types.ViewLibrary
+        }
         return m, cmd
     }
 }
 
-func (m Model) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
-    switch msg.String() {
-    case "ctrl+c", "q":
-        return tea.Quit
-    case "tab":
-        if m.currentView == types.ViewLogin {
-            m.loginForm.Focused = "password"
+// handleKeyPress deals with app-wide shortcuts. It reports whether the key
+// was consumed; otherwise the key goes on to the current view's handler.
+//
+// While a text input has focus it gets every key first: only a non-printable
+// quit key (ctrl+c by default) still works, so typing "q" never exits.
+func (m Model) handleKeyPress(msg tea.KeyMsg) (bool, Model, tea.Cmd) {
+    if m.confirmQuit {
+        switch msg.String() {
+        case "y", "Y":
+            next, cmd := m.quit()
+            return true, next, cmd
+        case "n", "N", "esc":
+            m.confirmQuit = false
+        }
+        return true, m, nil
+    }
+
+    action := m.keys.Match(m.currentView, msg.String())
+
+    if m.inputFocused() {
+        if action == keymap.Quit && !types.IsTextKey(msg) {
+            next, cmd := m.requestQuit()
+            return true, next, cmd
+        }
+        return false, m, nil
+    }
+
+    if m.showHelp {
+        switch action {
+        case keymap.Quit:
+            next, cmd := m.requestQuit()
+            return true, next, cmd
+        case keymap.Help, keymap.Back:
+            m.showHelp = false
+        }
+        return true, m, nil
+    }
+
+    switch action {
+    case keymap.Quit:
+        next, cmd := m.requestQuit()
+        return true, next, cmd
+    case keymap.Help:
+        m.showHelp = true
+        return true, m, nil
+    case keymap.NavNext, keymap.NavPrev:
+        if !m.loggedIn {
+            return false, m, nil
         }
-    case "shift+tab":
-        if m.currentView == types.ViewLogin {
-            m.loginForm.Focused = "username"
+        delta := 1
+        if action == keymap.NavPrev {
+            delta = -1
         }
+        m.selectedNav = (m.selectedNav + delta + len(m.navItems)) % len(m.navItems)
+        next, cmd := m.activateNav()
+        return true, next, cmd
+    }
+
+    return false, m, nil
+}
+
+// inputFocused reports whether a text component is capturing key presses.
+func (m Model) inputFocused() bool {
+    switch {
+    case m.currentView == types.ViewLogin:
+        return true
+    case m.currentView == types.ViewLibrary && m.searchBar.Active:
+        return true
+    case m.currentView == types.ViewBookDetails && m.reviewForm.Active:
+        return true
+    case (m.currentView == types.ViewBookDetails || m.currentView == types.ViewReading) && m.noteForm.Active:
+        return true
+    case m.currentView == types.ViewNotes && m.notesView.Searching:
+        return true
+    case m.currentView == types.ViewImport && m.importWizard.step == stepPath:
+        return true
+    }
+    return false
+}
+
+// requestQuit exits right away unless there are edits that would be lost.
+func (m Model) requestQuit() (Model, tea.Cmd) {
+    if m.reviewForm.Dirty() || m.noteForm.Dirty() {
+        m.showHelp = false
+        m.confirmQuit = true
+        return m, nil
     }
-    return nil
+    return m.quit()
 }
 
+// activateNav switches to the selected nav item's view and loads its data.
+// Page turns still pending in the reading view are sent, and the sitting
+// kept, on the way out.
+func (m Model) activateNav() (Model, tea.Cmd) {
+    m, leave := m.leaveBook()
+    m, load := m.showNav()
+    return m, tea.Batch(leave, load)
+}
+
+func (m Model) showNav() (Model, tea.Cmd) {
+    m.currentView = m.navItems[m.selectedNav].View
+
+    switch m.currentView {
+    case types.ViewLibrary:
+        return m, m.loadLibraryData()
+    case types.ViewProfile:
+        return m, m.loadProfileData()
+    case types.ViewFriends:
+        return m, m.loadFriendsData()
+    case types.ViewRecommendations:
+        return m, m.loadRecommendations()
+    case types.ViewReading:
+        m.sitting.opened = time.Now()
+        return m, m.loadReadingSessions()
+    case types.ViewDiscover:
+        if len(m.bookList.Books) == 0 {
+            return m.loadNextPage()
+        }
+    }
+    return m, nil
+}
+
+// View draws the frame, without the zone markers the mouse handler reads.
 func (m Model) View() string {
+    return zone.Strip(m.render())
+}
+
+func (m Model) render() string {
+    if m.tooSmall() {
+        return m.renderTooSmall()
+    }
+
     if m.loading {
         return m.renderLoading()
     }
@@ -181,6 +462,14 @@ func (m Model) View() string {
         return m.renderError()
     }
 
+    if m.confirmQuit {
+        return m.renderConfirmQuit()
+    }
+
+    if m.showHelp {
+        return m.renderHelpOverlay()
+    }
+
     // Render main layout with header, content, and footer
     return m.renderLayout()
 }
@@ -0,0 +1,133 @@
+package app
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/config"
+    "tui/types"
+)
+
+// run feeds the messages of cmd back into the model until nothing is left,
+// like the Bubble Tea loop does. Commands that take a while are ticks for
+// later, they are left out.
+func run(t *testing.T, m Model, cmd tea.Cmd) Model {
+    t.Helper()
+    queue := []tea.Cmd{cmd}
+    for steps := 0; len(queue) > 0; steps++ {
+        if steps > 1000 {
+            t.Fatal("commands never settle")
+        }
+        c := queue[0]
+        queue = queue[1:]
+        if c == nil {
+            continue
+        }
+        done := make(chan tea.Msg, 1)
+        go func() { done <- c() }()
+        var msg tea.Msg
+        select {
+        case msg = <-done:
+        case <-time.After(200 * time.Millisecond):
+            continue
+        }
+
+        switch msg := msg.(type) {
+        case nil:
+        case tea.BatchMsg:
+            queue = append(queue, msg...)
+        case types.FlushTurnsMsg, types.HealthTickMsg, types.ClearErrorMsg:
+        default:
+            next, cmd := m.Update(msg)
+            m = next.(Model)
+            queue = append(queue, cmd)
+        }
+    }
+    return m
+}
+
+func typeKeys(t *testing.T, m Model, keys ...tea.KeyMsg) Model {
+    t.Helper()
+    for _, key := range keys {
+        next, cmd := m.Update(key)
+        m = run(t, next.(Model), cmd)
+    }
+    return m
+}
+
+func text(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+func newTestModel(t *testing.T) Model {
+    cfg := config.Defaults()
+    cfg.DataDir = t.TempDir()
+    return NewModel(cfg, api.NewFake())
+}
+
+func TestLoginAgainstFake(t *testing.T) {
+    tests := []struct {
+        name, password string
+        loggedIn       bool
+        errorMsg       string
+    }{
+        {"right password", api.DemoPassword, true, ""},
+        {"wrong password", "nope", false, "Login failed: wrong username or password"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            m := typeKeys(t, newTestModel(t),
+                text(api.DemoUsername), tea.KeyMsg{Type: tea.KeyTab},
+                text(tt.password), tea.KeyMsg{Type: tea.KeyEnter})
+
+            if m.loggedIn != tt.loggedIn || m.errorMsg != tt.errorMsg {
+                t.Fatalf("loggedIn = %v, errorMsg = %q; want %v, %q", m.loggedIn, m.errorMsg, tt.loggedIn, tt.errorMsg)
+            }
+            // Only the six books of the demo library are looked up, not the catalog
+            if tt.loggedIn && (m.currentView != types.ViewLibrary || len(m.books) != 6) {
+                t.Fatalf("view %v with %d books after login", m.currentView, len(m.books))
+            }
+        })
+    }
+}
+
+// catalogGuard is a backend that fails if the whole catalog is fetched.
+type catalogGuard struct {
+    api.Service
+    mu     sync.Mutex
+    looked []int
+}
+
+func (g *catalogGuard) ListBooks() ([]types.Book, error) {
+    return nil, errors.New("ListBooks fetched the whole catalog")
+}
+
+func (g *catalogGuard) GetBook(bookID int) (types.Book, error) {
+    g.mu.Lock()
+    g.looked = append(g.looked, bookID)
+    g.mu.Unlock()
+    return g.Service.GetBook(bookID)
+}
+
+func TestLibraryLooksUpOnlyItsBooks(t *testing.T) {
+    fake := api.NewFake()
+    if _, err := fake.Login(api.DemoUsername, api.DemoPassword); err != nil {
+        t.Fatal(err)
+    }
+    g := &catalogGuard{Service: fake}
+    m := newTestModel(t)
+    m.api, m.username = g, api.DemoUsername
+
+    msg, ok := m.loadLibraryData()().(types.LoadLibraryMsg)
+    if !ok {
+        t.Fatalf("loadLibraryData failed")
+    }
+    sort.Ints(g.looked)
+    if fmt.Sprint(g.looked) != "[1 3 5 7 17 20]" || len(msg.Shelves["to_read"]) != 3 {
+        t.Errorf("looked up %v, shelves %v", g.looked, msg.Shelves)
+    }
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+    "strings"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/types"
+    "tui/views"
+    "tui/zone"
+)
+
+// drag follows a book from the mouse press on it to the release.
+type drag struct {
+    active   bool
+    shelf    string
+    index    int
+    book     types.Book
+    moved    bool
+    selected bool // the book was already selected when pressed
+}
+
+// handleMouse hit tests mouse events against the zones of the frame on
+// screen, drawn again from the model that View drew it from.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+    // Overlays cover the zones underneath
+    if !m.loggedIn || m.showHelp || m.confirmQuit || m.tooSmall() {
+        return m, nil
+    }
+    m.zones = zone.Scan(m.render())
+
+    if msg.Type == tea.MouseLeft {
+        if z, ok := m.zones.Find("nav:", msg.X, msg.Y); ok {
+            i := 0
+            for i < len(m.navItems) && views.NavZone(i) != z.ID {
+                i++
+            }
+            if i < len(m.navItems) {
+                m.selectedNav = i
+                return m.activateNav()
+            }
+        }
+    }
+
+    switch m.currentView {
+    case types.ViewLibrary:
+        if !m.searchBar.Active {
+            return m.mouseLibrary(msg)
+        }
+    case types.ViewBookDetails:
+        if !m.reviewForm.Active {
+            return m.mouseBookDetails(msg)
+        }
+    }
+    return m, nil
+}
+
+// mouseLibrary: a click selects a book and a second click opens it, the
+// wheel moves along the shelf under the pointer, and a book dropped on
+// another shelf moves there.
+func (m Model) mouseLibrary(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+    switch msg.Type {
+    case tea.MouseLeft:
+        z, ok := m.zones.Find("book:", msg.X, msg.Y)
+        if !ok {
+            return m, nil
+        }
+        shelf, i, ok := views.ParseBookZone(z.ID)
+        books := m.shelfView.Shelves[shelf]
+        if !ok || i >= len(books) {
+            return m, nil
+        }
+        selected := m.selectShelf(shelf) && m.shelfView.SelectedBook == i
+        m.shelfView.SelectedBook = i
+        m.drag = drag{active: true, shelf: shelf, index: i, book: books[i], selected: selected}
+
+    case tea.MouseMotion:
+        if m.drag.active {
+            m.drag.moved = true
+        }
+
+    case tea.MouseRelease:
+        d := m.drag
+        m.drag = drag{}
+        if !d.active {
+            return m, nil
+        }
+        if z, ok := m.zones.Find("shelf:", msg.X, msg.Y); ok {
+            target := strings.TrimPrefix(z.ID, "shelf:")
+            if d.moved && target != d.shelf {
+                m.selectShelf(target)
+                return m, m.moveBook(d.book.ID, target)
+            }
+        }
+        if !d.moved && d.selected {
+            return m.openBook(d.book)
+        }
+
+    case tea.MouseWheelUp, tea.MouseWheelDown:
+        z, ok := m.zones.Find("shelf:", msg.X, msg.Y)
+        if !ok {
+            return m, nil
+        }
+        shelf := strings.TrimPrefix(z.ID, "shelf:")
+        m.selectShelf(shelf)
+        delta := 1
+        if msg.Type == tea.MouseWheelUp {
+            delta = -1
+        }
+        books := m.shelfView.Shelves[shelf]
+        m.shelfView.SelectedBook = clamp(m.shelfView.SelectedBook+delta, 0, max(len(books)-1, 0))
+    }
+    return m, nil
+}
+
+// selectShelf focuses a shelf by name, reporting whether it was already.
+func (m *Model) selectShelf(name string) bool {
+    for i, shelf := range views.ShelfOrder {
+        if shelf == name {
+            if i == m.shelfView.SelectedShelf {
+                return true
+            }
+            m.shelfView = m.moveShelf(i - m.shelfView.SelectedShelf)
+            return false
+        }
+    }
+    return false
+}
+
+// mouseBookDetails makes the action buttons clickable and scrolls reviews.
+func (m Model) mouseBookDetails(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+    switch msg.Type {
+    case tea.MouseWheelUp, tea.MouseWheelDown:
+        if z, ok := m.zones.Get(views.ZoneReviews); ok && z.Contains(msg.X, msg.Y) {
+            delta := 1
+            if msg.Type == tea.MouseWheelUp {
+                delta = -1
+            }
+            m.reviewScroll = views.ClampReviewOffset(m.reviewScroll+delta, len(m.bookData.Reviews))
+        }
+
+    case tea.MouseLeft:
+        z, ok := m.zones.Find("button:", msg.X, msg.Y)
+        if !ok || m.selectedBookID == 0 {
+            return m, nil
+        }
+        switch z.ID {
+        case views.ButtonStartReading:
+            return m, m.startReading(m.selectedBookID)
+        case views.ButtonAddToLibrary:
+            // Only books not in the library yet, this isn't a way to move one
+            for _, books := range m.shelfView.Shelves {
+                for _, book := range books {
+                    if book.ID == m.selectedBookID {
+                        return m, nil
+                    }
+                }
+            }
+            return m, m.addToLibrary(m.selectedBookID, "to_read")
+        case views.ButtonAddReview:
+            m.reviewForm = types.ReviewForm{Active: true, Rating: 5, Focused: "text"}
+        case views.ButtonRate:
+            m.reviewForm = types.ReviewForm{Active: true, Rating: 5, Focused: "rating"}
+        }
+    }
+    return m, nil
+}
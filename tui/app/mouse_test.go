@@ -0,0 +1,102 @@
+package app
+
+import (
+    "fmt"
+    "testing"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/types"
+    "tui/views"
+    "tui/zone"
+)
+
+// moveRecorder is a backend that writes down the books it is asked to move.
+type moveRecorder struct {
+    api.Service
+    moves []string
+}
+
+func (r *moveRecorder) MoveBook(libraryID, bookID int, shelf string) error {
+    r.moves = append(r.moves, fmt.Sprintf("%d to %s", bookID, shelf))
+    return nil
+}
+
+// mouse sends a mouse event to the middle of the zone id, as the model
+// draws it now.
+func mouse(t *testing.T, m Model, typ tea.MouseEventType, id string) (Model, tea.Cmd) {
+    t.Helper()
+    z, ok := zone.Scan(m.render()).Get(id)
+    if !ok {
+        t.Fatalf("no zone %q on screen", id)
+    }
+    next, cmd := m.Update(tea.MouseMsg{Type: typ, X: (z.X0 + z.X1) / 2, Y: (z.Y0 + z.Y1) / 2})
+    return next.(Model), cmd
+}
+
+func TestMouseLibrary(t *testing.T) {
+    m := resize(shelvedModel(), 120, 40)
+    rec := &moveRecorder{Service: m.api}
+    m.api = rec
+    m.libraryData.LibraryID = 1
+
+    // A click selects a book, a second click opens it
+    m, _ = mouse(t, m, tea.MouseLeft, views.BookZone("read", 2))
+    m, _ = mouse(t, m, tea.MouseRelease, views.BookZone("read", 2))
+    if shelf := views.ShelfOrder[m.shelfView.SelectedShelf]; shelf != "read" || m.shelfView.SelectedBook != 2 {
+        t.Fatalf("first click selected %s %d", shelf, m.shelfView.SelectedBook)
+    }
+    m, _ = mouse(t, m, tea.MouseLeft, views.BookZone("read", 2))
+    opened, _ := mouse(t, m, tea.MouseRelease, views.BookZone("read", 2))
+    if opened.currentView != types.ViewBookDetails || opened.selectedBookID != m.shelfView.Shelves["read"][2].ID {
+        t.Fatalf("second click: view %v, book %d", opened.currentView, opened.selectedBookID)
+    }
+
+    // Dragged onto another shelf, the book moves there
+    book := m.shelfView.Shelves["to_read"][0].ID
+    m, _ = mouse(t, m, tea.MouseLeft, views.BookZone("to_read", 0))
+    m, _ = mouse(t, m, tea.MouseMotion, views.ShelfZone("currently_reading"))
+    m, cmd := mouse(t, m, tea.MouseRelease, views.ShelfZone("currently_reading"))
+    if cmd == nil {
+        t.Fatal("the drop did not move the book")
+    }
+    cmd()
+    if want := fmt.Sprintf("%d to currently_reading", book); len(rec.moves) != 1 || rec.moves[0] != want {
+        t.Fatalf("moves %q, want %q", rec.moves, want)
+    }
+
+    // The wheel moves along the shelf under the pointer
+    m, _ = mouse(t, m, tea.MouseWheelDown, views.ShelfZone("read"))
+    if shelf := views.ShelfOrder[m.shelfView.SelectedShelf]; shelf != "read" || m.shelfView.SelectedBook != 1 {
+        t.Errorf("wheel selected %s %d", shelf, m.shelfView.SelectedBook)
+    }
+}
+
+func TestMouseNavAndButtons(t *testing.T) {
+    m := resize(shelvedModel(), 120, 40)
+    m, _ = mouse(t, m, tea.MouseLeft, views.NavZone(1))
+    if m.selectedNav != 1 {
+        t.Fatalf("clicking the nav selected %d", m.selectedNav)
+    }
+
+    m = resize(shelvedModel(), 120, 40)
+    m, _ = m.openBook(m.shelfView.Shelves["read"][0])
+    m.bookData.Reviews = make([]types.Review, 20)
+    m, _ = mouse(t, m, tea.MouseWheelDown, views.ZoneReviews)
+    if m.reviewScroll != 1 {
+        t.Errorf("wheel over the reviews scrolled to %d", m.reviewScroll)
+    }
+    m, _ = mouse(t, m, tea.MouseLeft, views.ButtonRate)
+    if !m.reviewForm.Active || m.reviewForm.Focused != "rating" {
+        t.Errorf("the rate button gave %+v", m.reviewForm)
+    }
+
+    // The help overlay covers the zones underneath
+    m.reviewForm = types.ReviewForm{}
+    z, _ := zone.Scan(m.render()).Get(views.ButtonRate)
+    m.showHelp = true
+    next, _ := m.Update(tea.MouseMsg{Type: tea.MouseLeft, X: z.X0, Y: z.Y0})
+    if next.(Model).reviewForm.Active {
+        t.Error("a click went through the help overlay")
+    }
+}
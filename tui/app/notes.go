@@ -0,0 +1,248 @@
+package app
+
+import (
+    "fmt"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "tui/api"
+    "tui/export"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
+    "tui/types"
+    "tui/views"
+)
+
+// openNoteForm starts a note, quote or bookmark on a book, on the page the
+// book is open at in the reading view.
+func (m Model) openNoteForm(bookID int, kind string) Model {
+    page := ""
+    for _, s := range m.readingView.Sessions {
+        if s.Book.ID == bookID && s.CurrentPage > 0 {
+            page = strconv.Itoa(s.CurrentPage)
+        }
+    }
+    m.noteForm = types.NoteForm{Active: true, BookID: bookID, Kind: kind, Page: page, Focused: "text"}
+    return m
+}
+
+func (m Model) updateNoteForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    action := keymap.None
+    if !types.IsTextKey(key) {
+        action = m.keys.MatchMode(keymap.ModeNote, key.String())
+    }
+    switch action {
+    case keymap.Cancel:
+        m.noteForm = types.NoteForm{}
+        return m, nil
+    case keymap.NextField, keymap.PrevField:
+        if m.noteForm.Focused == "text" {
+            m.noteForm.Focused = "page"
+        } else {
+            m.noteForm.Focused = "text"
+        }
+        return m, nil
+    case keymap.Submit:
+        if m.noteForm.Dirty() {
+            form := m.noteForm
+            m.noteForm = types.NoteForm{}
+            return m, m.saveNote(form)
+        }
+        return m, nil
+    }
+
+    if m.noteForm.Focused == "page" {
+        // Digits only, the page is a number
+        switch key.Type {
+        case tea.KeyRunes:
+            if _, err := strconv.Atoi(string(key.Runes)); err != nil {
+                return m, nil
+            }
+            fallthrough
+        case tea.KeyBackspace, tea.KeyCtrlU:
+            m.noteForm.Page = types.EditText(m.noteForm.Page, msg)
+        }
+        return m, nil
+    }
+
+    m.noteForm.Text = types.EditText(m.noteForm.Text, msg)
+    return m, nil
+}
+
+// saveNote keeps the note in the store and says so in the footer.
+func (m Model) saveNote(form types.NoteForm) tea.Cmd {
+    st := m.store
+    return func() tea.Msg {
+        if st == nil {
+            return types.ErrorMsg{Message: errNoStore.Error()}
+        }
+        page, _ := strconv.Atoi(form.Page)
+        h := store.Highlight{BookID: form.BookID, Kind: form.Kind, Text: strings.TrimSpace(form.Text), Page: page}
+        if err := st.Add(h); err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+
+        text := "Note saved"
+        switch form.Kind {
+        case store.KindQuote:
+            text = "Quote saved"
+        case store.KindBookmark:
+            text = "Bookmark “" + h.Text + "” saved"
+        }
+        if page > 0 {
+            text += fmt.Sprintf(" on p. %d", page)
+        }
+        return types.NoticeMsg{Text: text}
+    }
+}
+
+// notes are the notes the notes view lists: those matching its query, the
+// latest first.
+func (m Model) notes() []store.Highlight {
+    if m.store == nil {
+        return nil
+    }
+    found := m.store.Search(m.notesView.Query)
+    for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+        found[i], found[j] = found[j], found[i]
+    }
+    return found
+}
+
+func (m Model) updateNotes(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    nv := &m.notesView
+    if nv.Searching {
+        action := keymap.None
+        if !types.IsTextKey(key) {
+            action = m.keys.MatchMode(keymap.ModeNotesSearch, key.String())
+        }
+        switch action {
+        case keymap.Submit, keymap.Cancel:
+            nv.Searching = false
+        default:
+            nv.Query = types.EditText(nv.Query, msg)
+            nv.Selected = 0
+        }
+        return m, nil
+    }
+
+    notes := m.notes()
+    switch m.keys.Match(types.ViewNotes, key.String()) {
+    case keymap.ItemPrev:
+        nv.Selected = max(nv.Selected-1, 0)
+    case keymap.ItemNext:
+        nv.Selected = max(min(nv.Selected+1, len(notes)-1), 0)
+    case keymap.Search:
+        nv.Searching = true
+    case keymap.OpenBook:
+        if nv.Selected < len(notes) {
+            id := notes[nv.Selected].BookID
+            book, ok := m.books[id]
+            if !ok {
+                book = types.Book{ID: id, Name: fmt.Sprintf("Book %d", id)}
+            }
+            return m.openBook(book)
+        }
+    case keymap.Export:
+        return m.exportNotes()
+    case keymap.Back:
+        if nv.Query != "" {
+            nv.Query, nv.Selected = "", 0
+        } else {
+            m.currentView = types.ViewLibrary
+        }
+    }
+    return m, nil
+}
+
+// exportNotes writes a Markdown note per book into the notes folder of the
+// export directory, for an Obsidian vault.
+func (m Model) exportNotes() (Model, tea.Cmd) {
+    if m.store == nil {
+        return m, func() tea.Msg { return types.ErrorMsg{Message: errNoStore.Error()} }
+    }
+    dir := filepath.Join(expandHome(m.config.ExportDir), export.NotesFolder)
+    st, known := m.store, m
+    return m, func() tea.Msg {
+        notes := st.All()
+        ids := make([]int, 0, len(notes))
+        for _, n := range notes {
+            ids = append(ids, n.BookID)
+        }
+        // Notes can be on books not seen in this run, look those up
+        missing, err := api.GetBooks(known.api, known.missingBooks(ids))
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        n, err := export.WriteVault(dir, append(known.knownBooks(), missing...), notes)
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        return types.NoticeMsg{Text: fmt.Sprintf("Wrote notes on %d book(s) to %s", n, dir)}
+    }
+}
+
+func (m Model) renderNotesView() string {
+    // Header, nav bar, footer, title and search take about 14 lines
+    rows, width := 20, 80
+    if m.height > 0 {
+        rows = max(m.height-14, 5)
+    }
+    if m.width > 0 {
+        width = max(m.width-styles.ContentStyle.GetHorizontalFrameSize(), 40)
+    }
+
+    return lipgloss.JoinVertical(
+        lipgloss.Top,
+        m.renderNavBar(),
+        styles.ContentStyle.Render(views.RenderNotes(m.notesView, m.notes(), m.books, rows, width)),
+    )
+}
+
+func (m Model) renderNoteForm() string {
+    label := lipgloss.NewStyle().Bold(true)
+
+    title := "📝 Your note"
+    switch m.noteForm.Kind {
+    case store.KindQuote:
+        title = "❝ A quote"
+    case store.KindBookmark:
+        title = "🔖 Name the bookmark"
+    }
+    text, page := m.noteForm.Text, m.noteForm.Page
+    if page == "" {
+        page = "—"
+    }
+    if m.noteForm.Focused == "text" {
+        text += "█"
+    } else {
+        page = lipgloss.NewStyle().Underline(true).Render(m.noteForm.Page + "█")
+    }
+
+    return styles.PanelStyle.Copy().
+        Width(60).
+        Padding(1, 2).
+        BorderForeground(styles.SecondaryColor).
+        Render(
+            lipgloss.JoinVertical(lipgloss.Left,
+                label.Render(title),
+                text,
+                "",
+                label.Render("Page: ")+page,
+            ),
+        )
+}
@@ -0,0 +1,264 @@
+package app
+
+import (
+    "fmt"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/keymap"
+    "tui/store"
+    "tui/types"
+)
+
+const (
+    // turnDebounce is how long the keys have to rest before the page turns
+    // gathered so far are sent as one request.
+    turnDebounce = 400 * time.Millisecond
+    // turnMaxWait bounds how long turns are held while a key stays down.
+    turnMaxWait = 2 * time.Second
+    // sittingGap is how long the pages can rest before the next turn starts
+    // a new sitting, the reader has been away.
+    sittingGap = 20 * time.Minute
+)
+
+// sitting is the stretch of reading going on in the reading view. It is
+// kept in the store once the reader leaves the book, if pages were turned.
+type sitting struct {
+    bookID    int
+    startPage int
+    endPage   int
+    start     time.Time
+    last      time.Time // of the latest turn
+    opened    time.Time // when the book was selected, reading starts before the first turn
+}
+
+// pendingTurns are page turns shown already but not sent to the backend.
+type pendingTurns struct {
+    bookID int
+    delta  int // net pages, negative is back
+    since  time.Time
+    seq    int // bumped on every turn, see FlushTurnsMsg
+}
+
+func (m Model) updateReading(msg tea.Msg) (tea.Model, tea.Cmd) {
+    if m.noteForm.Active {
+        return m.updateNoteForm(msg)
+    }
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    rv := m.readingView
+    switch action := m.keys.Match(types.ViewReading, key.String()); action {
+    case keymap.ItemPrev:
+        return m.selectSession(rv.Selected() - 1)
+    case keymap.ItemNext:
+        return m.selectSession(rv.Selected() + 1)
+    case keymap.PagePrev:
+        return m.turnPages(-1)
+    case keymap.PageNext:
+        return m.turnPages(1)
+    case keymap.OpenBook:
+        if i := rv.Selected(); i >= 0 {
+            m, leave := m.leaveBook()
+            m, load := m.openBook(rv.Sessions[i].Book)
+            return m, tea.Batch(leave, load)
+        }
+    case keymap.AddNote, keymap.AddQuote, keymap.AddBookmark:
+        if i := rv.Selected(); i >= 0 {
+            return m.openNoteForm(rv.Sessions[i].Book.ID, noteKinds[action]), nil
+        }
+    case keymap.Timeline:
+        if i := rv.Selected(); i >= 0 {
+            return m.openTimeline(rv.Sessions[i].Book.ID)
+        }
+    case keymap.Back:
+        m, leave := m.leaveBook()
+        m.currentView = types.ViewLibrary
+        return m, leave
+    }
+    return m, nil
+}
+
+// noteKinds are what the note keys write.
+var noteKinds = map[keymap.Action]string{
+    keymap.AddNote:     store.KindNote,
+    keymap.AddQuote:    store.KindQuote,
+    keymap.AddBookmark: store.KindBookmark,
+}
+
+// selectSession moves to another book, sending the turns made in this one.
+func (m Model) selectSession(i int) (Model, tea.Cmd) {
+    if i < 0 || i >= len(m.readingView.Sessions) {
+        return m, nil
+    }
+    m, leave := m.leaveBook()
+    m.readingView.BookID = m.readingView.Sessions[i].Book.ID
+    m.sitting.opened = time.Now()
+    return m, leave
+}
+
+// turnPages moves the selected book at once and holds the request back
+// until the keys rest, so holding an arrow down sends one TurnPage.
+func (m Model) turnPages(delta int) (Model, tea.Cmd) {
+    i := m.readingView.Selected()
+    if i < 0 {
+        return m, nil
+    }
+    session := m.readingView.Sessions[i]
+    page := max(session.CurrentPage+delta, 1)
+    if session.Book.Pages > 0 {
+        page = min(page, session.Book.Pages)
+    }
+    if page == session.CurrentPage {
+        return m, nil
+    }
+
+    var flush tea.Cmd
+    if m.turns.delta != 0 && m.turns.bookID != session.Book.ID {
+        m, flush = m.flushTurns()
+    }
+
+    sessions := append([]types.ReadingSession(nil), m.readingView.Sessions...)
+    sessions[i].CurrentPage = page
+    m.readingView.Sessions = sessions
+
+    m, ended := m.recordTurn(session.Book.ID, session.CurrentPage, page)
+    flush = tea.Batch(flush, ended)
+
+    if m.turns.delta == 0 {
+        m.turns.since = time.Now()
+    }
+    m.turns.bookID = session.Book.ID
+    m.turns.delta += page - session.CurrentPage
+    m.turns.seq++
+
+    // The last page ends the session, no point waiting for more turns
+    if page == session.Book.Pages || time.Since(m.turns.since) >= turnMaxWait {
+        m, send := m.flushTurns()
+        return m, tea.Batch(flush, send)
+    }
+
+    seq := m.turns.seq
+    return m, tea.Batch(flush, tea.Tick(turnDebounce, func(time.Time) tea.Msg {
+        return types.FlushTurnsMsg{Seq: seq}
+    }))
+}
+
+// flushTurns sends the pending turns as a single TurnPage with their net
+// direction and count. Anything leaving the reading view calls it first.
+func (m Model) flushTurns() (Model, tea.Cmd) {
+    if m.turns.delta == 0 {
+        return m, nil
+    }
+    bookID, delta := m.turns.bookID, m.turns.delta
+    m.turns.delta = 0
+
+    finished := false
+    for _, s := range m.readingView.Sessions {
+        if s.Book.ID == bookID {
+            finished = s.Book.Pages > 0 && s.CurrentPage >= s.Book.Pages
+        }
+    }
+
+    direction, count := "forward", delta
+    if delta < 0 {
+        direction, count = "back", -delta
+    }
+    return m, func() tea.Msg {
+        if err := m.api.TurnPage(bookID, direction, count); err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        return types.PagesTurnedMsg{BookID: bookID, Finished: finished}
+    }
+}
+
+// leaveBook sends the pending turns and keeps the sitting, for anything
+// that takes the reader away from the selected book.
+func (m Model) leaveBook() (Model, tea.Cmd) {
+    m, flush := m.flushTurns()
+    m, end := m.endSitting()
+    return m, tea.Batch(flush, end)
+}
+
+// recordTurn follows the sitting as a page is turned in a book from one
+// page to another. Turning in another book, or after a long rest, ends the
+// sitting and starts the next.
+func (m Model) recordTurn(bookID, from, to int) (Model, tea.Cmd) {
+    now := time.Now()
+    var end tea.Cmd
+    s := m.sitting
+    if s.bookID != 0 && (s.bookID != bookID || now.Sub(s.last) > sittingGap) {
+        m, end = m.endSitting()
+        s = m.sitting
+    }
+    if s.bookID == 0 {
+        start := now
+        if !s.opened.IsZero() && now.Sub(s.opened) <= sittingGap {
+            start = s.opened
+        }
+        s = sitting{bookID: bookID, startPage: from, start: start}
+    }
+    s.endPage, s.last = to, now
+    m.sitting = s
+    return m, end
+}
+
+// endSitting keeps the sitting in the store when pages were turned in it.
+func (m Model) endSitting() (Model, tea.Cmd) {
+    s := m.sitting
+    m.sitting = sitting{opened: time.Now()}
+    if s.bookID == 0 || s.endPage == s.startPage || m.store == nil {
+        return m, nil
+    }
+    st := m.store
+    return m, func() tea.Msg {
+        err := st.AddSitting(store.Sitting{BookID: s.bookID, StartPage: s.startPage, EndPage: s.endPage, Start: s.start, End: s.last})
+        if err != nil {
+            return types.ErrorMsg{Message: "Reading history: " + err.Error()}
+        }
+        return sittingSavedMsg{}
+    }
+}
+
+// sittingSavedMsg redraws the timeline, it may show the sitting just kept.
+type sittingSavedMsg struct{}
+
+// quit leaves the app once the pending page turns and the sitting are saved.
+func (m Model) quit() (Model, tea.Cmd) {
+    m, leave := m.leaveBook()
+    if leave == nil {
+        return m, tea.Quit
+    }
+    return m, tea.Sequence(leave, tea.Quit)
+}
+
+// readingSessions turns the backend's session rows into sessions with
+// their books, keeping the page of a book whose turns aren't sent yet.
+func (m Model) readingSessions(rows []map[string]interface{}) []types.ReadingSession {
+    books := m.books
+    sessions := make([]types.ReadingSession, 0, len(rows))
+    for _, row := range rows {
+        id := intField(row, "book_id")
+        book, ok := books[id]
+        if !ok {
+            book = types.Book{ID: id, Name: fmt.Sprintf("Book %d", id)}
+        }
+        session := types.ReadingSession{
+            Book:        book,
+            CurrentPage: intField(row, "current_page"),
+            StartedAt:   stringField(row, "started_at"),
+            LastReadAt:  stringField(row, "last_read_at"),
+        }
+        if m.turns.delta != 0 && m.turns.bookID == id {
+            for _, s := range m.readingView.Sessions {
+                if s.Book.ID == id {
+                    session.CurrentPage = s.CurrentPage
+                }
+            }
+        }
+        sessions = append(sessions, session)
+    }
+    return sessions
+}
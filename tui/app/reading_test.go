@@ -0,0 +1,172 @@
+package app
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/config"
+    "tui/store"
+    "tui/types"
+)
+
+// turnRecorder is a backend that writes down the page turns it is sent.
+type turnRecorder struct {
+    api.Service
+    turns []string
+}
+
+func (r *turnRecorder) TurnPage(bookID int, direction string, count int) error {
+    r.turns = append(r.turns, fmt.Sprintf("%d %s %d", bookID, direction, count))
+    return nil
+}
+
+func readingModel() (Model, *turnRecorder) {
+    rec := &turnRecorder{Service: api.NewFake()}
+    m := NewModel(config.Defaults(), rec)
+    m.currentView = types.ViewReading
+    m.loggedIn = true
+    m.readingView = types.ReadingView{BookID: 1, Sessions: []types.ReadingSession{
+        {Book: types.Book{ID: 1, Name: "Dune", Pages: 10}, CurrentPage: 3},
+        {Book: types.Book{ID: 2, Name: "Emma"}, CurrentPage: 1},
+    }}
+    return m, rec
+}
+
+// arrows presses arrow keys and sends what they ask for once the keys
+// rest: the debounce tick of the last turn, and any turns sent at once.
+func arrows(m Model, keys ...tea.KeyType) Model {
+    var cmds []tea.Cmd
+    for _, k := range keys {
+        next, cmd := m.Update(tea.KeyMsg{Type: k})
+        m = next.(Model)
+        cmds = append(cmds, cmd)
+    }
+    next, cmd := m.Update(types.FlushTurnsMsg{Seq: m.turns.seq})
+    m = next.(Model)
+    for _, cmd := range append(cmds, cmd) {
+        sendTurns(cmd)
+    }
+    return m
+}
+
+// sendTurns runs the commands that talk to the backend. The debounce
+// ticks take longer than it waits and are left behind.
+func sendTurns(cmd tea.Cmd) {
+    if cmd == nil {
+        return
+    }
+    done := make(chan tea.Msg, 1)
+    go func() { done <- cmd() }()
+    select {
+    case msg := <-done:
+        if batch, ok := msg.(tea.BatchMsg); ok {
+            for _, c := range batch {
+                sendTurns(c)
+            }
+        }
+    case <-time.After(20 * time.Millisecond):
+    }
+}
+
+func TestTurnPages(t *testing.T) {
+    right, left, down := tea.KeyRight, tea.KeyLeft, tea.KeyDown
+    tests := []struct {
+        name string
+        keys []tea.KeyType
+        page int // of Dune afterwards
+        sent string
+    }{
+        {"one turn", []tea.KeyType{right}, 4, "1 forward 1"},
+        {"held down", []tea.KeyType{right, right, right, right}, 7, "1 forward 4"},
+        {"back and forth", []tea.KeyType{right, left, left}, 2, "1 back 1"},
+        {"back where it was", []tea.KeyType{right, left}, 3, ""},
+        {"stops at the first page", []tea.KeyType{left, left, left, left}, 1, "1 back 2"},
+        // The last page is sent at once, the rest of the turns go nowhere
+        {"last page", []tea.KeyType{right, right, right, right, right, right, right, right, right}, 10, "1 forward 7"},
+        {"another book", []tea.KeyType{right, right, down, right}, 5, "1 forward 2, 2 forward 1"},
+    }
+    for _, tt := range tests {
+        m, rec := readingModel()
+        m = arrows(m, tt.keys...)
+        if page := m.readingView.Sessions[0].CurrentPage; page != tt.page {
+            t.Errorf("%s: on page %d, want %d", tt.name, page, tt.page)
+        }
+        if got := strings.Join(rec.turns, ", "); got != tt.sent {
+            t.Errorf("%s: sent %q, want %q", tt.name, got, tt.sent)
+        }
+    }
+}
+
+// A debounce tick from before the latest turn sends nothing.
+func TestStaleFlush(t *testing.T) {
+    m, rec := readingModel()
+    next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRight})
+    m = next.(Model)
+    stale := m.turns.seq
+    next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+    m = next.(Model)
+
+    next, cmd := m.Update(types.FlushTurnsMsg{Seq: stale})
+    sendTurns(cmd)
+    if len(rec.turns) != 0 || next.(Model).turns.delta != 2 {
+        t.Fatalf("a stale tick sent %q", rec.turns)
+    }
+
+    // Leaving the view sends what is pending
+    next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+    sendTurns(cmd)
+    if strings.Join(rec.turns, ", ") != "1 forward 2" || next.(Model).currentView != types.ViewLibrary {
+        t.Fatalf("leaving sent %q", rec.turns)
+    }
+}
+
+func TestSittings(t *testing.T) {
+    st, err := store.Open(t.TempDir(), "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    m := Model{store: st}
+
+    // Turns in one book make one sitting, from the first page to the last
+    m, _ = m.recordTurn(1, 10, 11)
+    m, _ = m.recordTurn(1, 11, 12)
+    m, cmd := m.recordTurn(2, 0, 1)
+    if cmd == nil {
+        t.Fatal("turning in another book kept the sitting going")
+    }
+    cmd()
+    if got := st.Timeline(1); len(got) != 1 || got[0].StartPage != 10 || got[0].EndPage != 12 {
+        t.Fatalf("sitting of book 1 = %+v", got)
+    }
+
+    // A long rest starts the next sitting
+    m.sitting.last = time.Now().Add(-sittingGap - time.Minute)
+    m, cmd = m.recordTurn(2, 1, 2)
+    if cmd == nil {
+        t.Fatal("a turn after a long rest kept the sitting going")
+    }
+    cmd()
+    if m.sitting.startPage != 1 || m.sitting.endPage != 2 {
+        t.Errorf("next sitting = %+v", m.sitting)
+    }
+
+    m, cmd = m.endSitting()
+    cmd()
+    if got := st.Timeline(2); len(got) != 2 {
+        t.Errorf("sittings of book 2 = %+v", got)
+    }
+
+    // Paging back and forth to where it started keeps nothing
+    m, _ = m.recordTurn(3, 5, 6)
+    m, _ = m.recordTurn(3, 6, 5)
+    if m, cmd = m.endSitting(); cmd != nil {
+        t.Error("a sitting that ended where it began was kept")
+    }
+    if got := st.Timeline(3); len(got) != 0 {
+        t.Errorf("sittings of book 3 = %+v", got)
+    }
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+    "fmt"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
+    "tui/types"
+    "tui/views"
+)
+
+// openTimeline shows how a book was read, scrolled to the latest sittings.
+// The sitting going on is kept first, so it is on the timeline too.
+func (m Model) openTimeline(bookID int) (Model, tea.Cmd) {
+    m, leave := m.leaveBook()
+    m.timelineView = types.TimelineView{BookID: bookID, Scroll: len(m.timeline(bookID)) + 1}
+    m.timelineFrom = m.currentView
+    m.currentView = types.ViewTimeline
+    return m, leave
+}
+
+// timeline of a book from the store, empty before login.
+func (m Model) timeline(bookID int) []store.Entry {
+    if m.store == nil {
+        return nil
+    }
+    return m.store.Timeline(bookID)
+}
+
+func (m Model) updateTimeline(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    tv := &m.timelineView
+    count := len(m.timeline(tv.BookID))
+    switch m.keys.Match(types.ViewTimeline, key.String()) {
+    case keymap.ItemPrev:
+        tv.Scroll = max(views.ClampScroll(tv.Scroll, count, m.timelineRows())-1, 0)
+    case keymap.ItemNext:
+        tv.Scroll = views.ClampScroll(tv.Scroll+1, count, m.timelineRows())
+    case keymap.Back:
+        m.currentView = m.timelineFrom
+    }
+    return m, nil
+}
+
+// timelineRows is how many sittings fit, around the header, summary and
+// bookmarks.
+func (m Model) timelineRows() int {
+    rows := 10
+    if m.height > 0 {
+        rows = max(m.height-18, 3)
+    }
+    if m.store != nil {
+        rows = max(rows-len(m.store.Bookmarks(m.timelineView.BookID)), 3)
+    }
+    // Pauses take a line of their own
+    return max(rows/2, 3)
+}
+
+func (m Model) renderTimelineView() string {
+    tv := m.timelineView
+    book, ok := m.books[tv.BookID]
+    if !ok {
+        book = types.Book{ID: tv.BookID, Name: fmt.Sprintf("Book %d", tv.BookID)}
+    }
+    startedAt := ""
+    for _, s := range m.readingView.Sessions {
+        if s.Book.ID == tv.BookID {
+            startedAt = s.StartedAt
+        }
+    }
+    var bookmarks []store.Highlight
+    if m.store != nil {
+        bookmarks = m.store.Bookmarks(tv.BookID)
+    }
+
+    return lipgloss.JoinVertical(
+        lipgloss.Top,
+        m.renderNavBar(),
+        styles.ContentStyle.Render(views.RenderTimeline(book, startedAt, m.timeline(tv.BookID), bookmarks, tv.Scroll, m.timelineRows())),
+    )
+}
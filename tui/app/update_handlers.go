@@ -1,24 +1,35 @@
 package app
 
 import (
+    "errors"
+    "net/http"
+
     tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
+    "tui/keymap"
+    "tui/store"
     "tui/types"
+    "tui/views"
 )
 
 func (m Model) updateLogin(msg tea.Msg) (tea.Model, tea.Cmd) {
     switch msg := msg.(type) {
     case tea.KeyMsg:
-        switch msg.String() {
-        case "enter":
+        if types.IsTextKey(msg) {
+            break
+        }
+        switch m.keys.Match(types.ViewLogin, msg.String()) {
+        case keymap.Submit:
             // Attempt login
             m.loading = true
             return m, m.attemptLogin()
-        case "up", "down":
+        case keymap.NextField, keymap.PrevField:
             if m.loginForm.Focused == "username" {
                 m.loginForm.Focused = "password"
             } else {
                 m.loginForm.Focused = "username"
             }
+            return m, nil
         }
     }
 
@@ -33,81 +44,255 @@ func (m Model) updateLogin(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) updateLibrary(msg tea.Msg) (tea.Model, tea.Cmd) {
+    if m.searchBar.Active {
+        return m.updateSearch(msg)
+    }
+
     switch msg := msg.(type) {
     case tea.KeyMsg:
-        switch msg.String() {
-        case "left":
-            if m.selectedNav > 0 {
-                m.selectedNav--
-            }
-        case "right":
-            if m.selectedNav < len(m.navItems)-1 {
-                m.selectedNav++
-            }
-        case "enter":
-            if m.selectedNav < len(m.navItems) {
-                // Convert NavItem.View (types.View) to app.View
-                navView := m.navItems[m.selectedNav].View
-                m.currentView = navView
-
-                // Load data for the selected view
-                switch m.currentView {
-                case types.ViewLibrary:
-                    return m, m.loadLibraryData()
-                case types.ViewProfile:
-                    return m, m.loadProfileData()
-                case types.ViewFriends:
-                    return m, m.loadFriendsData()
-                case types.ViewRecommendations:
-                    return m, m.loadRecommendations()
-                case types.ViewReading:
-                    return m, m.loadReadingSessions()
-                }
+        shelf := m.shelfView.Shelves[views.ShelfOrder[m.shelfView.SelectedShelf]]
+
+        switch m.keys.Match(types.ViewLibrary, msg.String()) {
+        case keymap.BookPrev:
+            if m.shelfView.SelectedBook > 0 {
+                m.shelfView.SelectedBook--
+            }
+        case keymap.BookNext:
+            if m.shelfView.SelectedBook < len(shelf)-1 {
+                m.shelfView.SelectedBook++
             }
-        case "s":
-            m.searchBar.Active = !m.searchBar.Active
-        case "r":
+        case keymap.ShelfPrev:
+            m.shelfView = m.moveShelf(-1)
+        case keymap.ShelfNext:
+            m.shelfView = m.moveShelf(1)
+        case keymap.OpenBook:
+            if m.shelfView.SelectedBook < len(shelf) {
+                return m.openBook(shelf[m.shelfView.SelectedBook])
+            }
+        case keymap.Search:
+            m.searchBar = types.SearchBar{Active: true}
+        case keymap.Refresh:
             // Refresh data
             return m, m.refreshData()
-        case "esc":
-            m.currentView = types.ViewLibrary
+        case keymap.Bookcase:
+            m.shelfView.Bookcase = !m.shelfView.Bookcase
+        case keymap.Sidebar:
+            m.sidebarOpen = !m.sidebarOpen
+        case keymap.Import:
+            return m.startImport()
+        case keymap.Export:
+            return m.exportAll()
+        }
+    }
+
+    return m, nil
+}
+
+func (m Model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    action := keymap.None
+    if !types.IsTextKey(key) {
+        action = m.keys.MatchMode(keymap.ModeSearch, key.String())
+    }
+    switch action {
+    case keymap.Cancel:
+        m.searchBar = types.SearchBar{}
+    case keymap.ItemPrev:
+        m.searchBar.Selected = clamp(m.searchBar.Selected-1, 0, max(len(m.searchBar.Results)-1, 0))
+    case keymap.ItemNext:
+        m.searchBar.Selected = clamp(m.searchBar.Selected+1, 0, max(len(m.searchBar.Results)-1, 0))
+    case keymap.OpenBook:
+        if m.searchBar.Selected < len(m.searchBar.Results) {
+            book := m.searchBar.Results[m.searchBar.Selected]
+            m.searchBar = types.SearchBar{}
+            return m.openBook(book)
         }
+    default:
+        m.searchBar.Query = types.EditText(m.searchBar.Query, msg)
+        m.searchBar.Results = searchBooks(m.knownBooks(), m.searchBar.Query)
+        m.searchBar.Selected = 0
     }
 
     return m, nil
 }
 
+// openBook shows a book's details, starting from what the list knew about it.
+func (m Model) openBook(book types.Book) (Model, tea.Cmd) {
+    m.selectedBookID = book.ID
+    m.bookData = types.BookData{Book: book}
+    m.reviewScroll = 0
+    m.detailsFrom = m.currentView
+    m.currentView = types.ViewBookDetails
+    return m, m.loadBookDetails(book.ID)
+}
+
+// moveShelf selects another shelf, keeping the cursor on a book that exists there.
+func (m Model) moveShelf(delta int) types.ShelfView {
+    sv := m.shelfView
+    sv.SelectedShelf = clamp(sv.SelectedShelf+delta, 0, len(views.ShelfOrder)-1)
+    shelf := sv.Shelves[views.ShelfOrder[sv.SelectedShelf]]
+    sv.SelectedBook = clamp(sv.SelectedBook, 0, max(len(shelf)-1, 0))
+    return sv
+}
+
 func (m Model) updateBookDetails(msg tea.Msg) (tea.Model, tea.Cmd) {
+    if m.reviewForm.Active {
+        return m.updateReviewForm(msg)
+    }
+    if m.noteForm.Active {
+        return m.updateNoteForm(msg)
+    }
+
     switch msg := msg.(type) {
     case tea.KeyMsg:
-        switch msg.String() {
-        case "esc", "backspace":
-            m.currentView = types.ViewLibrary
+        switch m.keys.Match(types.ViewBookDetails, msg.String()) {
+        case keymap.Back:
+            m.currentView = m.detailsFrom
             m.selectedBookID = 0
-        case "r":
+        case keymap.StartReading:
             // Start reading the book
             if m.selectedBookID > 0 {
                 return m, m.startReading(m.selectedBookID)
             }
-        case "a":
+        case keymap.AddReview:
             // Add review
             if m.selectedBookID > 0 {
-                // You could show a form for adding review here
-                m.errorMsg = "Review feature coming soon!"
-                return m, m.clearErrorAfter(2)
+                m.reviewForm = types.ReviewForm{Active: true, Rating: 5, Focused: "text"}
+            }
+        case keymap.AddNote:
+            if m.selectedBookID > 0 {
+                m = m.openNoteForm(m.selectedBookID, store.KindNote)
+            }
+        case keymap.AddQuote:
+            if m.selectedBookID > 0 {
+                m = m.openNoteForm(m.selectedBookID, store.KindQuote)
             }
+        case keymap.AddBookmark:
+            if m.selectedBookID > 0 {
+                m = m.openNoteForm(m.selectedBookID, store.KindBookmark)
+            }
+        case keymap.Timeline:
+            if m.selectedBookID > 0 {
+                return m.openTimeline(m.selectedBookID)
+            }
+        case keymap.NotesOrder:
+            if m.notesOrder == store.ByPage {
+                m.notesOrder = store.ByTime
+            } else {
+                m.notesOrder = store.ByPage
+            }
+        }
+    }
+    return m, nil
+}
+
+func (m Model) updateReviewForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    // Typing in the text field never triggers a binding, the rating takes any key
+    action := keymap.None
+    if m.reviewForm.Focused == "rating" || !types.IsTextKey(key) {
+        action = m.keys.MatchMode(keymap.ModeReview, key.String())
+    }
+    switch action {
+    case keymap.Cancel:
+        m.reviewForm = types.ReviewForm{}
+        return m, nil
+    case keymap.NextField, keymap.PrevField:
+        if m.reviewForm.Focused == "text" {
+            m.reviewForm.Focused = "rating"
+        } else {
+            m.reviewForm.Focused = "text"
         }
+        return m, nil
+    case keymap.Submit:
+        if m.reviewForm.Dirty() {
+            form := m.reviewForm
+            m.reviewForm = types.ReviewForm{}
+            return m, m.submitReview(m.selectedBookID, form.Text, form.Rating)
+        }
+        return m, nil
     }
+
+    if m.reviewForm.Focused == "rating" {
+        switch action {
+        case keymap.RatingDown:
+            m.reviewForm.Rating = clamp(m.reviewForm.Rating-1, 1, 5)
+        case keymap.RatingUp:
+            m.reviewForm.Rating = clamp(m.reviewForm.Rating+1, 1, 5)
+        default:
+            if r := key.String(); len(r) == 1 && r >= "1" && r <= "5" {
+                m.reviewForm.Rating = int(r[0] - '0')
+            }
+        }
+        return m, nil
+    }
+
+    m.reviewForm.Text = types.EditText(m.reviewForm.Text, msg)
     return m, nil
 }
 
-func (m Model) updateReading(msg tea.Msg) (tea.Model, tea.Cmd) {
-    // Handle reading view updates
+// prefetchRows is how close to the end of the loaded catalog the cursor
+// gets before the next page is requested.
+const prefetchRows = 10
+
+func (m Model) updateDiscover(msg tea.Msg) (tea.Model, tea.Cmd) {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return m, nil
+    }
+
+    list := m.bookList
+    switch m.keys.Match(types.ViewDiscover, key.String()) {
+    case keymap.ItemPrev:
+        m.bookList.Selected = clamp(list.Selected-1, 0, max(len(list.Books)-1, 0))
+    case keymap.ItemNext:
+        m.bookList.Selected = clamp(list.Selected+1, 0, max(len(list.Books)-1, 0))
+    case keymap.OpenBook:
+        if list.Selected < len(list.Books) {
+            return m.openBook(list.Books[list.Selected])
+        }
+    case keymap.Back:
+        m.currentView = types.ViewLibrary
+        return m, nil
+    }
+
+    if m.bookList.Selected >= len(m.bookList.Books)-prefetchRows {
+        return m.loadNextPage()
+    }
     return m, nil
 }
 
+// loadNextPage asks for the page after the loaded ones, unless one is on
+// its way already or the catalog is complete.
+func (m Model) loadNextPage() (Model, tea.Cmd) {
+    if m.bookList.Loading || !m.bookList.More() {
+        return m, nil
+    }
+    m.bookList.Loading = true
+
+    offset, limit := len(m.bookList.Books), m.bookList.PageSize
+    return m, func() tea.Msg {
+        page, err := m.api.ListBooksPage(offset, limit)
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        return types.LoadBookPageMsg{Page: page}
+    }
+}
+
 func (m Model) updateProfile(msg tea.Msg) (tea.Model, tea.Cmd) {
     // Handle profile view updates
+    if key, ok := msg.(tea.KeyMsg); ok && m.keys.Match(types.ViewProfile, key.String()) == keymap.Back {
+        m.currentView = types.ViewLibrary
+    }
     return m, nil
 }
 
@@ -115,6 +300,15 @@ func (m Model) attemptLogin() tea.Cmd {
     return func() tea.Msg {
         // Call API
         token, err := m.api.Login(m.loginForm.Username, m.loginForm.Password)
+        var apiErr *api.APIError
+        if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+            // The local backend says why, e.g. an account without a password
+            reason := "wrong username or password"
+            if apiErr.Detail != "" {
+                reason = apiErr.Detail
+            }
+            return types.LoginErrorMsg{Message: "Login failed: " + reason}
+        }
         if err != nil {
             return types.LoginErrorMsg{Message: err.Error()}
         }
@@ -133,15 +327,10 @@ func (m Model) attemptLogin() tea.Cmd {
     }
 }
 
+// loadLibraryData loads the user's library and looks up only its books,
+// the catalog itself is paged through in Discover.
 func (m Model) loadLibraryData() tea.Cmd {
     return func() tea.Msg {
-        // Load books
-        books, err := m.api.ListBooks()
-        if err != nil {
-            return types.ErrorMsg{Message: err.Error()}
-        }
-
-        // Organize by shelves
         shelves := make(map[string][]types.Book)
         shelves["to_read"] = []types.Book{}
         shelves["currently_reading"] = []types.Book{}
@@ -149,24 +338,52 @@ func (m Model) loadLibraryData() tea.Cmd {
 
         // Load user's libraries
         libraries, err := m.api.GetUserLibraries(m.username)
-        if err == nil && len(libraries) > 0 {
-            // Use first library's organization
-            for shelf, bookIDs := range libraries[0].Books {
-                for _, id := range bookIDs {
-                    for _, book := range books {
-                        if book.ID == id {
-                            shelves[shelf] = append(shelves[shelf], book)
-                            break
-                        }
-                    }
+        if err != nil || len(libraries) == 0 {
+            return types.LoadLibraryMsg{Shelves: shelves}
+        }
+
+        // Use the configured library, or the first one if it isn't found
+        library := libraries[0]
+        for _, lib := range libraries {
+            if lib.Name == m.config.DefaultLibrary {
+                library = lib
+                break
+            }
+        }
+
+        var ids []int
+        for _, shelf := range views.ShelfOrder {
+            ids = append(ids, library.Books[shelf]...)
+        }
+        books, err := api.GetBooks(m.api, ids)
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        index := indexBooks(books)
+        for shelf, bookIDs := range library.Books {
+            for _, id := range bookIDs {
+                if book, ok := index[id]; ok {
+                    shelves[shelf] = append(shelves[shelf], book)
                 }
             }
         }
 
         return types.LoadLibraryMsg{
-            Books:   books,
-            Shelves: shelves,
+            Books:     books,
+            Shelves:   shelves,
+            LibraryID: library.ID,
+        }
+    }
+}
+
+func (m Model) loadBookDetails(bookID int) tea.Cmd {
+    return func() tea.Msg {
+        data, err := m.api.GetBookDetails(bookID)
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
         }
+
+        return types.LoadBookDetailsMsg{Data: data}
     }
 }
 
@@ -224,7 +441,17 @@ func (m Model) loadReadingSessions() tea.Cmd {
             return types.ErrorMsg{Message: err.Error()}
         }
 
-        return types.LoadReadingSessionsMsg{Sessions: sessions}
+        // Titles of books started outside the library, from Discover or the CLI
+        ids := make([]int, 0, len(sessions))
+        for _, row := range sessions {
+            ids = append(ids, intField(row, "book_id"))
+        }
+        books, err := api.GetBooks(m.api, m.missingBooks(ids))
+        if err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+
+        return types.LoadReadingSessionsMsg{Sessions: sessions, Books: books}
     }
 }
 
@@ -240,7 +467,46 @@ func (m Model) startReading(bookID int) tea.Cmd {
     }
 }
 
+func (m Model) submitReview(bookID int, text string, rating int) tea.Cmd {
+    return func() tea.Msg {
+        if err := m.api.AddReview(bookID, text, rating); err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+
+        return types.ReviewSubmittedMsg{
+            BookID: bookID,
+            Review: types.Review{User: m.username, Rating: rating, Text: text},
+        }
+    }
+}
+
+// addToLibrary puts a book on a shelf of the open library and reloads it.
+func (m Model) addToLibrary(bookID int, shelf string) tea.Cmd {
+    return m.changeShelf(m.api.AddBookToLibrary, bookID, shelf)
+}
+
+// moveBook takes a book of the open library to another shelf and reloads it.
+func (m Model) moveBook(bookID int, shelf string) tea.Cmd {
+    return m.changeShelf(m.api.MoveBook, bookID, shelf)
+}
+
+func (m Model) changeShelf(change func(libraryID, bookID int, shelf string) error, bookID int, shelf string) tea.Cmd {
+    libraryID := m.libraryData.LibraryID
+    return func() tea.Msg {
+        if libraryID == 0 {
+            return types.ErrorMsg{Message: "no library to put the book in"}
+        }
+        if err := change(libraryID, bookID, shelf); err != nil {
+            return types.ErrorMsg{Message: err.Error()}
+        }
+        return m.loadLibraryData()()
+    }
+}
+
 func (m Model) refreshData() tea.Cmd {
+    if r, ok := m.api.(api.Refresher); ok {
+        r.Refresh()
+    }
     return tea.Batch(
         m.loadLibraryData(),
         m.loadProfileData(),
@@ -1,12 +1,40 @@
 package app
 
 import (
+    "fmt"
     "strings"
     "github.com/charmbracelet/lipgloss"
+    "tui/api"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
     "tui/views"
     "tui/types"
+    "tui/zone"
 )
 
+// Below minWidth x minHeight the layout falls apart, a notice is shown
+// instead. Under narrowWidth the sidebar starts folded away.
+const (
+    minWidth    = 60
+    minHeight   = 20
+    narrowWidth = 100
+)
+
+// tooSmall is false until the first WindowSizeMsg, the size isn't known yet.
+func (m Model) tooSmall() bool {
+    return m.width > 0 && (m.width < minWidth || m.height < minHeight)
+}
+
+func (m Model) renderTooSmall() string {
+    body := lipgloss.JoinVertical(lipgloss.Center,
+        styles.ErrorStyle.Render("Terminal too small"),
+        fmt.Sprintf("%dx%d, needs at least %dx%d", m.width, m.height, minWidth, minHeight),
+    )
+
+    return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+}
+
 func (m Model) renderLayout() string {
     header := m.renderHeader()
     content := m.renderContent()
@@ -28,13 +56,7 @@ func (m Model) renderLayout() string {
 
 func (m Model) renderHeader() string {
     if m.currentView == types.ViewLogin {
-        return lipgloss.NewStyle().
-            Background(lipgloss.Color("#2563EB")).
-            Foreground(lipgloss.Color("#F3F4F6")).
-            Padding(0, 2).
-            Height(3).
-            Bold(true).
-            Render("📚 Book Tracker")
+        return styles.HeaderStyle.Render("📚 Book Tracker")
     }
 
     greeting := ""
@@ -42,22 +64,11 @@ func (m Model) renderHeader() string {
         greeting = " | Welcome, " + m.username
     }
 
-    left := lipgloss.NewStyle().
-        Bold(true).
-        Padding(0, 1).
-        Render("📚 Book Tracker" + greeting)
+    left := styles.HeaderLeftStyle.Render("📚 Book Tracker" + greeting)
 
-    right := lipgloss.NewStyle().
-        Faint(true).
-        Render("📅 Today's Read")
+    right := styles.HeaderRightStyle.Render("📅 Today's Read")
 
-    return lipgloss.NewStyle().
-        Background(lipgloss.Color("#2563EB")).
-        Foreground(lipgloss.Color("#F3F4F6")).
-        Padding(0, 2).
-        Height(3).
-        Bold(true).
-        Render(
+    return styles.HeaderStyle.Render(
             lipgloss.JoinHorizontal(lipgloss.Center, left, right),
         )
 }
@@ -72,6 +83,14 @@ func (m Model) renderContent() string {
         return m.renderBookDetailsView()
     case types.ViewReading:
         return m.renderReadingView()
+    case types.ViewDiscover:
+        return m.renderDiscoverView()
+    case types.ViewImport:
+        return m.renderImportView()
+    case types.ViewNotes:
+        return m.renderNotesView()
+    case types.ViewTimeline:
+        return m.renderTimelineView()
     case types.ViewProfile:
         return m.renderProfileView()
     default:
@@ -80,74 +99,61 @@ func (m Model) renderContent() string {
 }
 
 func (m Model) renderFooter() string {
-    helpText := ""
-    switch m.currentView {
-    case types.ViewLogin:
-        helpText = "↑↓: Navigate | Tab: Next field | Enter: Login | Q: Quit"
-    case types.ViewLibrary:
-        helpText = "←→: Move shelf | ↑↓: Move book | Enter: Select | N: New book | S: Search | Q: Quit"
-    case types.ViewBookDetails:
-        helpText = "R: Start reading | A: Add to library | F: Add friend | Esc: Back | Q: Quit"
+    helpText := m.keys.ShortHelp(m.currentView)
+    switch {
+    case m.currentView == types.ViewLibrary && m.searchBar.Active:
+        helpText = "Type to search | " + m.keys.ModeHelp(keymap.ModeSearch)
+    case m.currentView == types.ViewBookDetails && m.reviewForm.Active:
+        helpText = "1-5: Rating | " + m.keys.ModeHelp(keymap.ModeReview)
+    case (m.currentView == types.ViewBookDetails || m.currentView == types.ViewReading) && m.noteForm.Active:
+        helpText = m.keys.ModeHelp(keymap.ModeNote)
+    case m.currentView == types.ViewNotes && m.notesView.Searching:
+        helpText = "Type to search all notes | " + m.keys.ModeHelp(keymap.ModeNotesSearch)
+    case m.currentView == types.ViewImport && m.importWizard.step == stepRunning:
+        helpText = "Importing..."
+    case m.currentView == types.ViewImport && m.importWizard.step == stepPath:
+        helpText = "Type the path | " + m.keys.ModeHelp(keymap.ModeImportPath)
+    case m.currentView == types.ViewImport:
+        helpText = m.keys.ModeHelp(importModes[m.importWizard.step])
     }
-
-    status := ""
-    if m.loggedIn {
-        status = "🟢 Online"
-    } else {
-        status = "🔴 Offline"
+    if m.notice != "" {
+        helpText = styles.SuccessStyle.Render(m.notice)
     }
 
-    left := lipgloss.NewStyle().
-        Faint(true).
-        Render(helpText)
+    status := m.connectionLabel()
 
-    right := lipgloss.NewStyle().
-        Bold(true).
-        Render(status)
+    left := styles.FooterLeftStyle.Render(helpText)
 
-    return lipgloss.NewStyle().
-        Background(lipgloss.Color("#1F2937")).
-        Foreground(lipgloss.Color("#F3F4F6")).
-        Padding(0, 2).
-        Height(2).
-        Render(
+    right := styles.FooterRightStyle.Render(status)
+
+    return styles.FooterStyle.Render(
             lipgloss.JoinHorizontal(lipgloss.Center, left, right),
         )
 }
 
 func (m Model) renderLoginView() string {
-    title := lipgloss.NewStyle().
-        Bold(true).
-        Foreground(lipgloss.Color("#2563EB")).
-        MarginBottom(1).
-        Render("Welcome to Book Tracker")
-
-    subtitle := lipgloss.NewStyle().
-        Faint(true).
-        MarginBottom(2).
-        Render("Your personal digital library")
-
-    form := lipgloss.NewStyle().
-        Width(40).
-        Padding(2, 3).
-        Border(lipgloss.RoundedBorder()).
-        BorderForeground(lipgloss.Color("#2563EB")).
-        Render(
-            lipgloss.JoinVertical(lipgloss.Left,
-                lipgloss.NewStyle().Bold(true).MarginBottom(1).Render("Username:"),
-                m.loginForm.RenderUsername(),
-                "\n",
-                lipgloss.NewStyle().Bold(true).MarginBottom(1).Render("Password:"),
-                m.loginForm.RenderPassword(),
-                "\n",
-                lipgloss.NewStyle().
-                    Background(lipgloss.Color("#2563EB")).
-                    Foreground(lipgloss.Color("#F3F4F6")).
-                    Padding(0, 3).
-                    Bold(true).
-                    Render("Login"),
-            ),
-        )
+    title := styles.TitleStyle.Render("Welcome to Book Tracker")
+
+    subtitleText := "Your personal digital library"
+    switch m.config.Backend {
+    case "demo":
+        subtitleText = "Demo mode: log in as " + api.DemoUsername + " / " + api.DemoPassword
+    case "local":
+        subtitleText = "Local mode: " + m.config.Database
+    }
+    subtitle := styles.SubtitleStyle.Render(subtitleText)
+
+    form := styles.FormStyle.Render(
+        lipgloss.JoinVertical(lipgloss.Left,
+            styles.InputLabelStyle.Render("Username:"),
+            m.loginForm.RenderUsername(),
+            "\n",
+            styles.InputLabelStyle.Render("Password:"),
+            m.loginForm.RenderPassword(),
+            "\n",
+            styles.ButtonStyle.Render("Login"),
+        ),
+    )
 
     return lipgloss.JoinVertical(
         lipgloss.Center,
@@ -169,13 +175,14 @@ func (m Model) renderLibraryView() string {
         mainContent = m.renderShelfView()
     }
 
-    sidebar := m.renderSidebar()
-
-    content := lipgloss.JoinHorizontal(
-        lipgloss.Top,
-        sidebar,
-        mainContent,
-    )
+    content := mainContent
+    if m.sidebarOpen {
+        content = lipgloss.JoinHorizontal(
+            lipgloss.Top,
+            m.renderSidebar(),
+            mainContent,
+        )
+    }
 
     return lipgloss.JoinVertical(
         lipgloss.Top,
@@ -187,25 +194,16 @@ func (m Model) renderLibraryView() string {
 func (m Model) renderNavBar() string {
     var navItems []string
     for i, item := range m.navItems {
-        style := lipgloss.NewStyle().
-            Padding(0, 2).
-            Foreground(lipgloss.Color("#F3F4F6"))
-
+        style := styles.NavItemStyle
         if i == m.selectedNav {
-            style = style.
-                Background(lipgloss.Color("#7C3AED")).
-                Bold(true)
+            style = styles.NavItemSelectedStyle
         }
-        navItems = append(navItems, style.Render(item.Label))
+        navItems = append(navItems, zone.Mark(views.NavZone(i), style.Render(views.StableWidth(item.Label))))
     }
 
-    return lipgloss.NewStyle().
-        Background(lipgloss.Color("#374151")).
-        Padding(0, 2).
-        Height(2).
-        Render(
-            lipgloss.JoinHorizontal(lipgloss.Left, navItems...),
-        )
+    return styles.NavBarStyle.Render(
+        lipgloss.JoinHorizontal(lipgloss.Left, navItems...),
+    )
 }
 
 func (m Model) renderSidebar() string {
@@ -219,14 +217,9 @@ func (m Model) renderSidebar() string {
         "⭐ Avg Rating: 4.2",
     }
 
-    return lipgloss.NewStyle().
-        Width(25).
-        Padding(1, 2).
-        Border(lipgloss.RoundedBorder()).
-        BorderForeground(lipgloss.Color("#2563EB")).
-        Render(
-            strings.Join(stats, "\n"),
-        )
+    return styles.SidebarStyle.Render(
+        strings.Join(stats, "\n"),
+    )
 }
 
 func (m Model) renderShelfView() string {
@@ -234,11 +227,29 @@ func (m Model) renderShelfView() string {
     if m.shelfView.Shelves == nil || len(m.shelfView.Shelves) == 0 {
         return "No books in library yet."
     }
-    return views.RenderLibrary(m.shelfView.Shelves, m.shelfView.SelectedShelf, m.shelfView.SelectedBook)
+    width := m.width
+    if m.sidebarOpen && width > 0 {
+        width -= lipgloss.Width(m.renderSidebar())
+    }
+
+    if m.shelfView.Bookcase {
+        return views.RenderBookcase(m.shelfView, width, m.config.SpineColor)
+    }
+    return views.RenderLibrary(m.shelfView, width)
 }
 
 func (m Model) renderBookDetailsView() string {
     // Fetch book details if not already loaded
+    if m.selectedBookID > 0 && m.bookData.Book.ID == m.selectedBookID {
+        details := views.RenderBookDetails(m.bookData.Book, m.bookData.Reviews, m.reviewScroll, m.highlights(m.selectedBookID), m.notesOrder)
+        if m.reviewForm.Active {
+            return lipgloss.JoinVertical(lipgloss.Left, details, m.renderReviewForm())
+        }
+        if m.noteForm.Active {
+            return lipgloss.JoinVertical(lipgloss.Left, details, m.renderNoteForm())
+        }
+        return details
+    }
     if m.selectedBookID > 0 {
         // We would typically have loaded the book data, but for now, let's use a placeholder.
         // In a real app, you would have a method to load the book data and reviews.
@@ -252,13 +263,48 @@ func (m Model) renderBookDetailsView() string {
             Language: "English",
             Publisher: "Sample Publisher",
         }
-        return views.RenderBookDetails(book, []types.Review{})
+        return views.RenderBookDetails(book, []types.Review{}, 0, nil, m.notesOrder)
     }
     return "No book selected"
 }
 
+// highlights of a book from the store, none before login.
+func (m Model) highlights(bookID int) []store.Highlight {
+    if m.store == nil {
+        return nil
+    }
+    return m.store.Highlights(bookID, m.notesOrder)
+}
+
+func (m Model) renderDiscoverView() string {
+    // Header, nav bar, footer and the list's own title take about 12 lines
+    rows := 20
+    if m.height > 0 {
+        rows = max(m.height-12, 5)
+    }
+
+    return lipgloss.JoinVertical(
+        lipgloss.Top,
+        m.renderNavBar(),
+        styles.ContentStyle.Render(views.RenderCatalog(m.bookList, rows)),
+    )
+}
+
 func (m Model) renderReadingView() string {
-    return "Reading view not implemented"
+    width := 80
+    if m.width > 0 {
+        width = max(m.width-styles.ContentStyle.GetHorizontalFrameSize(), 20)
+    }
+
+    reading := styles.ContentStyle.Render(views.RenderReading(m.readingView, m.turns.delta != 0, width))
+    if m.noteForm.Active {
+        reading = lipgloss.JoinVertical(lipgloss.Left, reading, m.renderNoteForm())
+    }
+    return lipgloss.JoinVertical(
+        lipgloss.Top,
+        m.renderNavBar(),
+        reading,
+    )
 }
 
 func (m Model) renderProfileView() string {
@@ -266,19 +312,113 @@ func (m Model) renderProfileView() string {
 }
 
 func (m Model) renderSearchView() string {
-    return "Search view not implemented"
+    input := styles.InputStyle.Copy().
+        BorderForeground(styles.PrimaryColor).
+        Width(40).
+        Render("🔍 " + m.searchBar.Query + "█")
+
+    var lines []string
+    switch {
+    case m.searchBar.Query == "":
+        lines = append(lines, styles.HintStyle.Render("Search by title or author"))
+    case len(m.searchBar.Results) == 0:
+        lines = append(lines, styles.HintStyle.Render("No matches"))
+    }
+    for i, book := range m.searchBar.Results {
+        line := views.Fit(book.Name, 34) + " — " + views.Fit(book.Author, 20)
+        if i == m.searchBar.Selected {
+            line = styles.SelectedStyle.Render("▶ " + line)
+        } else {
+            line = "  " + line
+        }
+        lines = append(lines, line)
+    }
+
+    return lipgloss.NewStyle().
+        Padding(1, 2).
+        Render(lipgloss.JoinVertical(lipgloss.Left, input, "", strings.Join(lines, "\n")))
+}
+
+func (m Model) renderReviewForm() string {
+    label := lipgloss.NewStyle().Bold(true)
+
+    text := m.reviewForm.Text
+    rating := strings.Repeat("⭐", m.reviewForm.Rating) + strings.Repeat("·", 5-m.reviewForm.Rating)
+    if m.reviewForm.Focused == "text" {
+        text += "█"
+    } else {
+        rating = lipgloss.NewStyle().Underline(true).Render(rating)
+    }
+
+    return styles.PanelStyle.Copy().
+        Width(60).
+        Padding(1, 2).
+        BorderForeground(styles.SecondaryColor).
+        Render(
+            lipgloss.JoinVertical(lipgloss.Left,
+                label.Render("💬 Your review"),
+                text,
+                "",
+                label.Render("Rating: ")+rating,
+            ),
+        )
+}
+
+func (m Model) renderConfirmQuit() string {
+    body := styles.PanelStyle.Copy().
+        BorderForeground(styles.WarningColor).
+        Render(
+            lipgloss.JoinVertical(lipgloss.Center,
+                lipgloss.NewStyle().Bold(true).Render(m.unsavedLabel()),
+                "",
+                "Quit anyway? (y/n)",
+            ),
+        )
+
+    return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+}
+
+// unsavedLabel says what quitting would throw away.
+func (m Model) unsavedLabel() string {
+    if m.noteForm.Dirty() {
+        return "You have an unsaved note."
+    }
+    return "You have an unsaved review."
 }
 
 func (m Model) renderLoading() string {
-    return lipgloss.NewStyle().
-        Foreground(lipgloss.Color("#F59E0B")).
-        Bold(true).
-        Render("Loading...")
+    return styles.LoadingStyle.Render("Loading...")
 }
 
 func (m Model) renderError() string {
-    return lipgloss.NewStyle().
-        Foreground(lipgloss.Color("#EF4444")).
+    return styles.ErrorStyle.Render("Error: " + m.errorMsg)
+}
+
+func (m Model) renderHelpOverlay() string {
+    title := styles.TitleStyle.Render(views.StableWidth("⌨️  Keyboard Shortcuts"))
+
+    keyStyle := lipgloss.NewStyle().
         Bold(true).
-        Render("Error: " + m.errorMsg)
+        Width(22)
+
+    var sections []string
+    for _, scope := range m.keys.Sections(m.currentView) {
+        lines := []string{lipgloss.NewStyle().Bold(true).Underline(true).Render(scope.Name)}
+        for _, b := range scope.Bindings {
+            lines = append(lines, keyStyle.Render(keymap.KeyLabel(b.Keys))+b.Help)
+        }
+        sections = append(sections, strings.Join(lines, "\n"))
+    }
+
+    body := styles.PanelStyle.Render(
+        lipgloss.JoinVertical(lipgloss.Left,
+            title,
+            strings.Join(sections, "\n\n"),
+            "",
+            styles.HintStyle.Render("Press "+keymap.KeyLabel(m.keys.Keys(m.currentView, keymap.Help))+
+                " or "+keymap.KeyLabel(m.keys.Keys(m.currentView, keymap.Back))+" to close"),
+        ),
+    )
+
+    return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
 }
\ No newline at end of file
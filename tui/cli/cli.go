@@ -0,0 +1,236 @@
+package cli
+
+import (
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+
+    "golang.org/x/term"
+    "tui/api"
+    "tui/config"
+)
+
+const usage = `Usage: tui [global flags] [command]
+
+Without a command the interactive interface starts.
+
+Commands:
+  register [--name display name]      create the --user account (local or http)
+  books list                          list the catalog
+  books show <id>                     show one book with its reviews
+  shelf add <id> [--shelf to_read]    put a book on a shelf (--library id)
+  read start <id>                     start a reading session
+  read turn <id> [--count n] [--back] turn pages in a session
+  read bookmark <id> <name>           name a page to come back to (--page n)
+  read history <id>                   list a book's sittings and bookmarks
+  recommend <user> <id> [--message m] recommend a book to a friend
+  import goodreads <file.csv>         import a Goodreads library export
+        [--library id] [--shelf-map a=b] (--dry-run only shows the matches)
+  import kindle <My Clippings.txt>    keep Kindle highlights with their books
+  import calibre <library>            shelve the books of a Calibre library
+        [--shelf to_read] [--pages-column pages] (also a metadata.db or .opf)
+  notes add <id> <text> [--page n]    note a thought on a page (--quote for a quote)
+  notes list <id> [--by page|time]    list a book's notes and highlights
+  notes search <words>                search the notes of every book
+  notes export [--output dir]         write a Markdown note per book, for Obsidian
+  export [--format f] [--output file] export everything as json, csv or md
+  serve [--addr a] [--latency d]      run the stand-in backend in Go
+        [--fail-rate r] [--seed n]    (log in as demo/demo)
+
+Every command accepts --json for machine readable output.
+Commands that change data log in as --user (or BOOKTRACKER_USER) with
+the password from BOOKTRACKER_PASSWORD, prompting for it when unset.
+`
+
+// errUsage marks mistakes in how a command was invoked (exit code 2).
+var errUsage = errors.New("usage")
+
+// errFlags marks flag parse failures, the flag package already printed help for those.
+var errFlags = errors.New("invalid flags")
+
+// command is one invocation of a subcommand.
+type command struct {
+    cfg    config.Config
+    client api.Service
+    stdout io.Writer
+    stderr io.Writer
+    json   bool
+}
+
+// Run executes a subcommand and returns the process exit code.
+func Run(cfg config.Config, service api.Service, args []string, stdout, stderr io.Writer) int {
+    cmd := &command{
+        cfg:    cfg,
+        client: service,
+        stdout: stdout,
+        stderr: stderr,
+    }
+
+    err := cmd.dispatch(args)
+    switch {
+    case err == nil:
+        return 0
+    case errors.Is(err, flag.ErrHelp):
+        return 0
+    case errors.Is(err, errFlags):
+        return 2
+    case errors.Is(err, errUsage):
+        fmt.Fprintf(stderr, "Error: %v\n\n%s", err, usage)
+        return 2
+    default:
+        fmt.Fprintf(stderr, "Error: %v\n", err)
+        return 1
+    }
+}
+
+func (c *command) dispatch(args []string) error {
+    if len(args) == 0 {
+        return usageError("missing command")
+    }
+
+    group, rest := args[0], args[1:]
+    switch group {
+    case "help", "-h", "--help":
+        fmt.Fprint(c.stdout, usage)
+        return nil
+    case "register":
+        return c.register(rest)
+    case "recommend":
+        return c.recommend(rest)
+    case "serve":
+        return c.serve(rest)
+    case "export":
+        return c.export(rest)
+    }
+
+    if len(rest) == 0 {
+        return usageError("%s: missing subcommand", group)
+    }
+    name, rest := rest[0], rest[1:]
+
+    switch group + " " + name {
+    case "books list":
+        return c.booksList(rest)
+    case "books show":
+        return c.booksShow(rest)
+    case "shelf add":
+        return c.shelfAdd(rest)
+    case "read start":
+        return c.readStart(rest)
+    case "read turn":
+        return c.readTurn(rest)
+    case "read bookmark":
+        return c.readBookmark(rest)
+    case "read history":
+        return c.readHistory(rest)
+    case "import goodreads":
+        return c.importGoodreads(rest)
+    case "import kindle":
+        return c.importKindle(rest)
+    case "import calibre":
+        return c.importCalibre(rest)
+    case "notes add":
+        return c.notesAdd(rest)
+    case "notes list":
+        return c.notesList(rest)
+    case "notes search":
+        return c.notesSearch(rest)
+    case "notes export":
+        return c.notesExport(rest)
+    default:
+        return usageError("unknown command %q", group+" "+name)
+    }
+}
+
+// flags returns a flag set with the options shared by every command.
+func (c *command) flags(name string) *flag.FlagSet {
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    fs.SetOutput(c.stderr)
+    fs.BoolVar(&c.json, "json", false, "print JSON instead of text")
+    return fs
+}
+
+// parse accepts flags before, between and after positional arguments,
+// so "shelf add 12 --shelf read" works like "shelf add --shelf read 12".
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+    var positional []string
+    for {
+        if err := fs.Parse(args); err != nil {
+            if errors.Is(err, flag.ErrHelp) {
+                return nil, err
+            }
+            return nil, fmt.Errorf("%w: %v", errFlags, err)
+        }
+        args = fs.Args()
+        if len(args) == 0 {
+            break
+        }
+        positional = append(positional, args[0])
+        args = args[1:]
+    }
+
+    if len(positional) != want {
+        return nil, usageError("%s: expected %d argument(s), got %d", fs.Name(), want, len(positional))
+    }
+    return positional, nil
+}
+
+// login authenticates the client for commands that modify data.
+func (c *command) login() error {
+    if c.cfg.Username == "" {
+        return usageError("this command needs --user or BOOKTRACKER_USER")
+    }
+    password, err := c.password()
+    if err != nil {
+        return err
+    }
+    _, err = c.client.Login(c.cfg.Username, password)
+    return err
+}
+
+// password is BOOKTRACKER_PASSWORD, or asked for on the terminal.
+func (c *command) password() (string, error) {
+    if password := os.Getenv("BOOKTRACKER_PASSWORD"); password != "" {
+        return password, nil
+    }
+    if !term.IsTerminal(int(os.Stdin.Fd())) {
+        return "", fmt.Errorf("no password: set BOOKTRACKER_PASSWORD")
+    }
+    fmt.Fprintf(c.stderr, "Password for %s: ", c.cfg.Username)
+    raw, err := term.ReadPassword(int(os.Stdin.Fd()))
+    fmt.Fprintln(c.stderr)
+    return string(raw), err
+}
+
+func (c *command) printJSON(v interface{}) error {
+    enc := json.NewEncoder(c.stdout)
+    enc.SetIndent("", "  ")
+    return enc.Encode(v)
+}
+
+// done reports the outcome of a command that changes data.
+func (c *command) done(message string, fields map[string]interface{}) error {
+    if c.json {
+        fields["status"] = "ok"
+        return c.printJSON(fields)
+    }
+    fmt.Fprintln(c.stdout, message)
+    return nil
+}
+
+func parseID(what, value string) (int, error) {
+    id, err := strconv.Atoi(value)
+    if err != nil || id <= 0 {
+        return 0, usageError("%s: %q is not a valid id", what, value)
+    }
+    return id, nil
+}
+
+func usageError(format string, args ...interface{}) error {
+    return fmt.Errorf("%w: %s", errUsage, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+    "bytes"
+    "encoding/json"
+    "strings"
+    "testing"
+
+    "tui/api"
+    "tui/config"
+)
+
+// runFake runs one command against a fresh Fake, logged in as the demo user.
+func runFake(t *testing.T, fake *api.Fake, args ...string) (int, string, string) {
+    t.Helper()
+    cfg := config.Defaults()
+    cfg.Username = api.DemoUsername
+    cfg.DataDir = t.TempDir()
+    var stdout, stderr bytes.Buffer
+    code := Run(cfg, fake, args, &stdout, &stderr)
+    return code, stdout.String(), stderr.String()
+}
+
+func TestCommandsAgainstFake(t *testing.T) {
+    t.Setenv("BOOKTRACKER_PASSWORD", api.DemoPassword)
+
+    tests := []struct {
+        args []string
+        code int
+        out  string // expected in stdout, or stderr when code != 0
+    }{
+        {[]string{"books", "list"}, 0, "The Silent Horizon"},
+        {[]string{"books", "show", "7"}, 0, "Quiet and clever."},
+        {[]string{"books", "show", "999"}, 1, "404"},
+        {[]string{"books", "show", "x"}, 2, `"x" is not a valid id`},
+        {[]string{"shelf", "add", "4", "--library", "1", "--shelf", "read"}, 0, "Added book 4 to read"},
+        {[]string{"shelf", "add", "4", "--library", "1", "--shelf", "attic"}, 2, `unknown shelf "attic"`},
+        {[]string{"shelf", "add", "4", "--shelf", "to_read"}, 0, "Added book 4 to to_read"},
+        {[]string{"shelf", "add", "4", "--library", "9"}, 1, "404"},
+        {[]string{"read", "start", "9"}, 0, "Started reading book 9"},
+        {[]string{"read", "turn", "3", "--count", "5"}, 0, "Turned 5 page(s) forward in book 3"},
+        {[]string{"recommend", "ada", "9", "--message", "For the train"}, 0, "Recommended book 9 to ada"},
+        {[]string{"books"}, 2, "missing subcommand"},
+        {[]string{"shelves", "list"}, 2, `unknown command "shelves list"`},
+    }
+    for _, tt := range tests {
+        t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+            code, stdout, stderr := runFake(t, api.NewFake(), tt.args...)
+            got := stdout
+            if tt.code != 0 {
+                got = stderr
+            }
+            if code != tt.code || !strings.Contains(got, tt.out) {
+                t.Fatalf("exit %d, stdout %q, stderr %q; want exit %d with %q", code, stdout, stderr, tt.code, tt.out)
+            }
+        })
+    }
+}
+
+func TestShelfAddDefaultLibrary(t *testing.T) {
+    t.Setenv("BOOKTRACKER_PASSWORD", api.DemoPassword)
+    fake := api.NewFake()
+    fake.SetToken(api.DemoUsername)
+    holidays, _ := fake.CreateLibrary("Holidays")
+
+    tests := []struct {
+        defaultLibrary string
+        want           int
+    }{
+        {"", 1},
+        {"Holidays", holidays},
+        {"Missing", 1},
+    }
+    for _, tt := range tests {
+        cfg := config.Defaults()
+        cfg.Username = api.DemoUsername
+        cfg.DefaultLibrary = tt.defaultLibrary
+        var stdout, stderr bytes.Buffer
+        if code := Run(cfg, fake, []string{"shelf", "add", "21", "--json"}, &stdout, &stderr); code != 0 {
+            t.Fatalf("default_library %q: exit %d, %s", tt.defaultLibrary, code, stderr.String())
+        }
+        var out map[string]interface{}
+        json.Unmarshal(stdout.Bytes(), &out)
+        if out["library_id"] != float64(tt.want) {
+            t.Errorf("default_library %q: added to library %v, want %d", tt.defaultLibrary, out["library_id"], tt.want)
+        }
+    }
+}
+
+func TestWrongPassword(t *testing.T) {
+    t.Setenv("BOOKTRACKER_PASSWORD", "nope")
+    code, _, stderr := runFake(t, api.NewFake(), "read", "start", "9")
+    if code != 1 || !strings.Contains(stderr, "401 wrong username or password") {
+        t.Fatalf("exit %d, stderr %q", code, stderr)
+    }
+}
+
+func TestJSONOutput(t *testing.T) {
+    t.Setenv("BOOKTRACKER_PASSWORD", api.DemoPassword)
+    fake := api.NewFake()
+
+    code, stdout, _ := runFake(t, fake, "read", "turn", "3", "--count", "2", "--json")
+    var done map[string]interface{}
+    if code != 0 || json.Unmarshal([]byte(stdout), &done) != nil || done["status"] != "ok" || done["count"] != float64(2) {
+        t.Fatalf("exit %d, %q", code, stdout)
+    }
+
+    // The Fake kept the turn: book 3 was at page 112
+    fake.SetToken(api.DemoUsername)
+    sessions, _ := fake.GetActiveReading()
+    page := 0
+    for _, s := range sessions {
+        if s["book_id"] == 3 {
+            page = s["current_page"].(int)
+        }
+    }
+    if page != 114 {
+        t.Fatalf("book 3 at page %d, want 114", page)
+    }
+
+    code, stdout, _ = runFake(t, fake, "books", "list", "--json")
+    var books []bookJSON
+    if code != 0 || json.Unmarshal([]byte(stdout), &books) != nil || len(books) != len(api.SeedBooks) {
+        t.Fatalf("exit %d, %d books", code, len(books))
+    }
+}
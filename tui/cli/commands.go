@@ -0,0 +1,274 @@
+package cli
+
+import (
+    "fmt"
+    "text/tabwriter"
+
+    "tui/api"
+    "tui/types"
+)
+
+// bookJSON gives scripts stable lower-case field names.
+type bookJSON struct {
+    ID        int     `json:"id"`
+    Name      string  `json:"name"`
+    Author    string  `json:"author"`
+    Year      int     `json:"year"`
+    Pages     int     `json:"pages"`
+    Rating    float64 `json:"rating"`
+    Language  string  `json:"language"`
+    Publisher string  `json:"publisher"`
+}
+
+type reviewJSON struct {
+    User   string `json:"user"`
+    Rating int    `json:"rating"`
+    Text   string `json:"text"`
+    Likes  int    `json:"likes"`
+}
+
+type bookDetailsJSON struct {
+    bookJSON
+    Reviews []reviewJSON `json:"reviews"`
+}
+
+func toBookJSON(book types.Book) bookJSON {
+    return bookJSON{
+        ID:        book.ID,
+        Name:      book.Name,
+        Author:    book.Author,
+        Year:      book.Year,
+        Pages:     book.Pages,
+        Rating:    book.Rating,
+        Language:  book.Language,
+        Publisher: book.Publisher,
+    }
+}
+
+func (c *command) booksList(args []string) error {
+    fs := c.flags("books list")
+    if _, err := parse(fs, args, 0); err != nil {
+        return err
+    }
+
+    books, err := c.client.ListBooks()
+    if err != nil {
+        return err
+    }
+
+    if c.json {
+        out := make([]bookJSON, 0, len(books))
+        for _, book := range books {
+            out = append(out, toBookJSON(book))
+        }
+        return c.printJSON(out)
+    }
+
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(w, "ID\tTITLE\tAUTHOR\tYEAR\tPAGES")
+    for _, book := range books {
+        fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\n", book.ID, book.Name, book.Author, book.Year, book.Pages)
+    }
+    return w.Flush()
+}
+
+func (c *command) booksShow(args []string) error {
+    fs := c.flags("books show")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("books show", positional[0])
+    if err != nil {
+        return err
+    }
+
+    details, err := c.client.GetBookDetails(bookID)
+    if err != nil {
+        return err
+    }
+    book := details.Book
+
+    if c.json {
+        out := bookDetailsJSON{bookJSON: toBookJSON(book), Reviews: []reviewJSON{}}
+        for _, r := range details.Reviews {
+            out.Reviews = append(out.Reviews, reviewJSON{User: r.User, Rating: r.Rating, Text: r.Text, Likes: r.Likes})
+        }
+        return c.printJSON(out)
+    }
+
+    fmt.Fprintf(c.stdout, "%s\n", book.Name)
+    fmt.Fprintf(c.stdout, "  Author:    %s\n", book.Author)
+    fmt.Fprintf(c.stdout, "  Year:      %d\n", book.Year)
+    fmt.Fprintf(c.stdout, "  Pages:     %d\n", book.Pages)
+    fmt.Fprintf(c.stdout, "  Rating:    %.1f/5\n", book.Rating)
+    fmt.Fprintf(c.stdout, "  Language:  %s\n", book.Language)
+    fmt.Fprintf(c.stdout, "  Publisher: %s\n", book.Publisher)
+
+    if len(details.Reviews) > 0 {
+        fmt.Fprintf(c.stdout, "\nReviews:\n")
+        for _, r := range details.Reviews {
+            fmt.Fprintf(c.stdout, "  %s (%d/5): %s\n", r.User, r.Rating, r.Text)
+        }
+    }
+    return nil
+}
+
+func (c *command) shelfAdd(args []string) error {
+    fs := c.flags("shelf add")
+    shelf := fs.String("shelf", "to_read", "shelf to put the book on (to_read, currently_reading, read)")
+    libraryID := fs.Int("library", 0, "id of the library to add the book to (default: default_library, or the first one)")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("shelf add", positional[0])
+    if err != nil {
+        return err
+    }
+    if !validShelf(*shelf) {
+        return usageError("shelf add: unknown shelf %q", *shelf)
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if *libraryID <= 0 {
+        if *libraryID, err = c.defaultLibrary(); err != nil {
+            return err
+        }
+    }
+    if err := c.client.AddBookToLibrary(*libraryID, bookID, *shelf); err != nil {
+        return err
+    }
+
+    return c.done(
+        fmt.Sprintf("Added book %d to %s", bookID, *shelf),
+        map[string]interface{}{"book_id": bookID, "library_id": *libraryID, "shelf": *shelf},
+    )
+}
+
+func (c *command) readStart(args []string) error {
+    fs := c.flags("read start")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("read start", positional[0])
+    if err != nil {
+        return err
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if err := c.client.StartReading(bookID); err != nil {
+        return err
+    }
+
+    return c.done(
+        fmt.Sprintf("Started reading book %d", bookID),
+        map[string]interface{}{"book_id": bookID},
+    )
+}
+
+func (c *command) readTurn(args []string) error {
+    fs := c.flags("read turn")
+    count := fs.Int("count", 1, "number of pages to turn")
+    back := fs.Bool("back", false, "turn pages backwards")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("read turn", positional[0])
+    if err != nil {
+        return err
+    }
+    if *count < 1 {
+        return usageError("read turn: --count must be at least 1")
+    }
+
+    direction := "forward"
+    if *back {
+        direction = "back"
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if err := c.client.TurnPage(bookID, direction, *count); err != nil {
+        return err
+    }
+
+    return c.done(
+        fmt.Sprintf("Turned %d page(s) %s in book %d", *count, direction, bookID),
+        map[string]interface{}{"book_id": bookID, "direction": direction, "count": *count},
+    )
+}
+
+func (c *command) register(args []string) error {
+    fs := c.flags("register")
+    name := fs.String("name", "", "display name (default: the username)")
+    if _, err := parse(fs, args, 0); err != nil {
+        return err
+    }
+    if c.cfg.Username == "" {
+        return usageError("register: give the new account's name with --user or BOOKTRACKER_USER")
+    }
+    registerer, ok := c.client.(api.Registerer)
+    if !ok {
+        return fmt.Errorf("register: the %s backend has no accounts to create", c.cfg.Backend)
+    }
+    password, err := c.password()
+    if err != nil {
+        return err
+    }
+    if password == "" {
+        return usageError("register: the password is empty")
+    }
+    if *name == "" {
+        *name = c.cfg.Username
+    }
+    if err := registerer.Register(c.cfg.Username, *name, password); err != nil {
+        return err
+    }
+
+    return c.done(
+        fmt.Sprintf("Registered %s with a \"My Library\"", c.cfg.Username),
+        map[string]interface{}{"username": c.cfg.Username},
+    )
+}
+
+func (c *command) recommend(args []string) error {
+    fs := c.flags("recommend")
+    message := fs.String("message", "", "note to send along")
+    positional, err := parse(fs, args, 2)
+    if err != nil {
+        return err
+    }
+    toUser := positional[0]
+    bookID, err := parseID("recommend", positional[1])
+    if err != nil {
+        return err
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if err := c.client.RecommendBook(toUser, bookID, *message); err != nil {
+        return err
+    }
+
+    return c.done(
+        fmt.Sprintf("Recommended book %d to %s", bookID, toUser),
+        map[string]interface{}{"book_id": bookID, "to_user": toUser},
+    )
+}
+
+func validShelf(name string) bool {
+    switch name {
+    case "to_read", "currently_reading", "read":
+        return true
+    }
+    return false
+}
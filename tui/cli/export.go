@@ -0,0 +1,62 @@
+package cli
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "tui/export"
+)
+
+func (c *command) export(args []string) error {
+    fs := c.flags("export")
+    format := fs.String("format", "", "json, csv (Goodreads columns) or md (default: from --output, else json)")
+    output := fs.String("output", "", "file to write (default: standard output)")
+    if _, err := parse(fs, args, 0); err != nil {
+        return err
+    }
+    if *format == "" {
+        *format = export.FormatOf(*output)
+    }
+    if *format == "" {
+        *format = "json"
+    }
+    if !contains(export.Formats, *format) {
+        return usageError("export: unknown format %q (expected one of %s)", *format, strings.Join(export.Formats, ", "))
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    snap, err := export.Collect(c.client, c.cfg.Username)
+    if err != nil {
+        return err
+    }
+
+    if *output == "" {
+        return export.Write(c.stdout, *format, snap)
+    }
+    f, err := os.Create(*output)
+    if err != nil {
+        return err
+    }
+    if err := export.Write(f, *format, snap); err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+    fmt.Fprintf(c.stderr, "Wrote %s: %d libraries, %d reviews, %d reading sessions\n",
+        *output, len(snap.Libraries), len(snap.Reviews), len(snap.Sessions))
+    return nil
+}
+
+func contains(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
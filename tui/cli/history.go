@@ -0,0 +1,126 @@
+package cli
+
+import (
+    "fmt"
+    "strings"
+    "text/tabwriter"
+    "time"
+
+    "tui/store"
+    "tui/views"
+)
+
+type sittingJSON struct {
+    StartPage int    `json:"start_page"`
+    EndPage   int    `json:"end_page"`
+    Start     string `json:"start"`
+    End       string `json:"end"`
+    Minutes   int    `json:"minutes"`
+    Pause     int    `json:"pause_minutes,omitempty"`
+    Reread    bool   `json:"reread,omitempty"`
+}
+
+type bookmarkJSON struct {
+    Name string `json:"name"`
+    Page int    `json:"page"`
+}
+
+func (c *command) readBookmark(args []string) error {
+    fs := c.flags("read bookmark")
+    page := fs.Int("page", 0, "page to mark (default: the page the book is open at)")
+    positional, err := parse(fs, args, 2)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("read bookmark", positional[0])
+    if err != nil {
+        return err
+    }
+    name := strings.TrimSpace(positional[1])
+    if name == "" {
+        return usageError("read bookmark: the bookmark needs a name")
+    }
+    st, err := c.store("read bookmark")
+    if err != nil {
+        return err
+    }
+    if *page <= 0 {
+        *page = c.currentPage(bookID)
+    }
+    if *page <= 0 {
+        return usageError("read bookmark: book %d is not open at a page, give --page", bookID)
+    }
+
+    if err := st.Add(store.Highlight{BookID: bookID, Kind: store.KindBookmark, Text: name, Page: *page}); err != nil {
+        return err
+    }
+    return c.done(fmt.Sprintf("Bookmark %q saved on book %d, page %d", name, bookID, *page),
+        map[string]interface{}{"book_id": bookID, "name": name, "page": *page})
+}
+
+func (c *command) readHistory(args []string) error {
+    fs := c.flags("read history")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("read history", positional[0])
+    if err != nil {
+        return err
+    }
+    st, err := c.store("read history")
+    if err != nil {
+        return err
+    }
+    entries, bookmarks := st.Timeline(bookID), st.Bookmarks(bookID)
+
+    if c.json {
+        out := struct {
+            BookID    int            `json:"book_id"`
+            Bookmarks []bookmarkJSON `json:"bookmarks"`
+            Sittings  []sittingJSON  `json:"sittings"`
+        }{BookID: bookID, Bookmarks: []bookmarkJSON{}, Sittings: []sittingJSON{}}
+        for _, b := range bookmarks {
+            out.Bookmarks = append(out.Bookmarks, bookmarkJSON{Name: b.Text, Page: b.Page})
+        }
+        for _, e := range entries {
+            out.Sittings = append(out.Sittings, sittingJSON{
+                StartPage: e.StartPage, EndPage: e.EndPage,
+                Start: e.Start.Format(time.RFC3339), End: e.End.Format(time.RFC3339),
+                Minutes: int(e.End.Sub(e.Start).Minutes()), Pause: int(e.Pause.Minutes()), Reread: e.Reread,
+            })
+        }
+        return c.printJSON(out)
+    }
+
+    if len(bookmarks) > 0 {
+        w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+        fmt.Fprintln(w, "PAGE\tBOOKMARK")
+        for _, b := range bookmarks {
+            fmt.Fprintf(w, "%d\t%s\n", b.Page, b.Text)
+        }
+        if err := w.Flush(); err != nil {
+            return err
+        }
+        fmt.Fprintln(c.stdout)
+    }
+    if len(entries) == 0 {
+        fmt.Fprintf(c.stdout, "No sittings of book %d yet, they are kept as pages are turned in the reading view.\n", bookID)
+        return nil
+    }
+
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(w, "STARTED\tFOR\tPAGES\tAFTER\t")
+    for _, e := range entries {
+        after, note := "-", ""
+        if e.Pause > 0 {
+            after = views.Duration(e.Pause)
+        }
+        if e.Reread {
+            note = "reread"
+        }
+        fmt.Fprintf(w, "%s\t%s\t%d → %d\t%s\t%s\n", e.Start.Local().Format("2006-01-02 15:04"),
+            views.Duration(e.End.Sub(e.Start)), e.StartPage, e.EndPage, after, note)
+    }
+    return w.Flush()
+}
@@ -0,0 +1,306 @@
+package cli
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+
+    "golang.org/x/term"
+    "tui/importer"
+    "tui/store"
+)
+
+type skippedJSON struct {
+    Line   int    `json:"line"`
+    Title  string `json:"title"`
+    Author string `json:"author"`
+    Reason string `json:"reason"`
+}
+
+func (c *command) importGoodreads(args []string) error {
+    fs := c.flags("import goodreads")
+    libraryID := fs.Int("library", 0, "id of the library to import into (default: the configured default library)")
+    shelfMap := fs.String("shelf-map", "", "extra shelf mappings, e.g. owned=read,wishlist=to_read")
+    dryRun := fs.Bool("dry-run", false, "show how rows match without importing anything")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    shelves, err := importer.ParseShelfMap(importer.GoodreadsShelves, *shelfMap)
+    if err != nil {
+        return usageError("import goodreads: %v", err)
+    }
+
+    f, err := os.Open(positional[0])
+    if err != nil {
+        return err
+    }
+    rows, err := importer.ParseGoodreads(f)
+    f.Close()
+    if err != nil {
+        return err
+    }
+
+    catalog, err := c.client.ListBooks()
+    if err != nil {
+        return err
+    }
+    items := importer.Plan(rows, catalog, shelves)
+
+    if *dryRun {
+        return c.printPlan(items)
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if *libraryID <= 0 {
+        if *libraryID, err = c.defaultLibrary(); err != nil {
+            return err
+        }
+    }
+    if !c.json && term.IsTerminal(int(os.Stdin.Fd())) {
+        c.resolve(items)
+    }
+
+    return c.printSummary(importer.Apply(c.client, *libraryID, items), *libraryID)
+}
+
+func (c *command) importCalibre(args []string) error {
+    fs := c.flags("import calibre")
+    libraryID := fs.Int("library", 0, "id of the library to import into (default: the configured default library)")
+    shelf := fs.String("shelf", "to_read", "shelf for the matched books: to_read, currently_reading or read")
+    pagesColumn := fs.String("pages-column", importer.DefaultPagesColumn, "label of the custom column holding page counts")
+    dryRun := fs.Bool("dry-run", false, "show how books match, and where Calibre disagrees, without importing anything")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    if *shelf != "to_read" && *shelf != "currently_reading" && *shelf != "read" {
+        return usageError("import calibre: unknown shelf %q", *shelf)
+    }
+
+    books, err := importer.ReadCalibre(positional[0], *pagesColumn)
+    if err != nil {
+        return err
+    }
+    catalog, err := c.client.ListBooks()
+    if err != nil {
+        return err
+    }
+    items := importer.Plan(importer.CalibreRows(books), catalog, map[string]string{importer.CalibreShelf: *shelf})
+
+    if *dryRun {
+        if err := c.printPlan(items); err != nil || c.json {
+            return err
+        }
+        for i, item := range items {
+            if item.Status != importer.Matched {
+                continue
+            }
+            for _, diff := range importer.Differences(books[i].Book, item.Book) {
+                fmt.Fprintf(c.stdout, "  %s: %s\n", item.Book.Name, diff)
+            }
+        }
+        return nil
+    }
+
+    if err := c.login(); err != nil {
+        return err
+    }
+    if *libraryID <= 0 {
+        if *libraryID, err = c.defaultLibrary(); err != nil {
+            return err
+        }
+    }
+    if !c.json && term.IsTerminal(int(os.Stdin.Fd())) {
+        c.resolve(items)
+    }
+
+    return c.printSummary(importer.Apply(c.client, *libraryID, items), *libraryID)
+}
+
+func (c *command) importKindle(args []string) error {
+    fs := c.flags("import kindle")
+    dryRun := fs.Bool("dry-run", false, "show how books match without storing anything")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    if c.cfg.Username == "" && !*dryRun {
+        return usageError("import kindle: highlights are kept per user, give --user or BOOKTRACKER_USER")
+    }
+
+    f, err := os.Open(positional[0])
+    if err != nil {
+        return err
+    }
+    clippings, err := importer.ParseKindle(f)
+    f.Close()
+    if err != nil {
+        return err
+    }
+
+    catalog, err := c.client.ListBooks()
+    if err != nil {
+        return err
+    }
+    rows, grouped := importer.KindleBooks(clippings)
+    items := importer.Plan(rows, catalog, nil)
+
+    if *dryRun {
+        return c.printPlan(items)
+    }
+    if !c.json && term.IsTerminal(int(os.Stdin.Fd())) {
+        c.resolve(items)
+    }
+
+    st, err := store.Open(c.cfg.DataDir, c.cfg.Username)
+    if err != nil {
+        return err
+    }
+    highlights, skipped := importer.KindleHighlights(items, grouped)
+    added, err := st.AddHighlights(highlights)
+    if err != nil {
+        return err
+    }
+
+    if c.json {
+        out := make([]skippedJSON, 0, len(skipped))
+        for _, s := range skipped {
+            out = append(out, skippedJSON{Line: s.Row.Line, Title: s.Row.Title, Author: s.Row.Author, Reason: s.Reason})
+        }
+        return c.printJSON(map[string]interface{}{
+            "status":  "ok",
+            "found":   len(highlights),
+            "added":   added,
+            "books":   len(items) - len(skipped),
+            "skipped": out,
+        })
+    }
+
+    fmt.Fprintf(c.stdout, "Stored %d new highlight(s) of %d found, for %d book(s); skipped %d book(s)\n",
+        added, len(highlights), len(items)-len(skipped), len(skipped))
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    for _, s := range skipped {
+        fmt.Fprintf(w, "  line %d\t%s\t%s\n", s.Row.Line, s.Row.Title, s.Reason)
+    }
+    return w.Flush()
+}
+
+// defaultLibrary finds the id of the configured default library, or the
+// user's first one.
+func (c *command) defaultLibrary() (int, error) {
+    libraries, err := c.client.GetUserLibraries(c.cfg.Username)
+    if err != nil {
+        return 0, err
+    }
+    if len(libraries) == 0 {
+        return 0, fmt.Errorf("%s has no library yet", c.cfg.Username)
+    }
+    for _, lib := range libraries {
+        if lib.Name == c.cfg.DefaultLibrary {
+            return lib.ID, nil
+        }
+    }
+    return libraries[0].ID, nil
+}
+
+// resolve asks which book each ambiguous row is, on stderr so the summary
+// on stdout stays clean.
+func (c *command) resolve(items []importer.Item) {
+    in := bufio.NewScanner(os.Stdin)
+    for i := range items {
+        item := &items[i]
+        if item.Status != importer.Ambiguous {
+            continue
+        }
+
+        fmt.Fprintf(c.stderr, "\nLine %d: %s by %s\n", item.Row.Line, item.Row.Title, item.Row.Author)
+        for n, cand := range item.Candidates {
+            fmt.Fprintf(c.stderr, "  %d) %s by %s (%d, %.0f%%)\n", n+1, cand.Book.Name, cand.Book.Author, cand.Book.Year, cand.Score*100)
+        }
+        for {
+            fmt.Fprintf(c.stderr, "Which book? [1-%d, s to skip] ", len(item.Candidates))
+            if !in.Scan() {
+                return
+            }
+            answer := strings.TrimSpace(in.Text())
+            if answer == "s" || answer == "" {
+                item.Skip = true
+                break
+            }
+            if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(item.Candidates) {
+                item.Resolve(item.Candidates[n-1].Book)
+                break
+            }
+        }
+    }
+}
+
+func (c *command) printPlan(items []importer.Item) error {
+    if c.json {
+        type planJSON struct {
+            Line   int     `json:"line"`
+            Title  string  `json:"title"`
+            Status string  `json:"status"`
+            Shelf  string  `json:"shelf"`
+            BookID int     `json:"book_id,omitempty"`
+            Score  float64 `json:"score,omitempty"`
+        }
+        out := make([]planJSON, 0, len(items))
+        for _, item := range items {
+            p := planJSON{Line: item.Row.Line, Title: item.Row.Title, Status: item.Status.String(), Shelf: item.Shelf}
+            if len(item.Candidates) > 0 {
+                p.Score = item.Candidates[0].Score
+            }
+            if item.Status == importer.Matched {
+                p.BookID = item.Book.ID
+            }
+            out = append(out, p)
+        }
+        return c.printJSON(out)
+    }
+
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(w, "LINE\tSTATUS\tSHELF\tTITLE\tBOOK")
+    for _, item := range items {
+        book := ""
+        switch {
+        case item.Status == importer.Matched:
+            book = fmt.Sprintf("%d %s", item.Book.ID, item.Book.Name)
+        case len(item.Candidates) > 0:
+            book = fmt.Sprintf("%d candidates", len(item.Candidates))
+        }
+        fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", item.Row.Line, item.Status, item.Shelf, item.Row.Title, book)
+    }
+    return w.Flush()
+}
+
+func (c *command) printSummary(summary importer.Summary, libraryID int) error {
+    if c.json {
+        skipped := make([]skippedJSON, 0, len(summary.Skipped))
+        for _, s := range summary.Skipped {
+            skipped = append(skipped, skippedJSON{Line: s.Row.Line, Title: s.Row.Title, Author: s.Row.Author, Reason: s.Reason})
+        }
+        return c.printJSON(map[string]interface{}{
+            "status":     "ok",
+            "library_id": libraryID,
+            "imported":   summary.Imported,
+            "reviewed":   summary.Reviewed,
+            "unchanged":  summary.Unchanged,
+            "skipped":    skipped,
+        })
+    }
+
+    fmt.Fprintf(c.stdout, "Imported %d book(s) into library %d, %d with a rating; %d unchanged; skipped %d\n",
+        summary.Imported, libraryID, summary.Reviewed, summary.Unchanged, len(summary.Skipped))
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    for _, s := range summary.Skipped {
+        fmt.Fprintf(w, "  line %d\t%s\t%s\n", s.Row.Line, s.Row.Title, s.Reason)
+    }
+    return w.Flush()
+}
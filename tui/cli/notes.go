@@ -0,0 +1,193 @@
+package cli
+
+import (
+    "fmt"
+    "strings"
+    "text/tabwriter"
+
+    "tui/export"
+    "tui/store"
+    "tui/types"
+)
+
+type noteJSON struct {
+    BookID   int    `json:"book_id"`
+    Book     string `json:"book"`
+    Kind     string `json:"kind"`
+    Text     string `json:"text"`
+    Page     int    `json:"page,omitempty"`
+    Location string `json:"location,omitempty"`
+    Added    string `json:"added,omitempty"`
+}
+
+// store opens the user's notes, they are kept per user on this machine.
+func (c *command) store(name string) (*store.Store, error) {
+    if c.cfg.Username == "" {
+        return nil, usageError("%s: notes are kept per user, give --user or BOOKTRACKER_USER", name)
+    }
+    return store.Open(c.cfg.DataDir, c.cfg.Username)
+}
+
+func (c *command) notesAdd(args []string) error {
+    fs := c.flags("notes add")
+    page := fs.Int("page", 0, "page the note is about (default: the page the book is open at)")
+    quote := fs.Bool("quote", false, "the text is a quote from the book, not a thought about it")
+    positional, err := parse(fs, args, 2)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("notes add", positional[0])
+    if err != nil {
+        return err
+    }
+    text := strings.TrimSpace(positional[1])
+    if text == "" {
+        return usageError("notes add: the note is empty")
+    }
+    st, err := c.store("notes add")
+    if err != nil {
+        return err
+    }
+    if *page <= 0 {
+        *page = c.currentPage(bookID)
+    }
+
+    h := store.Highlight{BookID: bookID, Kind: store.KindNote, Text: text, Page: *page}
+    if *quote {
+        h.Kind = store.KindQuote
+    }
+    if err := st.Add(h); err != nil {
+        return err
+    }
+    return c.done(fmt.Sprintf("Saved a %s on book %d, page %d", h.Kind, bookID, *page),
+        map[string]interface{}{"book_id": bookID, "kind": h.Kind, "page": *page})
+}
+
+// currentPage is the page of the book's reading session, 0 when it has none.
+// Reading sessions need a login, the page is only a default so a failure
+// just leaves it out.
+func (c *command) currentPage(bookID int) int {
+    if c.login() != nil {
+        return 0
+    }
+    rows, err := c.client.GetActiveReading()
+    if err != nil {
+        return 0
+    }
+    for _, row := range rows {
+        if intField(row, "book_id") == bookID {
+            return intField(row, "current_page")
+        }
+    }
+    return 0
+}
+
+// intField reads a number from a session row, float64 over HTTP and int
+// from the in-process backends.
+func intField(row map[string]interface{}, key string) int {
+    switch v := row[key].(type) {
+    case int:
+        return v
+    case float64:
+        return int(v)
+    }
+    return 0
+}
+
+func (c *command) notesList(args []string) error {
+    fs := c.flags("notes list")
+    by := fs.String("by", "page", "order: page or time (the date they were added)")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    bookID, err := parseID("notes list", positional[0])
+    if err != nil {
+        return err
+    }
+    order := store.ByPage
+    switch *by {
+    case "page":
+    case "time":
+        order = store.ByTime
+    default:
+        return usageError("notes list: --by is page or time, not %q", *by)
+    }
+    st, err := c.store("notes list")
+    if err != nil {
+        return err
+    }
+    return c.printNotes(st.Highlights(bookID, order))
+}
+
+func (c *command) notesSearch(args []string) error {
+    fs := c.flags("notes search")
+    positional, err := parse(fs, args, 1)
+    if err != nil {
+        return err
+    }
+    st, err := c.store("notes search")
+    if err != nil {
+        return err
+    }
+    return c.printNotes(st.Search(positional[0]))
+}
+
+func (c *command) notesExport(args []string) error {
+    fs := c.flags("notes export")
+    output := fs.String("output", export.NotesFolder, "folder to write the notes to, e.g. inside an Obsidian vault")
+    if _, err := parse(fs, args, 0); err != nil {
+        return err
+    }
+    st, err := c.store("notes export")
+    if err != nil {
+        return err
+    }
+    catalog, err := c.client.ListBooks()
+    if err != nil {
+        return err
+    }
+    n, err := export.WriteVault(*output, catalog, st.All())
+    if err != nil {
+        return err
+    }
+    return c.done(fmt.Sprintf("Wrote notes on %d book(s) to %s", n, *output),
+        map[string]interface{}{"books": n, "output": *output})
+}
+
+func (c *command) printNotes(notes []store.Highlight) error {
+    books := map[int]types.Book{}
+    if catalog, err := c.client.ListBooks(); err == nil {
+        for _, b := range catalog {
+            books[b.ID] = b
+        }
+    }
+    name := func(id int) string {
+        if b, ok := books[id]; ok {
+            return b.Name
+        }
+        return fmt.Sprintf("Book %d", id)
+    }
+
+    if c.json {
+        out := make([]noteJSON, 0, len(notes))
+        for _, n := range notes {
+            out = append(out, noteJSON{BookID: n.BookID, Book: name(n.BookID), Kind: n.Kind, Text: n.Text, Page: n.Page, Location: n.Location, Added: n.Added})
+        }
+        return c.printJSON(out)
+    }
+
+    w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(w, "BOOK\tPAGE\tKIND\tTEXT")
+    for _, n := range notes {
+        where := fmt.Sprint(n.Page)
+        switch {
+        case n.Page == 0 && n.Location != "":
+            where = "loc. " + n.Location
+        case n.Page == 0:
+            where = "-"
+        }
+        fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name(n.BookID), where, n.Kind, strings.Join(strings.Fields(n.Text), " "))
+    }
+    return w.Flush()
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+    "fmt"
+    "net/http"
+    "time"
+
+    "tui/server"
+)
+
+func (c *command) serve(args []string) error {
+    fs := c.flags("serve")
+    addr := fs.String("addr", "127.0.0.1:8000", "address to listen on")
+    latency := fs.Duration("latency", 0, "delay added to every response")
+    failRate := fs.Float64("fail-rate", 0, "share of requests (0..1) answered with --fail-status")
+    failStatus := fs.Int("fail-status", http.StatusInternalServerError, "status code for failed requests")
+    seed := fs.Int64("seed", time.Now().UnixNano(), "seed for the failure dice")
+    if _, err := parse(fs, args, 0); err != nil {
+        return err
+    }
+    if *failRate < 0 || *failRate > 1 {
+        return usageError("serve: --fail-rate must be between 0 and 1")
+    }
+    if *failStatus < 400 || *failStatus > 599 {
+        return usageError("serve: --fail-status must be a 4xx or 5xx code")
+    }
+
+    srv := server.New(server.Options{
+        Latency:       *latency,
+        FailureRate:   *failRate,
+        FailureStatus: *failStatus,
+        Seed:          *seed,
+    })
+
+    fmt.Fprintf(c.stderr, "Serving the stand-in backend on http://%s (log in as demo/demo)\n", *addr)
+    return http.ListenAndServe(*addr, srv)
+}
@@ -0,0 +1,544 @@
+package config
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/BurntSushi/toml"
+    "tui/keymap"
+    "tui/store"
+    "tui/styles"
+)
+
+// Config holds every user-tunable setting of the TUI.
+// Values are resolved in layers: defaults -> config file -> environment -> flags.
+type Config struct {
+    Backend        string // "http", "demo" or "local"
+    APIURL         string
+    Database       string // SQLite file used by the local backend
+    Timeout        time.Duration
+    Username       string
+    Theme          string
+    DefaultLibrary string
+    PageSize       int
+    KeymapPreset   string
+    Keymap         map[string]string // action -> comma separated keys
+    StrictDecode   bool              // report API contract drift as errors
+    HTTPCache      bool              // cache GET responses of the http backend
+    Bidi           bool              // reorder right-to-left text for display
+    ShelfStyle     string            // "boxes" or "bookcase"
+    SpineColor     string            // what bookcase spines are coloured by
+    ShelfLayout    string            // "scroll" or "wrap" when a shelf is too wide
+    ExportDir      string            // where the TUI writes exports
+    DataDir        string            // highlights and other data kept on this machine
+
+    // Resolution details, not settings themselves
+    Path        string   // config file that was read, empty if none
+    PrintConfig bool     // --print-config was given
+    Args        []string // positional arguments left after flags
+    sources     map[string]string
+}
+
+// Themes are the built-in theme names, a path to a .toml theme works too.
+var Themes = styles.ThemeNames
+
+var Backends = []string{"http", "demo", "local"}
+
+// ShelfStyles are the ways the library can be drawn: "boxes" is a card per
+// book, "bookcase" stands the books on wooden planks by their spines.
+var ShelfStyles = []string{"boxes", "bookcase"}
+
+// SpineColors are what a spine's colour says in the bookcase.
+var SpineColors = []string{"shelf", "language", "rating"}
+
+// ShelfLayouts are what a shelf too wide for the terminal does: scroll
+// sideways or wrap into more rows.
+var ShelfLayouts = []string{"scroll", "wrap"}
+
+// fileConfig mirrors config.toml. Pointers tell "unset" apart from zero values.
+type fileConfig struct {
+    Backend        *string           `toml:"backend"`
+    APIURL         *string           `toml:"api_url"`
+    Database       *string           `toml:"database"`
+    Timeout        *time.Duration    `toml:"timeout"`
+    Username       *string           `toml:"username"`
+    Theme          *string           `toml:"theme"`
+    DefaultLibrary *string           `toml:"default_library"`
+    PageSize       *int              `toml:"page_size"`
+    KeymapPreset   *string           `toml:"keymap_preset"`
+    Keymap         map[string]string `toml:"keymap"`
+    StrictDecode   *bool             `toml:"strict_decode"`
+    HTTPCache      *bool             `toml:"http_cache"`
+    Bidi           *bool             `toml:"bidi"`
+    ShelfStyle     *string           `toml:"shelf_style"`
+    SpineColor     *string           `toml:"spine_color"`
+    ShelfLayout    *string           `toml:"shelf_layout"`
+    ExportDir      *string           `toml:"export_dir"`
+    DataDir        *string           `toml:"data_dir"`
+}
+
+func Defaults() Config {
+    return Config{
+        Backend:  "http",
+        APIURL:   "http://localhost:8000",
+        Database: "app.db",
+        Timeout:  10 * time.Second,
+        Theme:    "dark",
+        PageSize:     20,
+        KeymapPreset: "default",
+        Keymap:       map[string]string{},
+        Bidi:         true,
+        HTTPCache:    true,
+        ShelfStyle:   "boxes",
+        SpineColor:   "language",
+        ShelfLayout:  "scroll",
+        ExportDir:    ".",
+        DataDir:      store.DefaultDir(),
+        sources: map[string]string{
+            "backend":         "default",
+            "api_url":         "default",
+            "database":        "default",
+            "timeout":         "default",
+            "username":        "default",
+            "theme":           "default",
+            "default_library": "default",
+            "page_size":       "default",
+            "keymap_preset":   "default",
+            "strict_decode":   "default",
+            "http_cache":      "default",
+            "bidi":            "default",
+            "shelf_style":     "default",
+            "spine_color":     "default",
+            "shelf_layout":    "default",
+            "export_dir":      "default",
+            "data_dir":        "default",
+        },
+    }
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/booktracker/config.toml,
+// falling back to ~/.config/booktracker/config.toml.
+func DefaultPath() string {
+    dir := os.Getenv("XDG_CONFIG_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".config")
+    }
+    return filepath.Join(dir, "booktracker", "config.toml")
+}
+
+// Load resolves the configuration from all layers and validates the result.
+// args are the command line arguments without the program name.
+func Load(args []string) (Config, error) {
+    cfg := Defaults()
+
+    fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+    configPath := fs.String("config", "", "path to config file (default "+DefaultPath()+")")
+    backend := fs.String("backend", "", "where data comes from ("+strings.Join(Backends, ", ")+")")
+    demo := fs.Bool("demo", false, "use the built-in demo data, same as --backend demo")
+    apiURL := fs.String("api-url", "", "backend API base URL")
+    database := fs.String("db", "", "SQLite database for --backend local")
+    timeout := fs.Duration("timeout", 0, "HTTP request timeout, e.g. 5s")
+    username := fs.String("user", "", "username for commands that need to log in")
+    theme := fs.String("theme", "", "colour theme ("+strings.Join(Themes, ", ")+") or a .toml file")
+    library := fs.String("library", "", "name of the library to open on start")
+    pageSize := fs.Int("page-size", 0, "number of books fetched per page")
+    preset := fs.String("keymap", "", "key binding preset ("+strings.Join(keymap.Presets, ", ")+")")
+    keys := keyFlag{}
+    fs.Var(keys, "key", "override a key binding, e.g. --key quit=ctrl+q (repeatable)")
+    strict := fs.Bool("strict-decode", false, "fail on API responses with unknown or missing fields")
+    httpCache := fs.Bool("http-cache", true, "reuse recent API responses, revalidating them with ETags")
+    bidi := fs.Bool("bidi", true, "reorder Arabic and Hebrew text, turn off if the terminal does it")
+    shelfStyle := fs.String("shelf-style", "", "how shelves are drawn ("+strings.Join(ShelfStyles, ", ")+")")
+    spineColor := fs.String("spine-color", "", "what bookcase spines are coloured by ("+strings.Join(SpineColors, ", ")+")")
+    shelfLayout := fs.String("shelf-layout", "", "shelves too wide for the terminal ("+strings.Join(ShelfLayouts, ", ")+")")
+    exportDir := fs.String("export-dir", "", "directory the TUI writes exports to")
+    dataDir := fs.String("data-dir", "", "directory for highlights and other local data")
+    fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective configuration and exit")
+
+    if err := fs.Parse(args); err != nil {
+        return cfg, err
+    }
+    cfg.Args = fs.Args()
+
+    var problems []string
+
+    // Config file
+    path := *configPath
+    explicit := path != ""
+    if !explicit {
+        path = os.Getenv("BOOKTRACKER_CONFIG")
+        explicit = path != ""
+    }
+    if !explicit {
+        path = DefaultPath()
+    }
+    if path != "" {
+        unknown, err := cfg.loadFile(path, explicit)
+        if err != nil {
+            return cfg, err
+        }
+        problems = append(problems, unknown...)
+    }
+
+    // Environment
+    if v := os.Getenv("BOOKTRACKER_BACKEND"); v != "" {
+        cfg.set("backend", "env", func() { cfg.Backend = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_API_URL"); v != "" {
+        cfg.set("api_url", "env", func() { cfg.APIURL = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_DATABASE"); v != "" {
+        cfg.set("database", "env", func() { cfg.Database = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_TIMEOUT"); v != "" {
+        d, err := time.ParseDuration(v)
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("BOOKTRACKER_TIMEOUT: %q is not a duration", v))
+        } else {
+            cfg.set("timeout", "env", func() { cfg.Timeout = d })
+        }
+    }
+    if v := os.Getenv("BOOKTRACKER_USER"); v != "" {
+        cfg.set("username", "env", func() { cfg.Username = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_THEME"); v != "" {
+        cfg.set("theme", "env", func() { cfg.Theme = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_DEFAULT_LIBRARY"); v != "" {
+        cfg.set("default_library", "env", func() { cfg.DefaultLibrary = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_KEYMAP"); v != "" {
+        cfg.set("keymap_preset", "env", func() { cfg.KeymapPreset = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_STRICT_DECODE"); v != "" {
+        b, err := strconv.ParseBool(v)
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("BOOKTRACKER_STRICT_DECODE: %q is not a boolean", v))
+        } else {
+            cfg.set("strict_decode", "env", func() { cfg.StrictDecode = b })
+        }
+    }
+    if v := os.Getenv("BOOKTRACKER_HTTP_CACHE"); v != "" {
+        b, err := strconv.ParseBool(v)
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("BOOKTRACKER_HTTP_CACHE: %q is not a boolean", v))
+        } else {
+            cfg.set("http_cache", "env", func() { cfg.HTTPCache = b })
+        }
+    }
+    if v := os.Getenv("BOOKTRACKER_BIDI"); v != "" {
+        b, err := strconv.ParseBool(v)
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("BOOKTRACKER_BIDI: %q is not a boolean", v))
+        } else {
+            cfg.set("bidi", "env", func() { cfg.Bidi = b })
+        }
+    }
+    if v := os.Getenv("BOOKTRACKER_SHELF_STYLE"); v != "" {
+        cfg.set("shelf_style", "env", func() { cfg.ShelfStyle = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_SPINE_COLOR"); v != "" {
+        cfg.set("spine_color", "env", func() { cfg.SpineColor = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_SHELF_LAYOUT"); v != "" {
+        cfg.set("shelf_layout", "env", func() { cfg.ShelfLayout = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_EXPORT_DIR"); v != "" {
+        cfg.set("export_dir", "env", func() { cfg.ExportDir = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_DATA_DIR"); v != "" {
+        cfg.set("data_dir", "env", func() { cfg.DataDir = v })
+    }
+    if v := os.Getenv("BOOKTRACKER_PAGE_SIZE"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("BOOKTRACKER_PAGE_SIZE: %q is not a number", v))
+        } else {
+            cfg.set("page_size", "env", func() { cfg.PageSize = n })
+        }
+    }
+
+    // Flags, only the ones actually given
+    fs.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "backend":
+            cfg.set("backend", "flag", func() { cfg.Backend = *backend })
+        case "demo":
+            if *demo {
+                cfg.set("backend", "flag", func() { cfg.Backend = "demo" })
+            }
+        case "api-url":
+            cfg.set("api_url", "flag", func() { cfg.APIURL = *apiURL })
+        case "db":
+            cfg.set("database", "flag", func() { cfg.Database = *database })
+        case "timeout":
+            cfg.set("timeout", "flag", func() { cfg.Timeout = *timeout })
+        case "user":
+            cfg.set("username", "flag", func() { cfg.Username = *username })
+        case "theme":
+            cfg.set("theme", "flag", func() { cfg.Theme = *theme })
+        case "library":
+            cfg.set("default_library", "flag", func() { cfg.DefaultLibrary = *library })
+        case "page-size":
+            cfg.set("page_size", "flag", func() { cfg.PageSize = *pageSize })
+        case "keymap":
+            cfg.set("keymap_preset", "flag", func() { cfg.KeymapPreset = *preset })
+        case "strict-decode":
+            cfg.set("strict_decode", "flag", func() { cfg.StrictDecode = *strict })
+        case "http-cache":
+            cfg.set("http_cache", "flag", func() { cfg.HTTPCache = *httpCache })
+        case "bidi":
+            cfg.set("bidi", "flag", func() { cfg.Bidi = *bidi })
+        case "shelf-style":
+            cfg.set("shelf_style", "flag", func() { cfg.ShelfStyle = *shelfStyle })
+        case "spine-color":
+            cfg.set("spine_color", "flag", func() { cfg.SpineColor = *spineColor })
+        case "shelf-layout":
+            cfg.set("shelf_layout", "flag", func() { cfg.ShelfLayout = *shelfLayout })
+        case "export-dir":
+            cfg.set("export_dir", "flag", func() { cfg.ExportDir = *exportDir })
+        case "data-dir":
+            cfg.set("data_dir", "flag", func() { cfg.DataDir = *dataDir })
+        }
+    })
+    for action, binding := range keys {
+        cfg.Keymap[action] = binding
+    }
+
+    problems = append(problems, cfg.validate()...)
+    if len(problems) > 0 {
+        return cfg, &ValidationError{Problems: problems}
+    }
+
+    return cfg, nil
+}
+
+func (c *Config) set(key, source string, apply func()) {
+    apply()
+    c.sources[key] = source
+}
+
+// loadFile applies the settings of a config file and reports the keys it
+// doesn't know, misspelled ones would otherwise be silently ignored.
+func (c *Config) loadFile(path string, explicit bool) ([]string, error) {
+    var fc fileConfig
+    md, err := toml.DecodeFile(path, &fc)
+    if err != nil {
+        if errors.Is(err, os.ErrNotExist) && !explicit {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("config file %s: %w", path, err)
+    }
+    c.Path = path
+
+    // An unknown table is one problem, not one per key inside it
+    undecoded := map[string]bool{}
+    for _, key := range md.Undecoded() {
+        undecoded[key.String()] = true
+    }
+    var unknown []string
+    for _, key := range md.Undecoded() {
+        if len(key) > 1 && undecoded[key[:len(key)-1].String()] {
+            continue
+        }
+        unknown = append(unknown, fmt.Sprintf("config file %s: unknown key %q", path, key.String()))
+    }
+    sort.Strings(unknown)
+
+    if fc.Backend != nil {
+        c.set("backend", "file", func() { c.Backend = *fc.Backend })
+    }
+    if fc.APIURL != nil {
+        c.set("api_url", "file", func() { c.APIURL = *fc.APIURL })
+    }
+    if fc.Database != nil {
+        c.set("database", "file", func() { c.Database = *fc.Database })
+    }
+    if fc.Timeout != nil {
+        c.set("timeout", "file", func() { c.Timeout = *fc.Timeout })
+    }
+    if fc.Username != nil {
+        c.set("username", "file", func() { c.Username = *fc.Username })
+    }
+    if fc.Theme != nil {
+        c.set("theme", "file", func() { c.Theme = *fc.Theme })
+    }
+    if fc.DefaultLibrary != nil {
+        c.set("default_library", "file", func() { c.DefaultLibrary = *fc.DefaultLibrary })
+    }
+    if fc.PageSize != nil {
+        c.set("page_size", "file", func() { c.PageSize = *fc.PageSize })
+    }
+    if fc.StrictDecode != nil {
+        c.set("strict_decode", "file", func() { c.StrictDecode = *fc.StrictDecode })
+    }
+    if fc.HTTPCache != nil {
+        c.set("http_cache", "file", func() { c.HTTPCache = *fc.HTTPCache })
+    }
+    if fc.Bidi != nil {
+        c.set("bidi", "file", func() { c.Bidi = *fc.Bidi })
+    }
+    if fc.ShelfStyle != nil {
+        c.set("shelf_style", "file", func() { c.ShelfStyle = *fc.ShelfStyle })
+    }
+    if fc.SpineColor != nil {
+        c.set("spine_color", "file", func() { c.SpineColor = *fc.SpineColor })
+    }
+    if fc.ShelfLayout != nil {
+        c.set("shelf_layout", "file", func() { c.ShelfLayout = *fc.ShelfLayout })
+    }
+    if fc.ExportDir != nil {
+        c.set("export_dir", "file", func() { c.ExportDir = *fc.ExportDir })
+    }
+    if fc.DataDir != nil {
+        c.set("data_dir", "file", func() { c.DataDir = *fc.DataDir })
+    }
+    if fc.KeymapPreset != nil {
+        c.set("keymap_preset", "file", func() { c.KeymapPreset = *fc.KeymapPreset })
+    }
+    for action, binding := range fc.Keymap {
+        c.Keymap[action] = binding
+    }
+
+    return unknown, nil
+}
+
+func (c Config) validate() []string {
+    var problems []string
+
+    if !contains(Backends, c.Backend) {
+        problems = append(problems, fmt.Sprintf("backend: %q is not one of %s", c.Backend, strings.Join(Backends, ", ")))
+    }
+
+    u, err := url.Parse(c.APIURL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        problems = append(problems, fmt.Sprintf("api_url: %q must be an absolute http(s) URL", c.APIURL))
+    }
+
+    if c.Backend == "local" && c.Database == "" {
+        problems = append(problems, "database: a path is required for the local backend")
+    }
+
+    if c.Timeout <= 0 || c.Timeout > 5*time.Minute {
+        problems = append(problems, fmt.Sprintf("timeout: %s must be between 0s and 5m", c.Timeout))
+    }
+
+    if _, err := styles.Named(c.Theme); err != nil {
+        problems = append(problems, err.Error())
+    }
+
+    if c.PageSize < 1 || c.PageSize > 500 {
+        problems = append(problems, fmt.Sprintf("page_size: %d must be between 1 and 500", c.PageSize))
+    }
+
+    if !contains(ShelfStyles, c.ShelfStyle) {
+        problems = append(problems, fmt.Sprintf("shelf_style: %q is not one of %s", c.ShelfStyle, strings.Join(ShelfStyles, ", ")))
+    }
+
+    if !contains(SpineColors, c.SpineColor) {
+        problems = append(problems, fmt.Sprintf("spine_color: %q is not one of %s", c.SpineColor, strings.Join(SpineColors, ", ")))
+    }
+
+    if !contains(ShelfLayouts, c.ShelfLayout) {
+        problems = append(problems, fmt.Sprintf("shelf_layout: %q is not one of %s", c.ShelfLayout, strings.Join(ShelfLayouts, ", ")))
+    }
+
+    if c.ExportDir == "" {
+        problems = append(problems, "export_dir: a directory is required")
+    }
+
+    if c.DataDir == "" {
+        problems = append(problems, "data_dir: a directory is required")
+    }
+
+    if _, err := keymap.New(c.KeymapPreset, c.Keymap); err != nil {
+        problems = append(problems, err.Error())
+    }
+
+    return problems
+}
+
+// Print writes the effective configuration as TOML, noting where each value came from.
+func (c Config) Print(w io.Writer) {
+    if c.Path != "" {
+        fmt.Fprintf(w, "# config file: %s\n", c.Path)
+    } else {
+        fmt.Fprintf(w, "# config file: none (looked for %s)\n", DefaultPath())
+    }
+    fmt.Fprintf(w, "backend = %q  # %s\n", c.Backend, c.sources["backend"])
+    fmt.Fprintf(w, "api_url = %q  # %s\n", c.APIURL, c.sources["api_url"])
+    fmt.Fprintf(w, "database = %q  # %s\n", c.Database, c.sources["database"])
+    fmt.Fprintf(w, "timeout = %q  # %s\n", c.Timeout.String(), c.sources["timeout"])
+    fmt.Fprintf(w, "username = %q  # %s\n", c.Username, c.sources["username"])
+    fmt.Fprintf(w, "theme = %q  # %s\n", c.Theme, c.sources["theme"])
+    fmt.Fprintf(w, "default_library = %q  # %s\n", c.DefaultLibrary, c.sources["default_library"])
+    fmt.Fprintf(w, "page_size = %d  # %s\n", c.PageSize, c.sources["page_size"])
+    fmt.Fprintf(w, "keymap_preset = %q  # %s\n", c.KeymapPreset, c.sources["keymap_preset"])
+    fmt.Fprintf(w, "strict_decode = %t  # %s\n", c.StrictDecode, c.sources["strict_decode"])
+    fmt.Fprintf(w, "http_cache = %t  # %s\n", c.HTTPCache, c.sources["http_cache"])
+    fmt.Fprintf(w, "bidi = %t  # %s\n", c.Bidi, c.sources["bidi"])
+    fmt.Fprintf(w, "shelf_style = %q  # %s\n", c.ShelfStyle, c.sources["shelf_style"])
+    fmt.Fprintf(w, "spine_color = %q  # %s\n", c.SpineColor, c.sources["spine_color"])
+    fmt.Fprintf(w, "shelf_layout = %q  # %s\n", c.ShelfLayout, c.sources["shelf_layout"])
+    fmt.Fprintf(w, "export_dir = %q  # %s\n", c.ExportDir, c.sources["export_dir"])
+    fmt.Fprintf(w, "data_dir = %q  # %s\n", c.DataDir, c.sources["data_dir"])
+
+    if len(c.Keymap) > 0 {
+        fmt.Fprintln(w, "\n[keymap]")
+        actions := make([]string, 0, len(c.Keymap))
+        for action := range c.Keymap {
+            actions = append(actions, action)
+        }
+        sort.Strings(actions)
+        for _, action := range actions {
+            fmt.Fprintf(w, "%s = %q\n", action, c.Keymap[action])
+        }
+    }
+}
+
+// ValidationError collects every invalid setting so they can be fixed in one go.
+type ValidationError struct {
+    Problems []string
+}
+
+func (e *ValidationError) Error() string {
+    return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// keyFlag collects repeated --key action=keys flags.
+type keyFlag map[string]string
+
+func (k keyFlag) String() string {
+    return ""
+}
+
+func (k keyFlag) Set(value string) error {
+    action, binding, ok := strings.Cut(value, "=")
+    if !ok || action == "" {
+        return fmt.Errorf("expected action=keys, got %q", value)
+    }
+    k[action] = binding
+    return nil
+}
+
+func contains(list []string, value string) bool {
+    for _, item := range list {
+        if item == value {
+            return true
+        }
+    }
+    return false
+}
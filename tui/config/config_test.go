@@ -0,0 +1,169 @@
+package config
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// isolate keeps the developer's own config and environment out of a test.
+func isolate(t *testing.T) {
+    t.Helper()
+    t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+    for _, kv := range os.Environ() {
+        if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "BOOKTRACKER_") {
+            t.Setenv(name, "")
+        }
+    }
+}
+
+func writeConfig(t *testing.T, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "config.toml")
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestLayers(t *testing.T) {
+    isolate(t)
+    path := writeConfig(t, `
+backend = "demo"
+timeout = "30s"
+page_size = 50
+theme = "light"
+
+[keymap]
+quit = "ctrl+q"
+`)
+    t.Setenv("BOOKTRACKER_PAGE_SIZE", "40")
+    t.Setenv("BOOKTRACKER_THEME", "dark")
+
+    cfg, err := Load([]string{"--config", path, "--theme", "light", "books", "list"})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        key, source string
+        got, want   interface{}
+    }{
+        {"backend", "file", cfg.Backend, "demo"},
+        {"timeout", "file", cfg.Timeout, 30 * time.Second},
+        {"page_size", "env", cfg.PageSize, 40},
+        {"theme", "flag", cfg.Theme, "light"},
+        {"api_url", "default", cfg.APIURL, "http://localhost:8000"},
+    }
+    for _, tt := range tests {
+        if tt.got != tt.want || cfg.sources[tt.key] != tt.source {
+            t.Errorf("%s = %v from %s, want %v from %s", tt.key, tt.got, cfg.sources[tt.key], tt.want, tt.source)
+        }
+    }
+    if cfg.Keymap["quit"] != "ctrl+q" {
+        t.Errorf("keymap quit = %q", cfg.Keymap["quit"])
+    }
+    if cfg.Path != path || strings.Join(cfg.Args, " ") != "books list" {
+        t.Errorf("path %q, args %q", cfg.Path, cfg.Args)
+    }
+}
+
+func TestDemoFlag(t *testing.T) {
+    isolate(t)
+    t.Setenv("BOOKTRACKER_BACKEND", "local")
+    cfg, err := Load([]string{"--demo"})
+    if err != nil || cfg.Backend != "demo" {
+        t.Fatalf("backend %q, %v", cfg.Backend, err)
+    }
+}
+
+func TestMissingFile(t *testing.T) {
+    isolate(t)
+    // The default path may be missing, a path given explicitly may not
+    if _, err := Load(nil); err != nil {
+        t.Fatalf("without a config file: %v", err)
+    }
+    missing := filepath.Join(t.TempDir(), "nope.toml")
+    if _, err := Load([]string{"--config", missing}); err == nil || !strings.Contains(err.Error(), missing) {
+        t.Fatalf("explicit missing file: %v", err)
+    }
+}
+
+func TestProblems(t *testing.T) {
+    tests := []struct {
+        name    string
+        file    string
+        env     map[string]string
+        args    []string
+        problem []string
+    }{
+        {
+            name:    "unknown keys",
+            file:    "backend = \"demo\"\nthem = \"light\"\n[server]\nport = 1\n",
+            problem: []string{`unknown key "server"`, `unknown key "them"`},
+        },
+        {
+            name:    "bad values",
+            file:    "backend = \"ftp\"\npage_size = 0\napi_url = \"localhost\"\n",
+            problem: []string{`backend: "ftp"`, "page_size: 0", `api_url: "localhost"`},
+        },
+        {
+            name:    "bad env",
+            env:     map[string]string{"BOOKTRACKER_TIMEOUT": "soon", "BOOKTRACKER_BIDI": "maybe"},
+            problem: []string{`BOOKTRACKER_TIMEOUT: "soon"`, `BOOKTRACKER_BIDI: "maybe"`},
+        },
+        {
+            name:    "bad flags",
+            args:    []string{"--shelf-style", "stack", "--timeout", "1h"},
+            problem: []string{`shelf_style: "stack"`, "timeout: 1h0m0s"},
+        },
+        {
+            name:    "bad shelf settings",
+            file:    "spine_color = \"size\"\nshelf_layout = \"fold\"\n",
+            problem: []string{`spine_color: "size" is not one of shelf, language, rating`, `shelf_layout: "fold" is not one of scroll, wrap`},
+        },
+        {
+            name:    "local needs a database",
+            args:    []string{"--backend", "local", "--db", ""},
+            problem: []string{"database: a path is required"},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            isolate(t)
+            for k, v := range tt.env {
+                t.Setenv(k, v)
+            }
+            args := tt.args
+            path := ""
+            if tt.file != "" {
+                path = writeConfig(t, tt.file)
+                args = append([]string{"--config", path}, args...)
+            }
+
+            _, err := Load(args)
+            var verr *ValidationError
+            if !errors.As(err, &verr) {
+                t.Fatalf("Load = %v, want a ValidationError", err)
+            }
+            if len(verr.Problems) != len(tt.problem) {
+                t.Fatalf("problems %q, want %d", verr.Problems, len(tt.problem))
+            }
+            for _, want := range tt.problem {
+                if !strings.Contains(err.Error(), want) {
+                    t.Errorf("%q not in %v", want, err)
+                }
+            }
+            if path != "" && strings.Contains(tt.name, "unknown") {
+                for _, p := range verr.Problems {
+                    if !strings.HasPrefix(p, "config file "+path+":") {
+                        t.Errorf("problem %q doesn't name the file", p)
+                    }
+                }
+            }
+        })
+    }
+}
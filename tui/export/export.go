@@ -0,0 +1,252 @@
+package export
+
+import (
+    "fmt"
+    "io"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "tui/api"
+    "tui/types"
+)
+
+// Formats are the export formats, in the order the TUI offers them.
+var Formats = []string{"json", "csv", "md"}
+
+// Snapshot is everything a user has in the tracker, as of ExportedAt.
+type Snapshot struct {
+    ExportedAt      time.Time        `json:"exported_at"`
+    User            User             `json:"user"`
+    Libraries       []Library        `json:"libraries"`
+    Reviews         []Review         `json:"reviews"`
+    Sessions        []Session        `json:"sessions"`
+    Recommendations []Recommendation `json:"recommendations"`
+}
+
+type User struct {
+    Username    string   `json:"username"`
+    DisplayName string   `json:"display_name"`
+    JoinedDate  string   `json:"joined_date"`
+    Friends     []string `json:"friends"`
+}
+
+type Book struct {
+    ID        int     `json:"id"`
+    Name      string  `json:"name"`
+    Author    string  `json:"author"`
+    Year      int     `json:"year"`
+    Pages     int     `json:"pages"`
+    Rating    float64 `json:"rating"`
+    Language  string  `json:"language"`
+    Publisher string  `json:"publisher"`
+}
+
+type Library struct {
+    ID      int               `json:"id"`
+    Name    string            `json:"name"`
+    Shelves map[string][]Book `json:"shelves"`
+}
+
+// Review is one of the user's own reviews.
+type Review struct {
+    Book   Book   `json:"book"`
+    Rating int    `json:"rating"`
+    Text   string `json:"text"`
+    Likes  int    `json:"likes"`
+}
+
+type Session struct {
+    Book        Book   `json:"book"`
+    CurrentPage int    `json:"current_page"`
+    StartedAt   string `json:"started_at"`
+    LastReadAt  string `json:"last_read_at,omitempty"`
+}
+
+type Recommendation struct {
+    From    string `json:"from"`
+    Book    string `json:"book"`
+    Message string `json:"message"`
+    Date    string `json:"date"`
+}
+
+// shelfOrder is how shelves are listed, the backend has no order of its own.
+var shelfOrder = []string{"currently_reading", "to_read", "read"}
+
+// Collect gathers a snapshot through the regular API calls. Reviews are
+// looked up for the books in the user's libraries and sessions, that is
+// where the user can have written them from the TUI.
+func Collect(service api.Service, username string) (Snapshot, error) {
+    snap := Snapshot{ExportedAt: time.Now()}
+
+    user, err := service.GetUser(username)
+    if err != nil {
+        return snap, fmt.Errorf("export: user: %w", err)
+    }
+    snap.User = User{Username: user.Username, DisplayName: user.DisplayName, JoinedDate: user.JoinedDate, Friends: user.Friends}
+
+    catalog, err := service.ListBooks()
+    if err != nil {
+        return snap, fmt.Errorf("export: books: %w", err)
+    }
+    books := make(map[int]Book, len(catalog))
+    for _, b := range catalog {
+        books[b.ID] = toBook(b)
+    }
+    book := func(id int) Book {
+        if b, ok := books[id]; ok {
+            return b
+        }
+        return Book{ID: id, Name: fmt.Sprintf("Book %d", id)}
+    }
+
+    libraries, err := service.GetUserLibraries(username)
+    if err != nil {
+        return snap, fmt.Errorf("export: libraries: %w", err)
+    }
+    var owned []int
+    seen := map[int]bool{}
+    for _, lib := range libraries {
+        out := Library{ID: lib.ID, Name: lib.Name, Shelves: map[string][]Book{}}
+        for shelf, ids := range lib.Books {
+            out.Shelves[shelf] = []Book{}
+            for _, id := range ids {
+                out.Shelves[shelf] = append(out.Shelves[shelf], book(id))
+                if !seen[id] {
+                    seen[id] = true
+                    owned = append(owned, id)
+                }
+            }
+        }
+        snap.Libraries = append(snap.Libraries, out)
+    }
+
+    rows, err := service.GetActiveReading()
+    if err != nil {
+        return snap, fmt.Errorf("export: reading: %w", err)
+    }
+    for _, row := range rows {
+        s := Session{
+            Book:        book(number(row["book_id"])),
+            CurrentPage: number(row["current_page"]),
+        }
+        s.StartedAt, _ = row["started_at"].(string)
+        s.LastReadAt, _ = row["last_read_at"].(string)
+        snap.Sessions = append(snap.Sessions, s)
+        if !seen[s.Book.ID] {
+            seen[s.Book.ID] = true
+            owned = append(owned, s.Book.ID)
+        }
+    }
+
+    sort.Ints(owned)
+    for _, id := range owned {
+        details, err := service.GetBookDetails(id)
+        if err != nil {
+            return snap, fmt.Errorf("export: reviews of book %d: %w", id, err)
+        }
+        for _, r := range details.Reviews {
+            if r.User == username {
+                snap.Reviews = append(snap.Reviews, Review{Book: book(id), Rating: r.Rating, Text: r.Text, Likes: r.Likes})
+            }
+        }
+    }
+
+    recommendations, err := service.GetRecommendations()
+    if err != nil {
+        return snap, fmt.Errorf("export: recommendations: %w", err)
+    }
+    for _, r := range recommendations {
+        snap.Recommendations = append(snap.Recommendations, Recommendation(r))
+    }
+    return snap, nil
+}
+
+// Write writes the snapshot in one of Formats.
+func Write(w io.Writer, format string, snap Snapshot) error {
+    switch format {
+    case "json":
+        return WriteJSON(w, snap)
+    case "csv":
+        return WriteCSV(w, snap)
+    case "md":
+        return WriteMarkdown(w, snap)
+    }
+    return fmt.Errorf("unknown export format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// FormatOf guesses the format from a file name, "" when it can't tell.
+func FormatOf(path string) string {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".json":
+        return "json"
+    case ".csv":
+        return "csv"
+    case ".md", ".markdown":
+        return "md"
+    }
+    return ""
+}
+
+// FileName is the default name of an export, e.g. "booktracker-alice-2024-03-02.md".
+func FileName(snap Snapshot, format string) string {
+    return BaseName(snap) + "." + format
+}
+
+// BaseName is FileName without the extension.
+func BaseName(snap Snapshot) string {
+    return fmt.Sprintf("booktracker-%s-%s", snap.User.Username, snap.ExportedAt.Format("2006-01-02"))
+}
+
+func toBook(b types.Book) Book {
+    return Book{
+        ID:        b.ID,
+        Name:      b.Name,
+        Author:    b.Author,
+        Year:      b.Year,
+        Pages:     b.Pages,
+        Rating:    b.Rating,
+        Language:  b.Language,
+        Publisher: b.Publisher,
+    }
+}
+
+// number reads a JSON number, float64 from the HTTP client and int from
+// the in-process backends.
+func number(v interface{}) int {
+    switch n := v.(type) {
+    case int:
+        return n
+    case float64:
+        return int(n)
+    }
+    return 0
+}
+
+// shelves lists a library's shelves in shelfOrder, unknown ones after.
+func shelves(lib Library) []string {
+    var names []string
+    for _, name := range shelfOrder {
+        if _, ok := lib.Shelves[name]; ok {
+            names = append(names, name)
+        }
+    }
+    var rest []string
+    for name := range lib.Shelves {
+        if !contains(shelfOrder, name) {
+            rest = append(rest, name)
+        }
+    }
+    sort.Strings(rest)
+    return append(names, rest...)
+}
+
+func contains(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
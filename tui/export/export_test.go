@@ -0,0 +1,173 @@
+package export
+
+import (
+    "bytes"
+    "encoding/json"
+    "strings"
+    "testing"
+    "time"
+
+    "tui/api"
+    "tui/importer"
+)
+
+var (
+    dune  = Book{ID: 1, Name: "Dune", Author: "Frank Herbert", Year: 1965, Pages: 412, Rating: 4.25}
+    emma  = Book{ID: 2, Name: "Emma", Author: "Jane Austen", Year: 1815, Pages: 474}
+    nsync = Book{ID: 3, Name: "*NSYNC [live]", Author: "Anonymous"}
+)
+
+func snapshot() Snapshot {
+    return Snapshot{
+        ExportedAt: time.Date(2024, 3, 2, 10, 15, 0, 0, time.UTC),
+        User:       User{Username: "alice", DisplayName: "Alice"},
+        Libraries: []Library{
+            {ID: 1, Name: "My Library", Shelves: map[string][]Book{
+                "read":    {dune},
+                "to_read": {emma},
+                "owned":   {nsync},
+            }},
+            {ID: 2, Name: "Holiday Reads", Shelves: map[string][]Book{"to_read": {dune}}},
+        },
+        Reviews:         []Review{{Book: dune, Rating: 5, Text: "Spice.\nSand."}},
+        Sessions:        []Session{{Book: emma, CurrentPage: 237, StartedAt: "2024-02-28T21:00:00"}},
+        Recommendations: []Recommendation{{From: "ben", Book: "Emma", Message: "Witty", Date: "2024-01-05T09:00:00"}},
+    }
+}
+
+func TestWriteJSON(t *testing.T) {
+    var buf bytes.Buffer
+    if err := Write(&buf, "json", snapshot()); err != nil {
+        t.Fatal(err)
+    }
+    var back Snapshot
+    if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+        t.Fatal(err)
+    }
+    if back.User.Username != "alice" || len(back.Libraries) != 2 || back.Libraries[0].Shelves["read"][0] != dune ||
+        back.Reviews[0].Text != "Spice.\nSand." || back.Sessions[0].CurrentPage != 237 || !back.ExportedAt.Equal(snapshot().ExportedAt) {
+        t.Fatalf("round trip lost data: %+v", back)
+    }
+}
+
+// The CSV is a Goodreads export, the importer reads it back.
+func TestWriteCSV(t *testing.T) {
+    var buf bytes.Buffer
+    if err := Write(&buf, "csv", snapshot()); err != nil {
+        t.Fatal(err)
+    }
+    rows, err := importer.ParseGoodreads(&buf)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        title, shelf, tags, review string
+        rating                     int
+    }{
+        {"Emma", "to-read", "my-library", "", 0},
+        // Shelved in both libraries, the first one gives the shelf
+        {"Dune", "read", "my-library,holiday-reads", "Spice.\nSand.", 5},
+        {"*NSYNC [live]", "owned", "my-library", "", 0},
+    }
+    if len(rows) != len(tests) {
+        t.Fatalf("%d rows, want %d: %+v", len(rows), len(tests), rows)
+    }
+    for i, tt := range tests {
+        r := rows[i]
+        if r.Title != tt.title || r.Shelf != tt.shelf || strings.Join(r.Tags, ",") != tt.tags || r.Review != tt.review || r.Rating != tt.rating {
+            t.Errorf("row %d = %+v, want %+v", i, r, tt)
+        }
+    }
+}
+
+func TestWriteMarkdown(t *testing.T) {
+    var buf bytes.Buffer
+    if err := Write(&buf, "md", snapshot()); err != nil {
+        t.Fatal(err)
+    }
+    md := buf.String()
+    for _, want := range []string{
+        "# Reading log of Alice\n",
+        "_Exported on 2 March 2024._",
+        "## Reading now\n\n- **Emma** by Jane Austen — page 237 of 474 (50%), since 2024-02-28\n",
+        "## My Library\n\n### To read (1)\n",
+        "### Read (1)\n\n- **Dune** by Frank Herbert (1965) ★★★★★\n  > Spice.\n  > Sand.\n",
+        "### owned (1)\n\n- **\\*NSYNC \\[live\\]** by Anonymous\n",
+        "## Holiday Reads\n",
+        "- **Emma**, from ben on 2024-01-05: “Witty”\n",
+    } {
+        if !strings.Contains(md, want) {
+            t.Errorf("%q not in\n%s", want, md)
+        }
+    }
+    // Shelves in reading order, unknown ones last
+    if strings.Index(md, "### To read") > strings.Index(md, "### Read") || strings.Index(md, "### Read") > strings.Index(md, "### owned") {
+        t.Errorf("shelves out of order:\n%s", md)
+    }
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+    if err := Write(&bytes.Buffer{}, "pdf", snapshot()); err == nil || !strings.Contains(err.Error(), "json, csv, md") {
+        t.Fatalf("Write pdf = %v", err)
+    }
+}
+
+func TestFormatHelpers(t *testing.T) {
+    formats := []struct{ path, want string }{
+        {"out.json", "json"}, {"OUT.CSV", "csv"}, {"log.md", "md"}, {"log.markdown", "md"}, {"log.txt", ""}, {"log", ""},
+    }
+    for _, tt := range formats {
+        if got := FormatOf(tt.path); got != tt.want {
+            t.Errorf("FormatOf(%q) = %q, want %q", tt.path, got, tt.want)
+        }
+    }
+    if got := FileName(snapshot(), "csv"); got != "booktracker-alice-2024-03-02.csv" {
+        t.Errorf("FileName = %q", got)
+    }
+
+    helpers := []struct {
+        name    string
+        f       func(string) string
+        in, out string
+    }{
+        {"authorLastFirst", authorLastFirst, "Frank Herbert", "Herbert, Frank"},
+        {"authorLastFirst", authorLastFirst, "J. R. R. Tolkien", "Tolkien, J. R. R."},
+        {"authorLastFirst", authorLastFirst, "Homer", "Homer"},
+        {"slug", slug, "  Holiday  Reads ", "holiday-reads"},
+        {"day", day, "2024-03-02T10:15:00", "2024-03-02"},
+        {"day", day, "2024-03-02", "2024-03-02"},
+        {"mdEscape", mdEscape, "*NSYNC_[1]`x`", "\\*NSYNC\\_\\[1\\]\\`x\\`"},
+        {"shelfTitle", shelfTitle, "currently_reading", "Currently reading"},
+        {"shelfTitle", shelfTitle, "owned", "owned"},
+    }
+    for _, tt := range helpers {
+        if got := tt.f(tt.in); got != tt.out {
+            t.Errorf("%s(%q) = %q, want %q", tt.name, tt.in, got, tt.out)
+        }
+    }
+
+    for rating, want := range map[int]string{-1: "☆☆☆☆☆", 0: "☆☆☆☆☆", 3: "★★★☆☆", 7: "★★★★★"} {
+        if got := stars(rating); got != want {
+            t.Errorf("stars(%d) = %q, want %q", rating, got, want)
+        }
+    }
+}
+
+func TestCollect(t *testing.T) {
+    fake := api.NewFake()
+    if _, err := fake.Login(api.DemoUsername, api.DemoPassword); err != nil {
+        t.Fatal(err)
+    }
+    snap, err := Collect(fake, api.DemoUsername)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if snap.User.Username != api.DemoUsername || len(snap.Libraries) == 0 || len(snap.Sessions) == 0 {
+        t.Fatalf("snapshot %+v", snap)
+    }
+    // Only the user's own reviews, not the other readers' of the same book
+    if len(snap.Reviews) != 1 || snap.Reviews[0].Book.ID != 7 || snap.Reviews[0].Text != "Quiet and clever." {
+        t.Errorf("reviews %+v", snap.Reviews)
+    }
+}
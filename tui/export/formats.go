@@ -0,0 +1,217 @@
+package export
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// WriteJSON writes the whole snapshot, nothing is left out.
+func WriteJSON(w io.Writer, snap Snapshot) error {
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(snap)
+}
+
+// goodreadsHeader is the header of a Goodreads library export, so the file
+// loads back into Goodreads, into this app's importer, or into anything
+// else that reads those.
+var goodreadsHeader = []string{
+    "Book Id", "Title", "Author", "Author l-f", "Additional Authors", "ISBN", "ISBN13",
+    "My Rating", "Average Rating", "Publisher", "Binding", "Number of Pages",
+    "Year Published", "Original Publication Year", "Date Read", "Date Added",
+    "Bookshelves", "Bookshelves with positions", "Exclusive Shelf", "My Review",
+    "Spoiler", "Private Notes", "Read Count", "Owned Copies",
+}
+
+// goodreadsShelves are our shelves under their Goodreads names.
+var goodreadsShelves = map[string]string{
+    "to_read":           "to-read",
+    "currently_reading": "currently-reading",
+    "read":              "read",
+}
+
+// WriteCSV writes one row per book. Goodreads has a single library, so a
+// book takes its shelf from the first library it is in, and the names of
+// all its libraries become its other shelves.
+func WriteCSV(w io.Writer, snap Snapshot) error {
+    reviews := map[int]Review{}
+    for _, r := range snap.Reviews {
+        reviews[r.Book.ID] = r
+    }
+
+    type row struct {
+        book      Book
+        shelf     string
+        libraries []string
+    }
+    var rows []*row
+    byID := map[int]*row{}
+    for _, lib := range snap.Libraries {
+        for _, shelf := range shelves(lib) {
+            for _, book := range lib.Shelves[shelf] {
+                r, ok := byID[book.ID]
+                if !ok {
+                    r = &row{book: book, shelf: shelf}
+                    byID[book.ID] = r
+                    rows = append(rows, r)
+                }
+                r.libraries = append(r.libraries, slug(lib.Name))
+            }
+        }
+    }
+
+    out := csv.NewWriter(w)
+    if err := out.Write(goodreadsHeader); err != nil {
+        return err
+    }
+    for _, r := range rows {
+        shelf, ok := goodreadsShelves[r.shelf]
+        if !ok {
+            shelf = r.shelf
+        }
+        rating, review := "0", ""
+        if rv, ok := reviews[r.book.ID]; ok {
+            rating, review = strconv.Itoa(rv.Rating), rv.Text
+        }
+        readCount := "0"
+        if r.shelf == "read" {
+            readCount = "1"
+        }
+        year := ""
+        if r.book.Year > 0 {
+            year = strconv.Itoa(r.book.Year)
+        }
+
+        record := []string{
+            strconv.Itoa(r.book.ID), r.book.Name, r.book.Author, authorLastFirst(r.book.Author), "", "", "",
+            rating, strconv.FormatFloat(r.book.Rating, 'f', 2, 64), r.book.Publisher, "", strconv.Itoa(r.book.Pages),
+            year, year, "", "",
+            strings.Join(r.libraries, ", "), "", shelf, review,
+            "", "", readCount, "0",
+        }
+        if err := out.Write(record); err != nil {
+            return err
+        }
+    }
+    out.Flush()
+    return out.Error()
+}
+
+// WriteMarkdown writes a reading log meant for people: what is being read,
+// each library shelf by shelf with ratings and reviews, and what friends
+// recommended.
+func WriteMarkdown(w io.Writer, snap Snapshot) error {
+    var b strings.Builder
+    name := snap.User.DisplayName
+    if name == "" {
+        name = snap.User.Username
+    }
+    fmt.Fprintf(&b, "# Reading log of %s\n\n", name)
+    fmt.Fprintf(&b, "_Exported on %s._\n", snap.ExportedAt.Format("2 January 2006"))
+
+    reviews := map[int]Review{}
+    for _, r := range snap.Reviews {
+        reviews[r.Book.ID] = r
+    }
+
+    if len(snap.Sessions) > 0 {
+        b.WriteString("\n## Reading now\n\n")
+        for _, s := range snap.Sessions {
+            fmt.Fprintf(&b, "- **%s** by %s — page %d", mdEscape(s.Book.Name), mdEscape(s.Book.Author), s.CurrentPage)
+            if s.Book.Pages > 0 {
+                fmt.Fprintf(&b, " of %d (%d%%)", s.Book.Pages, min(s.CurrentPage*100/s.Book.Pages, 100))
+            }
+            if day := day(s.StartedAt); day != "" {
+                fmt.Fprintf(&b, ", since %s", day)
+            }
+            b.WriteString("\n")
+        }
+    }
+
+    for _, lib := range snap.Libraries {
+        fmt.Fprintf(&b, "\n## %s\n", mdEscape(lib.Name))
+        for _, shelf := range shelves(lib) {
+            books := lib.Shelves[shelf]
+            if len(books) == 0 {
+                continue
+            }
+            fmt.Fprintf(&b, "\n### %s (%d)\n\n", shelfTitle(shelf), len(books))
+            for _, book := range books {
+                fmt.Fprintf(&b, "- **%s** by %s", mdEscape(book.Name), mdEscape(book.Author))
+                if book.Year > 0 {
+                    fmt.Fprintf(&b, " (%d)", book.Year)
+                }
+                if r, ok := reviews[book.ID]; ok {
+                    fmt.Fprintf(&b, " %s", stars(r.Rating))
+                    if r.Text != "" {
+                        fmt.Fprintf(&b, "\n  > %s", strings.ReplaceAll(strings.TrimSpace(r.Text), "\n", "\n  > "))
+                    }
+                }
+                b.WriteString("\n")
+            }
+        }
+    }
+
+    if len(snap.Recommendations) > 0 {
+        b.WriteString("\n## Recommended by friends\n\n")
+        for _, r := range snap.Recommendations {
+            fmt.Fprintf(&b, "- **%s**, from %s", mdEscape(r.Book), mdEscape(r.From))
+            if day := day(r.Date); day != "" {
+                fmt.Fprintf(&b, " on %s", day)
+            }
+            if r.Message != "" {
+                fmt.Fprintf(&b, ": “%s”", mdEscape(r.Message))
+            }
+            b.WriteString("\n")
+        }
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// authorLastFirst is Goodreads' "Author l-f" column, "Finch, Eleanor".
+func authorLastFirst(author string) string {
+    words := strings.Fields(author)
+    if len(words) < 2 {
+        return author
+    }
+    return words[len(words)-1] + ", " + strings.Join(words[:len(words)-1], " ")
+}
+
+// slug turns a library name into a Goodreads shelf name, "My Library" into "my-library".
+func slug(name string) string {
+    return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+func shelfTitle(shelf string) string {
+    switch shelf {
+    case "to_read":
+        return "To read"
+    case "currently_reading":
+        return "Currently reading"
+    case "read":
+        return "Read"
+    }
+    return shelf
+}
+
+func stars(rating int) string {
+    rating = min(max(rating, 0), 5)
+    return strings.Repeat("★", rating) + strings.Repeat("☆", 5-rating)
+}
+
+// day keeps the date of a "2006-01-02T15:04:05" timestamp.
+func day(ts string) string {
+    d, _, _ := strings.Cut(ts, "T")
+    return d
+}
+
+// mdEscape keeps titles like "*NSYNC" from turning into emphasis.
+func mdEscape(s string) string {
+    return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`).Replace(s)
+}
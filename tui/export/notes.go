@@ -0,0 +1,134 @@
+package export
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "tui/store"
+    "tui/types"
+)
+
+// NotesFolder is the folder the notes go to inside the export directory,
+// ready to be dropped into an Obsidian vault.
+const NotesFolder = "Book notes"
+
+// WriteNotes writes the notes of one book as an Obsidian note: properties
+// for the book, then quotes, highlights and notes by page.
+func WriteNotes(w io.Writer, book types.Book, notes []store.Highlight) error {
+    var b strings.Builder
+    b.WriteString("---\n")
+    fmt.Fprintf(&b, "title: %s\n", yamlString(book.Name))
+    fmt.Fprintf(&b, "author: %s\n", yamlString(book.Author))
+    if book.Year > 0 {
+        fmt.Fprintf(&b, "year: %d\n", book.Year)
+    }
+    if book.Pages > 0 {
+        fmt.Fprintf(&b, "pages: %d\n", book.Pages)
+    }
+    b.WriteString("tags:\n  - book\n")
+    b.WriteString("---\n\n")
+    fmt.Fprintf(&b, "# %s\n", mdEscape(book.Name))
+
+    for _, n := range notes {
+        b.WriteString("\n")
+        text := strings.TrimSpace(n.Text)
+        switch n.Kind {
+        case store.KindBookmark:
+            fmt.Fprintf(&b, "- 🔖 %s", where(n))
+            if text != "" {
+                fmt.Fprintf(&b, ": %s", text)
+            }
+            b.WriteString("\n")
+        case store.KindNote:
+            fmt.Fprintf(&b, "%s\n— %s\n", text, where(n))
+        default:
+            fmt.Fprintf(&b, "> %s\n> — %s\n", strings.ReplaceAll(text, "\n", "\n> "), where(n))
+        }
+    }
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// WriteVault writes one note per book with notes into dir, named after the
+// book, and reports how many it wrote. Files of earlier exports are
+// overwritten, the store has everything they had.
+func WriteVault(dir string, catalog []types.Book, notes []store.Highlight) (int, error) {
+    books := make(map[int]types.Book, len(catalog))
+    for _, b := range catalog {
+        books[b.ID] = b
+    }
+    var order []int
+    byBook := map[int][]store.Highlight{}
+    for _, n := range notes {
+        if _, ok := byBook[n.BookID]; !ok {
+            order = append(order, n.BookID)
+        }
+        byBook[n.BookID] = append(byBook[n.BookID], n)
+    }
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return 0, err
+    }
+
+    for _, id := range order {
+        book, ok := books[id]
+        if !ok {
+            book = types.Book{ID: id, Name: fmt.Sprintf("Book %d", id)}
+        }
+        f, err := os.Create(filepath.Join(dir, NoteFileName(book)))
+        if err != nil {
+            return 0, err
+        }
+        err = WriteNotes(f, book, sortByPage(byBook[id]))
+        if cerr := f.Close(); err == nil {
+            err = cerr
+        }
+        if err != nil {
+            return 0, err
+        }
+    }
+    return len(order), nil
+}
+
+// NoteFileName is the book's title without the characters Obsidian
+// refuses in a note name.
+func NoteFileName(book types.Book) string {
+    name := strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`\/:*?"<>|#^[]`, r) {
+            return ' '
+        }
+        return r
+    }, book.Name)
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        name = fmt.Sprintf("Book %d", book.ID)
+    }
+    return name + ".md"
+}
+
+// where is the page of a note, or its location when the book has no pages.
+func where(n store.Highlight) string {
+    switch {
+    case n.Page > 0:
+        return fmt.Sprintf("p. %d", n.Page)
+    case n.Location != "":
+        return "loc. " + n.Location
+    }
+    return "no page"
+}
+
+func sortByPage(notes []store.Highlight) []store.Highlight {
+    out := append([]store.Highlight(nil), notes...)
+    // Stable, notes on one page stay in the order they were written
+    sort.SliceStable(out, func(i, j int) bool { return out[i].Page < out[j].Page })
+    return out
+}
+
+// yamlString quotes a property value, titles have colons often enough.
+func yamlString(s string) string {
+    return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "tui/store"
+    "tui/types"
+)
+
+func TestWriteNotes(t *testing.T) {
+    book := types.Book{ID: 1, Name: `Dune: "Deluxe" Edition`, Author: "Frank Herbert", Year: 1965, Pages: 412}
+    notes := []store.Highlight{
+        {BookID: 1, Kind: store.KindBookmark, Page: 3},
+        {BookID: 1, Kind: store.KindHighlight, Text: "I must not fear.\nFear is the mind-killer.", Page: 8},
+        {BookID: 1, Kind: store.KindNote, Text: "  The litany again  ", Location: "183"},
+        {BookID: 1, Kind: store.KindQuote, Text: "Deep in the human unconscious", Location: ""},
+        {BookID: 1, Kind: store.KindBookmark, Text: "Arrakis", Page: 40},
+    }
+
+    var buf bytes.Buffer
+    if err := WriteNotes(&buf, book, notes); err != nil {
+        t.Fatal(err)
+    }
+    want := `---
+title: "Dune: \"Deluxe\" Edition"
+author: "Frank Herbert"
+year: 1965
+pages: 412
+tags:
+  - book
+---
+
+# Dune: "Deluxe" Edition
+
+- 🔖 p. 3
+
+> I must not fear.
+> Fear is the mind-killer.
+> — p. 8
+
+The litany again
+— loc. 183
+
+> Deep in the human unconscious
+> — no page
+
+- 🔖 p. 40: Arrakis
+`
+    if got := buf.String(); got != want {
+        t.Errorf("WriteNotes =\n%s\nwant\n%s", got, want)
+    }
+}
+
+func TestNoteFileName(t *testing.T) {
+    tests := []struct {
+        book types.Book
+        want string
+    }{
+        {types.Book{Name: "Dune"}, "Dune.md"},
+        {types.Book{Name: "Dune: Messiah"}, "Dune Messiah.md"},
+        {types.Book{Name: `AC/DC? "Live" #1 [b]`}, "AC DC Live 1 b.md"},
+        {types.Book{ID: 9, Name: "???"}, "Book 9.md"},
+        {types.Book{Name: "Cien años de soledad"}, "Cien años de soledad.md"},
+    }
+    for _, tt := range tests {
+        if got := NoteFileName(tt.book); got != tt.want {
+            t.Errorf("NoteFileName(%q) = %q, want %q", tt.book.Name, got, tt.want)
+        }
+    }
+}
+
+func TestWriteVault(t *testing.T) {
+    dir := filepath.Join(t.TempDir(), NotesFolder)
+    catalog := []types.Book{{ID: 1, Name: "Dune", Author: "Frank Herbert"}}
+    notes := []store.Highlight{
+        {BookID: 1, Kind: store.KindNote, Text: "later", Page: 90},
+        {BookID: 2, Kind: store.KindNote, Text: "not in the catalog", Page: 1},
+        {BookID: 1, Kind: store.KindNote, Text: "earlier", Page: 10},
+    }
+
+    n, err := WriteVault(dir, catalog, notes)
+    if err != nil || n != 2 {
+        t.Fatalf("WriteVault = %d, %v; want 2 notes", n, err)
+    }
+    dune, err := os.ReadFile(filepath.Join(dir, "Dune.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Index(string(dune), "earlier") > strings.Index(string(dune), "later") {
+        t.Errorf("notes not by page:\n%s", dune)
+    }
+    if _, err := os.Stat(filepath.Join(dir, "Book 2.md")); err != nil {
+        t.Errorf("book missing from the catalog: %v", err)
+    }
+
+    // A second export overwrites the notes rather than adding to them
+    if _, err := WriteVault(dir, catalog, notes[:1]); err != nil {
+        t.Fatal(err)
+    }
+    dune, _ = os.ReadFile(filepath.Join(dir, "Dune.md"))
+    if strings.Contains(string(dune), "earlier") {
+        t.Errorf("old notes kept:\n%s", dune)
+    }
+}
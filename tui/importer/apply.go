@@ -0,0 +1,138 @@
+package importer
+
+import (
+    "fmt"
+
+    "tui/types"
+)
+
+// Target is the part of the backend an import reads and writes. The reads
+// find what a previous import of the same file already did.
+type Target interface {
+    GetCurrentUser() (types.User, error)
+    GetUserLibraries(username string) ([]types.Library, error)
+    GetBookDetails(bookID int) (types.BookData, error)
+    AddBookToLibrary(libraryID, bookID int, shelf string) error
+    MoveBook(libraryID, bookID int, shelf string) error
+    AddReview(bookID int, text string, rating int) error
+}
+
+// Skipped is a row that was not imported, and why.
+type Skipped struct {
+    Row    Row
+    Reason string
+}
+
+// Summary is the outcome of an import.
+type Summary struct {
+    Imported  int // books put on a shelf
+    Reviewed  int // ratings, with their review text, posted
+    Unchanged int // already on that shelf and reviewed, left alone
+    Skipped   []Skipped
+}
+
+// Apply shelves every matched item in the library and posts its rating.
+// Items that can't be imported are reported, they don't stop the rest.
+// Reviews need a rating here, a review left unrated is not posted.
+// Importing the same file again leaves alone what is already there: the
+// backend allows one review per user and book.
+func Apply(target Target, libraryID int, items []Item) Summary {
+    var summary Summary
+    user, err := target.GetCurrentUser()
+    if err != nil {
+        for _, item := range items {
+            summary.Skipped = append(summary.Skipped, Skipped{Row: item.Row, Reason: err.Error()})
+        }
+        return summary
+    }
+    shelfOf := shelves(target, user.Username, libraryID)
+
+    for _, item := range items {
+        if reason := skipReason(item); reason != "" {
+            summary.Skipped = append(summary.Skipped, Skipped{Row: item.Row, Reason: reason})
+            continue
+        }
+
+        shelved := shelfOf[item.Book.ID] == item.Shelf
+        rated := item.Row.Rating >= 1 && item.Row.Rating <= 5
+        reviewed := false
+        if rated {
+            details, err := target.GetBookDetails(item.Book.ID)
+            if err != nil {
+                summary.Skipped = append(summary.Skipped, Skipped{Row: item.Row, Reason: err.Error()})
+                continue
+            }
+            reviewed = hasReview(details.Reviews, user.Username)
+        }
+        if shelved && (!rated || reviewed) {
+            summary.Unchanged++
+            continue
+        }
+
+        if !shelved {
+            place := target.AddBookToLibrary
+            if shelfOf[item.Book.ID] != "" {
+                place = target.MoveBook // on another shelf, don't add it twice
+            }
+            if err := place(libraryID, item.Book.ID, item.Shelf); err != nil {
+                summary.Skipped = append(summary.Skipped, Skipped{Row: item.Row, Reason: err.Error()})
+                continue
+            }
+            shelfOf[item.Book.ID] = item.Shelf
+            summary.Imported++
+        }
+
+        if rated && !reviewed {
+            if err := target.AddReview(item.Book.ID, item.Row.Review, item.Row.Rating); err != nil {
+                summary.Skipped = append(summary.Skipped, Skipped{Row: item.Row, Reason: "shelved, but the rating failed: " + err.Error()})
+                continue
+            }
+            summary.Reviewed++
+        }
+    }
+    return summary
+}
+
+// shelves maps each book in the library to its shelf. A library that
+// can't be read is taken as empty, the import then shelves every book.
+func shelves(target Target, username string, libraryID int) map[int]string {
+    shelfOf := map[int]string{}
+    libraries, err := target.GetUserLibraries(username)
+    if err != nil {
+        return shelfOf
+    }
+    for _, lib := range libraries {
+        if lib.ID != libraryID {
+            continue
+        }
+        for shelf, ids := range lib.Books {
+            for _, id := range ids {
+                shelfOf[id] = shelf
+            }
+        }
+    }
+    return shelfOf
+}
+
+func hasReview(reviews []types.Review, username string) bool {
+    for _, r := range reviews {
+        if r.User == username {
+            return true
+        }
+    }
+    return false
+}
+
+func skipReason(item Item) string {
+    switch {
+    case item.Skip:
+        return "skipped while resolving"
+    case item.Status == Unmatched:
+        return "no matching book in the catalog"
+    case item.Status == Ambiguous:
+        return "ambiguous, left unresolved"
+    case item.Shelf == "":
+        return fmt.Sprintf("no shelf mapped for %q", item.Row.Shelf)
+    }
+    return ""
+}
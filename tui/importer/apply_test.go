@@ -0,0 +1,130 @@
+package importer
+
+import (
+    "path/filepath"
+    "testing"
+
+    "tui/api"
+    "tui/types"
+)
+
+// localTarget is a fresh local database with alice logged in, so reviews
+// hit the same UNIQUE(user, book_id) constraint as the real backend.
+func localTarget(t *testing.T) (*api.Local, int) {
+    t.Helper()
+    l, err := api.OpenLocal(filepath.Join(t.TempDir(), "app.db"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { l.Close() })
+    if err := l.Register("alice", "Alice", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := l.Login("alice", "pw"); err != nil {
+        t.Fatal(err)
+    }
+    libraries, err := l.GetUserLibraries("alice")
+    if err != nil || len(libraries) == 0 {
+        t.Fatalf("GetUserLibraries = %+v, %v", libraries, err)
+    }
+    return l, libraries[0].ID
+}
+
+func matched(line, bookID int, shelf string, rating int) Item {
+    return Item{
+        Row:    Row{Line: line, Rating: rating, Review: "review"},
+        Shelf:  shelf,
+        Status: Matched,
+        Book:   types.Book{ID: bookID},
+    }
+}
+
+func TestApply(t *testing.T) {
+    target, libraryID := localTarget(t)
+    items := []Item{
+        matched(2, 1, "read", 4),
+        matched(3, 2, "to_read", 0),
+        matched(4, 3, "read", 9), // out of range, shelved unrated
+        {Row: Row{Line: 5}, Status: Unmatched},
+        {Row: Row{Line: 6}, Status: Ambiguous},
+        {Row: Row{Line: 7, Shelf: "dnf"}, Status: Matched, Book: types.Book{ID: 4}},
+        {Row: Row{Line: 8}, Status: Matched, Book: types.Book{ID: 5}, Shelf: "read", Skip: true},
+    }
+
+    tests := []struct {
+        name                          string
+        items                         []Item
+        imported, reviewed, unchanged int
+        skipped                       int
+    }{
+        {"first import", items, 3, 1, 0, 4},
+        {"same file again", items, 0, 0, 3, 4},
+        {"moved shelf", []Item{matched(2, 1, "currently_reading", 4)}, 1, 0, 0, 0},
+        {"rated since", []Item{matched(3, 2, "to_read", 5)}, 0, 1, 0, 0},
+    }
+    for _, tt := range tests {
+        s := Apply(target, libraryID, tt.items)
+        if s.Imported != tt.imported || s.Reviewed != tt.reviewed || s.Unchanged != tt.unchanged || len(s.Skipped) != tt.skipped {
+            t.Errorf("%s: imported %d, reviewed %d, unchanged %d, skipped %+v; want %d, %d, %d, %d skipped",
+                tt.name, s.Imported, s.Reviewed, s.Unchanged, s.Skipped, tt.imported, tt.reviewed, tt.unchanged, tt.skipped)
+        }
+    }
+
+    libraries, _ := target.GetUserLibraries("alice")
+    for _, lib := range libraries {
+        if lib.ID == libraryID && (len(lib.Books["currently_reading"]) != 1 || len(lib.Books["read"]) != 1) {
+            t.Errorf("library shelves = %v", lib.Books)
+        }
+    }
+    details, err := target.GetBookDetails(1)
+    if err != nil || len(details.Reviews) != 1 {
+        t.Fatalf("book 1 reviews = %+v, %v; want one", details.Reviews, err)
+    }
+}
+
+func TestApplyWithoutUser(t *testing.T) {
+    fake := api.NewFake() // never logged in
+    s := Apply(fake, 1, []Item{matched(2, 1, "read", 4)})
+    if s.Imported != 0 || len(s.Skipped) != 1 {
+        t.Fatalf("summary = %+v, want the row skipped", s)
+    }
+}
+
+// moveRecorder counts how the import placed each book.
+type moveRecorder struct {
+    *api.Local
+    added, moved []int
+}
+
+func (r *moveRecorder) AddBookToLibrary(libraryID, bookID int, shelf string) error {
+    r.added = append(r.added, bookID)
+    return r.Local.AddBookToLibrary(libraryID, bookID, shelf)
+}
+
+func (r *moveRecorder) MoveBook(libraryID, bookID int, shelf string) error {
+    r.moved = append(r.moved, bookID)
+    return r.Local.MoveBook(libraryID, bookID, shelf)
+}
+
+func TestApplyMovesShelvedBook(t *testing.T) {
+    local, libraryID := localTarget(t)
+    if err := local.AddBookToLibrary(libraryID, 2, "to_read"); err != nil {
+        t.Fatal(err)
+    }
+    target := &moveRecorder{Local: local}
+
+    s := Apply(target, libraryID, []Item{matched(2, 2, "read", 0), matched(3, 3, "read", 0)})
+    if s.Imported != 2 || len(s.Skipped) != 0 {
+        t.Fatalf("summary = %+v, want both imported", s)
+    }
+    if len(target.moved) != 1 || target.moved[0] != 2 || len(target.added) != 1 || target.added[0] != 3 {
+        t.Errorf("moved %v, added %v; want book 2 moved and book 3 added", target.moved, target.added)
+    }
+
+    libraries, _ := local.GetUserLibraries("alice")
+    for _, lib := range libraries {
+        if lib.ID == libraryID && (len(lib.Books["to_read"]) != 0 || len(lib.Books["read"]) != 2) {
+            t.Errorf("library shelves = %v, want both books on read only", lib.Books)
+        }
+    }
+}
@@ -0,0 +1,396 @@
+package importer
+
+import (
+    "database/sql"
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+
+    "golang.org/x/text/language"
+    "golang.org/x/text/language/display"
+    "tui/types"
+
+    _ "modernc.org/sqlite"
+)
+
+// CalibreShelf is the source shelf of every Calibre row. Calibre has no
+// shelves, so all of its books go where this one is mapped.
+const CalibreShelf = "calibre"
+
+// DefaultPagesColumn is the custom column the Count Pages plugin creates.
+const DefaultPagesColumn = "pages"
+
+// CalibreBook is a book of a Calibre library mapped onto our Book. Authors
+// and Tags keep what the Book has no room for.
+type CalibreBook struct {
+    ID      int // Calibre's own id, 0 for a lone metadata.opf
+    Book    types.Book
+    Authors []string
+    Tags    []string
+    Dir     string // the book's folder, relative to the library
+}
+
+// ReadCalibre reads a Calibre library. path is the library folder, its
+// metadata.db, or a single metadata.opf. The database is opened read-only
+// so Calibre can stay open; the metadata.opf next to each book fills what
+// the database leaves empty. A folder without a metadata.db is searched for
+// metadata.opf files instead, as a copied library without its database.
+// The pages come from the integer custom column labelled pagesColumn, a
+// library without one has no page counts.
+func ReadCalibre(path, pagesColumn string) ([]CalibreBook, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    pagesColumn = strings.ToLower(strings.TrimPrefix(pagesColumn, "#"))
+
+    if !info.IsDir() {
+        if strings.EqualFold(filepath.Ext(path), ".opf") {
+            book, err := readOPF(path, pagesColumn)
+            if err != nil {
+                return nil, err
+            }
+            return []CalibreBook{book}, nil
+        }
+        return readCalibreLibrary(filepath.Dir(path), path, pagesColumn)
+    }
+
+    db := filepath.Join(path, "metadata.db")
+    if _, err := os.Stat(db); err == nil {
+        return readCalibreLibrary(path, db, pagesColumn)
+    }
+    return readOPFs(path, pagesColumn)
+}
+
+// readCalibreLibrary reads the database, then the OPF of every book.
+func readCalibreLibrary(dir, dbPath, pagesColumn string) ([]CalibreBook, error) {
+    books, err := readCalibreDB(dbPath, pagesColumn)
+    if err != nil {
+        return nil, fmt.Errorf("calibre: %s: %w", dbPath, err)
+    }
+    for i := range books {
+        if books[i].Dir == "" {
+            continue
+        }
+        opf, err := readOPF(filepath.Join(dir, books[i].Dir, "metadata.opf"), pagesColumn)
+        if err != nil {
+            // Calibre writes the OPFs lazily, a missing one is normal
+            continue
+        }
+        fillGaps(&books[i], opf)
+    }
+    return books, nil
+}
+
+// calibreLink reads one of the tables linking books to authors, tags and
+// the like: query returns book ids and values, set stores a value.
+type calibreLink struct {
+    query string
+    set   func(b *CalibreBook, value string)
+}
+
+func readCalibreDB(path, pagesColumn string) ([]CalibreBook, error) {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return nil, err
+    }
+    db, err := sql.Open("sqlite", "file:"+abs+"?mode=ro&_pragma=busy_timeout(5000)")
+    if err != nil {
+        return nil, err
+    }
+    defer db.Close()
+
+    rows, err := db.Query(`SELECT id, title, path, substr(COALESCE(pubdate, ''), 1, 4) FROM books ORDER BY id`)
+    if err != nil {
+        return nil, err
+    }
+    var books []CalibreBook
+    index := map[int]int{}
+    for rows.Next() {
+        var b CalibreBook
+        var year string
+        if err := rows.Scan(&b.ID, &b.Book.Name, &b.Dir, &year); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        b.Book.Year = calibreYear(year)
+        index[b.ID] = len(books)
+        books = append(books, b)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    // A book's language is set once per language, the first one last
+    links := []calibreLink{
+        {`SELECT l.book, a.name FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.id`,
+            func(b *CalibreBook, v string) { b.Authors = append(b.Authors, v) }},
+        {`SELECT l.book, p.name FROM books_publishers_link l JOIN publishers p ON p.id = l.publisher`,
+            func(b *CalibreBook, v string) { b.Book.Publisher = v }},
+        {`SELECT l.book, g.lang_code FROM books_languages_link l JOIN languages g ON g.id = l.lang_code ORDER BY l.item_order DESC`,
+            func(b *CalibreBook, v string) { b.Book.Language = languageName(v) }},
+        {`SELECT l.book, t.name FROM books_tags_link l JOIN tags t ON t.id = l.tag ORDER BY t.name`,
+            func(b *CalibreBook, v string) { b.Tags = append(b.Tags, v) }},
+    }
+    if table, ok, err := pagesTable(db, pagesColumn); err != nil {
+        return nil, err
+    } else if ok {
+        links = append(links, calibreLink{
+            fmt.Sprintf(`SELECT book, CAST(CAST(value AS INTEGER) AS TEXT) FROM %s`, table),
+            func(b *CalibreBook, v string) { b.Book.Pages, _ = strconv.Atoi(v) },
+        })
+    }
+
+    for _, link := range links {
+        rows, err := db.Query(link.query)
+        if err != nil {
+            return nil, err
+        }
+        for rows.Next() {
+            var id int
+            var value sql.NullString
+            if err := rows.Scan(&id, &value); err != nil {
+                rows.Close()
+                return nil, err
+            }
+            if i, ok := index[id]; ok && value.Valid {
+                link.set(&books[i], value.String)
+            }
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return nil, err
+        }
+    }
+
+    for i := range books {
+        books[i].Book.Author = strings.Join(books[i].Authors, " & ")
+    }
+    return books, nil
+}
+
+// pagesTable finds the table of the pages custom column. Calibre keeps
+// each custom column in a table of its own, named after the column's id.
+func pagesTable(db *sql.DB, label string) (string, bool, error) {
+    if label == "" {
+        return "", false, nil
+    }
+    var id int
+    var datatype string
+    err := db.QueryRow(`SELECT id, datatype FROM custom_columns WHERE lower(label) = ?`, label).Scan(&id, &datatype)
+    if errors.Is(err, sql.ErrNoRows) || (err != nil && strings.Contains(err.Error(), "no such table")) {
+        return "", false, nil
+    }
+    if err != nil {
+        return "", false, err
+    }
+    if datatype != "int" && datatype != "float" {
+        return "", false, fmt.Errorf("custom column #%s holds %s, not a number of pages", label, datatype)
+    }
+    return fmt.Sprintf("custom_column_%d", id), true, nil
+}
+
+// readOPFs reads every metadata.opf under dir.
+func readOPFs(dir, pagesColumn string) ([]CalibreBook, error) {
+    var books []CalibreBook
+    err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+        if err != nil || d.IsDir() || d.Name() != "metadata.opf" {
+            return err
+        }
+        book, err := readOPF(path, pagesColumn)
+        if err != nil {
+            return err
+        }
+        book.Dir, _ = filepath.Rel(dir, filepath.Dir(path))
+        books = append(books, book)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(books) == 0 {
+        return nil, fmt.Errorf("calibre: no metadata.db or metadata.opf in %s", dir)
+    }
+    sort.SliceStable(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+    return books, nil
+}
+
+// opfPackage is the part of an OPF the importer reads. encoding/xml
+// matches the names in any namespace, so dc: and opf: need no spelling out.
+type opfPackage struct {
+    Metadata struct {
+        Identifiers []struct {
+            Scheme string `xml:"scheme,attr"`
+            Value  string `xml:",chardata"`
+        } `xml:"identifier"`
+        Title    string `xml:"title"`
+        Creators []struct {
+            Role  string `xml:"role,attr"`
+            Value string `xml:",chardata"`
+        } `xml:"creator"`
+        Publisher string   `xml:"publisher"`
+        Date      string   `xml:"date"`
+        Languages []string `xml:"language"`
+        Subjects  []string `xml:"subject"`
+        Meta      []struct {
+            Name     string `xml:"name,attr"`
+            Property string `xml:"property,attr"`
+            Content  string `xml:"content,attr"`
+            Value    string `xml:",chardata"`
+        } `xml:"meta"`
+    } `xml:"metadata"`
+}
+
+func readOPF(path, pagesColumn string) (CalibreBook, error) {
+    raw, err := os.ReadFile(path)
+    if err != nil {
+        return CalibreBook{}, err
+    }
+    var pkg opfPackage
+    if err := xml.Unmarshal(raw, &pkg); err != nil {
+        return CalibreBook{}, fmt.Errorf("calibre: %s: %w", path, err)
+    }
+    md := pkg.Metadata
+
+    var b CalibreBook
+    b.Book.Name = strings.TrimSpace(md.Title)
+    if b.Book.Name == "" {
+        return CalibreBook{}, fmt.Errorf("calibre: %s: no title", path)
+    }
+    for _, id := range md.Identifiers {
+        if strings.EqualFold(id.Scheme, "calibre") {
+            b.ID, _ = strconv.Atoi(strings.TrimSpace(id.Value))
+        }
+    }
+    for _, c := range md.Creators {
+        if c.Role == "" || c.Role == "aut" {
+            b.Authors = append(b.Authors, strings.TrimSpace(c.Value))
+        }
+    }
+    b.Book.Author = strings.Join(b.Authors, " & ")
+    b.Book.Publisher = strings.TrimSpace(md.Publisher)
+    if len(md.Date) >= 4 {
+        b.Book.Year = calibreYear(md.Date[:4])
+    }
+    if len(md.Languages) > 0 {
+        b.Book.Language = languageName(strings.TrimSpace(md.Languages[0]))
+    }
+    for _, s := range md.Subjects {
+        b.Tags = append(b.Tags, strings.TrimSpace(s))
+    }
+    sort.Strings(b.Tags)
+
+    // Custom columns are JSON: one meta per column in OPF 2, all of them
+    // in one meta in OPF 3
+    if pagesColumn != "" {
+        for _, m := range md.Meta {
+            switch {
+            case strings.EqualFold(m.Name, "calibre:user_metadata:#"+pagesColumn):
+                b.Book.Pages = columnValue([]byte(m.Content))
+            case m.Property == "calibre:user_metadata":
+                var columns map[string]json.RawMessage
+                if json.Unmarshal([]byte(m.Value), &columns) == nil {
+                    b.Book.Pages = columnValue(columns["#"+pagesColumn])
+                }
+            }
+        }
+    }
+    return b, nil
+}
+
+// columnValue reads the number out of a custom column's JSON.
+func columnValue(raw []byte) int {
+    var column struct {
+        Value *float64 `json:"#value#"`
+    }
+    if json.Unmarshal(raw, &column) != nil || column.Value == nil {
+        return 0
+    }
+    return int(*column.Value)
+}
+
+// fillGaps completes a book from the database with its OPF.
+func fillGaps(b *CalibreBook, opf CalibreBook) {
+    if b.Book.Publisher == "" {
+        b.Book.Publisher = opf.Book.Publisher
+    }
+    if b.Book.Language == "" {
+        b.Book.Language = opf.Book.Language
+    }
+    if b.Book.Pages == 0 {
+        b.Book.Pages = opf.Book.Pages
+    }
+    if b.Book.Year == 0 {
+        b.Book.Year = opf.Book.Year
+    }
+    if len(b.Tags) == 0 {
+        b.Tags = opf.Tags
+    }
+}
+
+// calibreYear reads a year, Calibre writes 0101 when it doesn't know.
+func calibreYear(s string) int {
+    year, _ := strconv.Atoi(s)
+    if year <= 101 {
+        return 0
+    }
+    return year
+}
+
+// languageName turns Calibre's ISO 639 codes ("eng", "deu") into the
+// English names the catalog uses.
+func languageName(code string) string {
+    tag, err := language.Parse(code)
+    if err != nil {
+        return code
+    }
+    if name := display.English.Languages().Name(tag); name != "" {
+        return name
+    }
+    return code
+}
+
+// CalibreRows turns the books into rows for Plan, all on CalibreShelf. The
+// Line of a row is the Calibre id.
+func CalibreRows(books []CalibreBook) []Row {
+    rows := make([]Row, 0, len(books))
+    for _, b := range books {
+        author := ""
+        if len(b.Authors) > 0 {
+            // The catalog has one author per book, the first one is the likeliest
+            author = b.Authors[0]
+        }
+        rows = append(rows, Row{Line: b.ID, Title: b.Book.Name, Author: author, Shelf: CalibreShelf, Tags: b.Tags})
+    }
+    return rows
+}
+
+// Differences lists where Calibre and the catalog disagree about a book,
+// fields Calibre doesn't know are not compared.
+func Differences(calibre, catalog types.Book) []string {
+    var diffs []string
+    differ := func(field, ours, theirs string) {
+        if theirs != "" && !strings.EqualFold(ours, theirs) {
+            diffs = append(diffs, fmt.Sprintf("%s: %s here, %s in Calibre", field, ours, theirs))
+        }
+    }
+    number := func(n int) string {
+        if n == 0 {
+            return ""
+        }
+        return strconv.Itoa(n)
+    }
+    differ("pages", number(catalog.Pages), number(calibre.Pages))
+    differ("year", number(catalog.Year), number(calibre.Year))
+    differ("publisher", catalog.Publisher, calibre.Publisher)
+    differ("language", catalog.Language, calibre.Language)
+    return diffs
+}
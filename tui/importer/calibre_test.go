@@ -0,0 +1,226 @@
+package importer
+
+import (
+    "database/sql"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "tui/types"
+)
+
+// calibreSchema is the part of Calibre's metadata.db the importer reads.
+const calibreSchema = `
+CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, path TEXT, pubdate TIMESTAMP);
+CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT);
+CREATE TABLE books_authors_link (id INTEGER PRIMARY KEY, book INTEGER, author INTEGER);
+CREATE TABLE publishers (id INTEGER PRIMARY KEY, name TEXT);
+CREATE TABLE books_publishers_link (id INTEGER PRIMARY KEY, book INTEGER, publisher INTEGER);
+CREATE TABLE languages (id INTEGER PRIMARY KEY, lang_code TEXT);
+CREATE TABLE books_languages_link (id INTEGER PRIMARY KEY, book INTEGER, lang_code INTEGER, item_order INTEGER);
+CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT);
+CREATE TABLE books_tags_link (id INTEGER PRIMARY KEY, book INTEGER, tag INTEGER);
+CREATE TABLE custom_columns (id INTEGER PRIMARY KEY, label TEXT, datatype TEXT);
+CREATE TABLE custom_column_1 (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER);
+
+INSERT INTO books VALUES
+    (1, 'Dune', 'Frank Herbert/Dune (1)', '1965-08-01 00:00:00+00:00'),
+    (2, 'Good Omens', 'Terry Pratchett/Good Omens (2)', '0101-01-01 00:00:00+00:00'),
+    (3, 'Undated', '', NULL);
+INSERT INTO authors VALUES (1, 'Frank Herbert'), (2, 'Terry Pratchett'), (3, 'Neil Gaiman');
+INSERT INTO books_authors_link VALUES (1, 1, 1), (2, 2, 2), (3, 2, 3);
+INSERT INTO publishers VALUES (1, 'Chilton Books');
+INSERT INTO books_publishers_link VALUES (1, 1, 1);
+INSERT INTO languages VALUES (1, 'eng'), (2, 'deu');
+INSERT INTO books_languages_link VALUES (1, 1, 1, 0), (2, 2, 2, 1), (3, 2, 1, 0);
+INSERT INTO tags VALUES (1, 'sf'), (2, 'classic');
+INSERT INTO books_tags_link VALUES (1, 1, 1), (2, 1, 2);
+INSERT INTO custom_columns VALUES (1, 'pages', 'int');
+INSERT INTO custom_column_1 VALUES (1, 1, 412);
+`
+
+// opf2 keeps custom columns in one meta per column, opf3 in a single one.
+const opf2 = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:identifier opf:scheme="calibre">2</dc:identifier>
+    <dc:title>Good Omens</dc:title>
+    <dc:creator opf:role="aut">Terry Pratchett</dc:creator>
+    <dc:creator opf:role="aut">Neil Gaiman</dc:creator>
+    <dc:creator opf:role="ill">Somebody Else</dc:creator>
+    <dc:publisher>Gollancz</dc:publisher>
+    <dc:date>1990-05-01T00:00:00+00:00</dc:date>
+    <dc:language>en</dc:language>
+    <dc:subject>humour</dc:subject>
+    <dc:subject>fantasy</dc:subject>
+    <meta name="calibre:user_metadata:#pages" content="{&quot;#value#&quot;: 288, &quot;datatype&quot;: &quot;int&quot;}"/>
+  </metadata>
+</package>`
+
+const opf3 = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="calibre_id">calibre:7</dc:identifier>
+    <dc:identifier opf:scheme="calibre" xmlns:opf="http://www.idpf.org/2007/opf">7</dc:identifier>
+    <dc:title>Der Process</dc:title>
+    <dc:creator>Franz Kafka</dc:creator>
+    <dc:date>1925-04-26</dc:date>
+    <dc:language>deu</dc:language>
+    <meta property="calibre:user_metadata">{"#pages": {"#value#": 304.0, "datatype": "int"}}</meta>
+  </metadata>
+</package>`
+
+func writeFile(t *testing.T, path, content string) {
+    t.Helper()
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// calibreLibrary writes a library folder with a metadata.db and the OPF
+// of one book, which fills what the database lacks.
+func calibreLibrary(t *testing.T) string {
+    t.Helper()
+    dir := t.TempDir()
+    db, err := sql.Open("sqlite", filepath.Join(dir, "metadata.db"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer db.Close()
+    if _, err := db.Exec(calibreSchema); err != nil {
+        t.Fatal(err)
+    }
+    writeFile(t, filepath.Join(dir, "Terry Pratchett", "Good Omens (2)", "metadata.opf"), opf2)
+    return dir
+}
+
+func TestReadCalibreLibrary(t *testing.T) {
+    dir := calibreLibrary(t)
+    want := []CalibreBook{
+        {ID: 1, Book: types.Book{Name: "Dune", Author: "Frank Herbert", Year: 1965, Pages: 412, Language: "English", Publisher: "Chilton Books"},
+            Tags: []string{"classic", "sf"}},
+        // The year, publisher, pages and tags come from the OPF
+        {ID: 2, Book: types.Book{Name: "Good Omens", Author: "Terry Pratchett & Neil Gaiman", Year: 1990, Pages: 288, Language: "English", Publisher: "Gollancz"},
+            Tags: []string{"fantasy", "humour"}},
+        {ID: 3, Book: types.Book{Name: "Undated"}},
+    }
+
+    for _, path := range []string{dir, filepath.Join(dir, "metadata.db")} {
+        books, err := ReadCalibre(path, "#pages")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(books) != len(want) {
+            t.Fatalf("%s: %d books, want %d", path, len(books), len(want))
+        }
+        for i, w := range want {
+            got := books[i]
+            if got.ID != w.ID || got.Book != w.Book || strings.Join(got.Tags, ",") != strings.Join(w.Tags, ",") {
+                t.Errorf("%s: book %d = %+v\nwant %+v", path, i, got, w)
+            }
+        }
+    }
+
+    // Without the pages column only the OPF knows a page count
+    books, err := ReadCalibre(dir, "")
+    if err != nil || books[0].Book.Pages != 0 || books[1].Book.Pages != 0 {
+        t.Fatalf("without a pages column: %+v, %v", books, err)
+    }
+    if _, err := ReadCalibre(dir, "missing"); err != nil {
+        t.Fatalf("unknown pages column: %v", err)
+    }
+}
+
+func TestReadCalibreOPFs(t *testing.T) {
+    dir := t.TempDir()
+    writeFile(t, filepath.Join(dir, "Franz Kafka", "Der Process (7)", "metadata.opf"), opf3)
+    writeFile(t, filepath.Join(dir, "Terry Pratchett", "Good Omens (2)", "metadata.opf"), opf2)
+
+    books, err := ReadCalibre(dir, "pages")
+    if err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        id   int
+        book types.Book
+        dir  string
+    }{
+        {2, types.Book{Name: "Good Omens", Author: "Terry Pratchett & Neil Gaiman", Year: 1990, Pages: 288, Language: "English", Publisher: "Gollancz"},
+            filepath.Join("Terry Pratchett", "Good Omens (2)")},
+        {7, types.Book{Name: "Der Process", Author: "Franz Kafka", Year: 1925, Pages: 304, Language: "German"},
+            filepath.Join("Franz Kafka", "Der Process (7)")},
+    }
+    if len(books) != len(tests) {
+        t.Fatalf("%d books, want %d", len(books), len(tests))
+    }
+    for i, tt := range tests {
+        if got := books[i]; got.ID != tt.id || got.Book != tt.book || got.Dir != tt.dir {
+            t.Errorf("book %d = %+v, want %d %+v in %s", i, got, tt.id, tt.book, tt.dir)
+        }
+    }
+
+    one, err := ReadCalibre(filepath.Join(dir, "Franz Kafka", "Der Process (7)", "metadata.opf"), "pages")
+    if err != nil || len(one) != 1 || one[0].Book.Name != "Der Process" {
+        t.Fatalf("single OPF: %+v, %v", one, err)
+    }
+    if _, err := ReadCalibre(t.TempDir(), "pages"); err == nil {
+        t.Fatal("an empty folder read as a library")
+    }
+}
+
+func TestCalibreValues(t *testing.T) {
+    years := []struct {
+        s    string
+        want int
+    }{
+        {"1965", 1965}, {"0101", 0}, {"", 0}, {"abcd", 0},
+    }
+    for _, tt := range years {
+        if got := calibreYear(tt.s); got != tt.want {
+            t.Errorf("calibreYear(%q) = %d, want %d", tt.s, got, tt.want)
+        }
+    }
+
+    languages := []struct{ code, want string }{
+        {"eng", "English"}, {"deu", "German"}, {"fra", "French"}, {"en", "English"}, {"zzz", "zzz"}, {"??", "??"},
+    }
+    for _, tt := range languages {
+        if got := languageName(tt.code); got != tt.want {
+            t.Errorf("languageName(%q) = %q, want %q", tt.code, got, tt.want)
+        }
+    }
+}
+
+func TestDifferences(t *testing.T) {
+    catalog := types.Book{Pages: 412, Year: 1965, Publisher: "Chilton Books", Language: "English"}
+    tests := []struct {
+        calibre types.Book
+        want    []string
+    }{
+        {types.Book{}, nil},
+        {catalog, nil},
+        {types.Book{Publisher: "chilton books", Language: "english"}, nil},
+        {types.Book{Pages: 400, Year: 1965}, []string{"pages: 412 here, 400 in Calibre"}},
+        {types.Book{Year: 1966, Language: "German"}, []string{"year: 1965 here, 1966 in Calibre", "language: English here, German in Calibre"}},
+    }
+    for _, tt := range tests {
+        got := Differences(tt.calibre, catalog)
+        if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+            t.Errorf("Differences(%+v) = %q, want %q", tt.calibre, got, tt.want)
+        }
+    }
+}
+
+func TestCalibreRows(t *testing.T) {
+    rows := CalibreRows([]CalibreBook{
+        {ID: 2, Book: types.Book{Name: "Good Omens"}, Authors: []string{"Terry Pratchett", "Neil Gaiman"}, Tags: []string{"humour"}},
+        {ID: 3, Book: types.Book{Name: "Anonymous"}},
+    })
+    if len(rows) != 2 || rows[0].Line != 2 || rows[0].Author != "Terry Pratchett" || rows[0].Shelf != CalibreShelf || rows[1].Author != "" {
+        t.Fatalf("rows %+v", rows)
+    }
+}
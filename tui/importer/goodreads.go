@@ -0,0 +1,134 @@
+package importer
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// Row is one book from another service's export, before it is matched
+// against the catalog.
+type Row struct {
+    Line   int // line in the file, for reports
+    Title  string
+    Author string
+    Shelf  string   // the source's own shelf name, e.g. "to-read"
+    Tags   []string // other shelves or labels, informational
+    Rating int      // 0 when unrated
+    Review string
+}
+
+// goodreadsColumns are the headers of a Goodreads "Export Library" CSV
+// that the importer reads. The export has about thirty, the rest is ignored.
+var goodreadsColumns = []string{"Title", "Author", "My Rating", "Exclusive Shelf", "Bookshelves", "My Review"}
+
+// ParseGoodreads reads a Goodreads library export. Columns are found by
+// header name, so exports with reordered or extra columns still load.
+func ParseGoodreads(r io.Reader) ([]Row, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.LazyQuotes = true
+
+    header, err := reader.Read()
+    if err == io.EOF {
+        return nil, fmt.Errorf("goodreads: the file is empty")
+    }
+    if err != nil {
+        return nil, fmt.Errorf("goodreads: %w", err)
+    }
+
+    col := map[string]int{}
+    for i, name := range header {
+        // Excel likes to prepend a byte order mark
+        col[strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF"))] = i
+    }
+    for _, name := range []string{"Title", "Author"} {
+        if _, ok := col[name]; !ok {
+            return nil, fmt.Errorf("goodreads: no %q column, is this a Goodreads export? (expected %s)",
+                name, strings.Join(goodreadsColumns, ", "))
+        }
+    }
+
+    field := func(record []string, name string) string {
+        i, ok := col[name]
+        if !ok || i >= len(record) {
+            return ""
+        }
+        return strings.TrimSpace(record[i])
+    }
+
+    var rows []Row
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("goodreads: %w", err)
+        }
+        line, _ := reader.FieldPos(0)
+
+        row := Row{
+            Line:   line,
+            Title:  field(record, "Title"),
+            Author: field(record, "Author"),
+            Shelf:  field(record, "Exclusive Shelf"),
+            Review: cleanReview(field(record, "My Review")),
+        }
+        if row.Title == "" {
+            continue
+        }
+        row.Rating, _ = strconv.Atoi(field(record, "My Rating"))
+        for _, tag := range strings.Split(field(record, "Bookshelves"), ",") {
+            if tag = strings.TrimSpace(tag); tag != "" && tag != row.Shelf {
+                row.Tags = append(row.Tags, tag)
+            }
+        }
+        rows = append(rows, row)
+    }
+    return rows, nil
+}
+
+// GoodreadsShelves maps Goodreads' exclusive shelves to ours.
+var GoodreadsShelves = map[string]string{
+    "to-read":           "to_read",
+    "currently-reading": "currently_reading",
+    "read":              "read",
+}
+
+// ParseShelfMap reads extra shelf mappings like "owned=read,wishlist=to_read"
+// on top of base. Mapping a shelf to "" leaves its books out.
+func ParseShelfMap(base map[string]string, spec string) (map[string]string, error) {
+    shelves := make(map[string]string, len(base))
+    for from, to := range base {
+        shelves[from] = to
+    }
+    for _, pair := range strings.Split(spec, ",") {
+        if strings.TrimSpace(pair) == "" {
+            continue
+        }
+        from, to, ok := strings.Cut(pair, "=")
+        from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+        if !ok || from == "" {
+            return nil, fmt.Errorf("shelf map: %q is not source=shelf", pair)
+        }
+        switch to {
+        case "", "to_read", "currently_reading", "read":
+        default:
+            return nil, fmt.Errorf("shelf map: unknown shelf %q (expected to_read, currently_reading or read)", to)
+        }
+        shelves[from] = to
+    }
+    return shelves, nil
+}
+
+// cleanReview undoes the HTML Goodreads keeps reviews in.
+func cleanReview(s string) string {
+    s = strings.NewReplacer(
+        "<br/>", "\n", "<br />", "\n", "<br>", "\n",
+        "&amp;", "&", "&quot;", `"`, "&#39;", "'", "&lt;", "<", "&gt;", ">",
+    ).Replace(s)
+    return strings.TrimSpace(s)
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestParseGoodreads(t *testing.T) {
+    // Excel's byte order mark, columns in another order, a quoted review
+    // over two lines and a row without a title
+    csv := "\uFEFFBook Id,Author,Title,My Rating,Bookshelves,Exclusive Shelf,My Review\n" +
+        `1,Frank Herbert,Dune,5,"favourites, read",read,"Spice<br/>and sand &amp; worms"` + "\n" +
+        `2,Ann Leckie,Ancillary Justice,0,to-read,to-read,` + "\n" +
+        `3,Nobody,,3,read,read,` + "\n" +
+        `4,"Corey, James S.A.",Leviathan Wakes (The Expanse #1),4,owned,owned,"Line one` + "\n" + `line two"` + "\n"
+
+    rows, err := ParseGoodreads(strings.NewReader(csv))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []Row{
+        {Line: 2, Title: "Dune", Author: "Frank Herbert", Shelf: "read", Tags: []string{"favourites"}, Rating: 5, Review: "Spice\nand sand & worms"},
+        {Line: 3, Title: "Ancillary Justice", Author: "Ann Leckie", Shelf: "to-read"},
+        {Line: 5, Title: "Leviathan Wakes (The Expanse #1)", Author: "Corey, James S.A.", Shelf: "owned", Rating: 4, Review: "Line one\nline two"},
+    }
+    if len(rows) != len(want) {
+        t.Fatalf("%d rows, want %d: %+v", len(rows), len(want), rows)
+    }
+    for i, w := range want {
+        got := rows[i]
+        if got.Line != w.Line || got.Title != w.Title || got.Author != w.Author || got.Shelf != w.Shelf ||
+            got.Rating != w.Rating || got.Review != w.Review || strings.Join(got.Tags, ",") != strings.Join(w.Tags, ",") {
+            t.Errorf("row %d = %+v, want %+v", i, got, w)
+        }
+    }
+}
+
+func TestParseGoodreadsErrors(t *testing.T) {
+    tests := []struct {
+        name, csv, err string
+    }{
+        {"empty", "", "the file is empty"},
+        {"not goodreads", "Name,Writer\nDune,Herbert\n", `no "Title" column`},
+        {"no author", "Title\nDune\n", `no "Author" column`},
+    }
+    for _, tt := range tests {
+        _, err := ParseGoodreads(strings.NewReader(tt.csv))
+        if err == nil || !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+        }
+    }
+}
+
+func TestParseShelfMap(t *testing.T) {
+    tests := []struct {
+        spec string
+        want map[string]string // nil when the spec is invalid
+    }{
+        {"", GoodreadsShelves},
+        {"owned=read, wishlist = to_read", map[string]string{"owned": "read", "wishlist": "to_read"}},
+        {"to-read=", map[string]string{"to-read": ""}},
+        {"read=currently_reading", map[string]string{"read": "currently_reading"}},
+        {"owned", nil},
+        {"=read", nil},
+        {"owned=shelf", nil},
+    }
+    for _, tt := range tests {
+        got, err := ParseShelfMap(GoodreadsShelves, tt.spec)
+        if tt.want == nil {
+            if err == nil {
+                t.Errorf("ParseShelfMap(%q) = %v, want an error", tt.spec, got)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("ParseShelfMap(%q): %v", tt.spec, err)
+            continue
+        }
+        for from, to := range tt.want {
+            if shelf, ok := got[from]; !ok || shelf != to {
+                t.Errorf("ParseShelfMap(%q)[%q] = %q, want %q", tt.spec, from, shelf, to)
+            }
+        }
+    }
+    if GoodreadsShelves["owned"] != "" {
+        t.Error("ParseShelfMap changed the base map")
+    }
+}
@@ -0,0 +1,193 @@
+package importer
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "tui/store"
+)
+
+// Clipping is one entry of a Kindle "My Clippings.txt".
+type Clipping struct {
+    Line     int // where the entry starts in the file
+    Title    string
+    Author   string
+    Kind     string // "highlight", "note" or "bookmark"
+    Page     int    // 0 when the book has no page numbers
+    Location string // e.g. "1203-1205"
+    Added    string // the date as written, its language follows the device
+    Text     string
+}
+
+// clippingSeparator ends every entry.
+const clippingSeparator = "=========="
+
+// kindleKinds are the words for each kind of clipping in the languages a
+// Kindle writes them in: English, German, French, Spanish, Italian,
+// Portuguese and Dutch. The first kind with a word in the line wins.
+var kindleKinds = []struct {
+    kind  string
+    words []string
+}{
+    {"bookmark", []string{"bookmark", "lesezeichen", "signet", "marcador", "segnalibro", "bladwijzer"}},
+    {"note", []string{"note", "notiz", "nota", "notitie"}},
+    {"highlight", []string{"highlight", "markierung", "surlignement", "subrayado", "evidenziazione", "destaque", "markering"}},
+}
+
+var (
+    // "page 12", "Seite 12", "página 12", "pagina 12"
+    kindlePage = regexp.MustCompile(`(?i)\b(?:page|seite|página|pagina|pág\.)\s+([0-9ivxlcdm]+)`)
+    // "Location 1203-1205", "Loc. 1203-05", "Position 1203", "emplacement 1203-1205"
+    kindleLocation = regexp.MustCompile(`(?i)(?:location|loc\.|position|emplacement|posición|posizione|posição|locatie)\s+([0-9]+(?:-[0-9]+)?)`)
+    // The author is the last parenthesised part of the title line
+    kindleAuthor = regexp.MustCompile(`^(.*)\s*\(([^()]*)\)\s*$`)
+)
+
+// ParseKindle reads a Kindle clippings file. Entries it can't make sense of
+// are skipped rather than failing the file, the Kindle itself writes a
+// broken one now and then.
+func ParseKindle(r io.Reader) ([]Clipping, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+    var clippings []Clipping
+    var entry []string
+    start, line := 1, 0
+    flush := func() {
+        if c, ok := parseClipping(entry); ok {
+            c.Line = start
+            clippings = append(clippings, c)
+        }
+        entry = entry[:0]
+        start = line + 1
+    }
+
+    for scanner.Scan() {
+        line++
+        text := strings.TrimRight(scanner.Text(), "\r")
+        // Every entry of the file may start with a byte order mark
+        text = strings.TrimPrefix(text, "\uFEFF")
+        if strings.TrimSpace(text) == clippingSeparator {
+            flush()
+            continue
+        }
+        entry = append(entry, text)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, fmt.Errorf("kindle: %w", err)
+    }
+    flush()
+
+    if len(clippings) == 0 {
+        return nil, fmt.Errorf("kindle: no clippings found, is this a \"My Clippings.txt\"?")
+    }
+    return clippings, nil
+}
+
+// parseClipping reads one entry: the title line, the metadata line, a blank
+// line and the text, which is empty for bookmarks.
+func parseClipping(lines []string) (Clipping, bool) {
+    for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+        lines = lines[1:]
+    }
+    if len(lines) < 2 {
+        return Clipping{}, false
+    }
+
+    var c Clipping
+    c.Title = strings.TrimSpace(lines[0])
+    if m := kindleAuthor.FindStringSubmatch(c.Title); m != nil {
+        c.Title, c.Author = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+    }
+
+    meta := strings.TrimSpace(strings.TrimLeft(lines[1], "- "))
+    lower := strings.ToLower(meta)
+    for _, k := range kindleKinds {
+        for _, word := range k.words {
+            if strings.Contains(lower, word) {
+                c.Kind = k.kind
+                break
+            }
+        }
+        if c.Kind != "" {
+            break
+        }
+    }
+    if c.Kind == "" || c.Title == "" {
+        return Clipping{}, false
+    }
+
+    if m := kindlePage.FindStringSubmatch(meta); m != nil {
+        c.Page, _ = strconv.Atoi(m[1])
+    }
+    if m := kindleLocation.FindStringSubmatch(meta); m != nil {
+        c.Location = m[1]
+    }
+    if parts := strings.Split(meta, "|"); len(parts) > 1 {
+        c.Added = addedDate(parts[len(parts)-1])
+    }
+
+    c.Text = strings.TrimSpace(strings.Join(lines[2:], "\n"))
+    return c, true
+}
+
+// addedDate drops the "Added on" in front of the date, whatever the language.
+func addedDate(part string) string {
+    part = strings.TrimSpace(part)
+    for _, prefix := range []string{"Added on", "Hinzugefügt am", "Ajouté le", "Añadido el", "Aggiunto in data", "Aggiunto il", "Adicionado:", "Toegevoegd op"} {
+        if rest, ok := strings.CutPrefix(part, prefix); ok {
+            return strings.TrimSpace(rest)
+        }
+    }
+    return part
+}
+
+// KindleBooks groups clippings by book, in the order the books first
+// appear: rows for a Matcher, and the clippings of each row.
+func KindleBooks(clippings []Clipping) ([]Row, [][]Clipping) {
+    type book struct{ title, author string }
+    index := map[book]int{}
+    var rows []Row
+    var grouped [][]Clipping
+    for _, c := range clippings {
+        i, ok := index[book{c.Title, c.Author}]
+        if !ok {
+            i = len(rows)
+            index[book{c.Title, c.Author}] = i
+            rows = append(rows, Row{Line: c.Line, Title: c.Title, Author: c.Author})
+            grouped = append(grouped, nil)
+        }
+        grouped[i] = append(grouped[i], c)
+    }
+    return rows, grouped
+}
+
+// KindleHighlights turns the clippings of every matched book into
+// highlights for the store, and reports the books that were left out.
+// items and grouped are parallel, as Plan and KindleBooks return them.
+func KindleHighlights(items []Item, grouped [][]Clipping) ([]store.Highlight, []Skipped) {
+    var highlights []store.Highlight
+    var skipped []Skipped
+    for i, item := range items {
+        if reason := skipReason(item); reason != "" {
+            skipped = append(skipped, Skipped{Row: item.Row, Reason: reason})
+            continue
+        }
+        for _, c := range grouped[i] {
+            highlights = append(highlights, store.Highlight{
+                BookID:   item.Book.ID,
+                Kind:     c.Kind,
+                Text:     c.Text,
+                Page:     c.Page,
+                Location: c.Location,
+                Added:    c.Added,
+                Source:   "kindle",
+            })
+        }
+    }
+    return highlights, skipped
+}
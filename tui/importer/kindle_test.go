@@ -0,0 +1,120 @@
+package importer
+
+import (
+    "strings"
+    "testing"
+
+    "tui/types"
+)
+
+// clippings is a "My Clippings.txt" as Kindles write it: CRLF, a byte
+// order mark on the first entry, and a broken entry in the middle.
+var clippings = strings.ReplaceAll("\uFEFFDune (Frank Herbert)\n"+
+    "- Your Highlight on page 12 | Location 180-182 | Added on Sunday, January 14, 2024 9:02:11 PM\n"+
+    "\n"+
+    "I must not fear.\n"+
+    "==========\n"+
+    "Dune (Frank Herbert)\n"+
+    "- Your Note on Location 183 | Added on Sunday, January 14, 2024 9:03:00 PM\n"+
+    "\n"+
+    "Litany against fear\n"+
+    "==========\n"+
+    "Der Hobbit (Der Herr der Ringe) (J.R.R. Tolkien)\n"+
+    "- Ihre Markierung auf Seite 5 | Position 70-71 | Hinzugefügt am Samstag, 13. Januar 2024 10:15:32\n"+
+    "\n"+
+    "In einer Höhle in der Erde,\n"+
+    "da lebte ein Hobbit.\n"+
+    "==========\n"+
+    "garbage without a metadata line\n"+
+    "==========\n"+
+    "L'Étranger (Albert Camus)\n"+
+    "- Votre signet à l'emplacement 40 | Ajouté le samedi 13 janvier 2024 10:15:32\n"+
+    "\n"+
+    "\n"+
+    "==========\n", "\n", "\r\n")
+
+func TestParseKindle(t *testing.T) {
+    got, err := ParseKindle(strings.NewReader(clippings))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []Clipping{
+        {Line: 1, Title: "Dune", Author: "Frank Herbert", Kind: "highlight", Page: 12, Location: "180-182",
+            Added: "Sunday, January 14, 2024 9:02:11 PM", Text: "I must not fear."},
+        {Line: 6, Title: "Dune", Author: "Frank Herbert", Kind: "note", Location: "183",
+            Added: "Sunday, January 14, 2024 9:03:00 PM", Text: "Litany against fear"},
+        {Line: 11, Title: "Der Hobbit (Der Herr der Ringe)", Author: "J.R.R. Tolkien", Kind: "highlight", Page: 5, Location: "70-71",
+            Added: "Samstag, 13. Januar 2024 10:15:32", Text: "In einer Höhle in der Erde,\nda lebte ein Hobbit."},
+        {Line: 19, Title: "L'Étranger", Author: "Albert Camus", Kind: "bookmark", Location: "40",
+            Added: "samedi 13 janvier 2024 10:15:32"},
+    }
+    if len(got) != len(want) {
+        t.Fatalf("%d clippings, want %d: %+v", len(got), len(want), got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Errorf("clipping %d = %+v\nwant %+v", i, got[i], want[i])
+        }
+    }
+}
+
+func TestParseClippingKinds(t *testing.T) {
+    tests := []struct {
+        meta     string
+        kind     string
+        page     int
+        location string
+        added    string
+    }{
+        {"- Your Highlight on page 3 | Location 40-41 | Added on Friday, August 2, 2019", "highlight", 3, "40-41", "Friday, August 2, 2019"},
+        {"- Your Bookmark on Location 900 | Added on Friday, August 2, 2019", "bookmark", 0, "900", "Friday, August 2, 2019"},
+        {"- Highlight Loc. 1203-05  | Added on Friday, August 2, 2019", "highlight", 0, "1203-05", "Friday, August 2, 2019"},
+        {"- Tu subrayado en la página 7 | posición 95-96 | Añadido el sábado, 13 de enero de 2024", "highlight", 7, "95-96", "sábado, 13 de enero de 2024"},
+        {"- Tu nota en la posición 97 | Añadido el sábado, 13 de enero de 2024", "note", 0, "97", "sábado, 13 de enero de 2024"},
+        {"- La tua evidenziazione a pagina 8 | posizione 100-101 | Aggiunto in data sabato 13 gennaio 2024", "highlight", 8, "100-101", "sabato 13 gennaio 2024"},
+        {"- Seu destaque na página 9 | posição 110 | Adicionado: sábado, 13 de janeiro de 2024", "highlight", 9, "110", "sábado, 13 de janeiro de 2024"},
+        {"- Uw markering op pagina 10 | locatie 120-121 | Toegevoegd op zaterdag 13 januari 2024", "highlight", 10, "120-121", "zaterdag 13 januari 2024"},
+        {"- Your Clip on page xii | Added on Friday, August 2, 2019", "", 0, "", ""},
+    }
+    for _, tt := range tests {
+        c, ok := parseClipping([]string{"Title (Author)", tt.meta, "", "text"})
+        if ok != (tt.kind != "") || c.Kind != tt.kind || c.Page != tt.page || c.Location != tt.location || c.Added != tt.added {
+            t.Errorf("%q: %q page %d location %q added %q, ok %v; want %q page %d location %q added %q",
+                tt.meta, c.Kind, c.Page, c.Location, c.Added, ok, tt.kind, tt.page, tt.location, tt.added)
+        }
+    }
+}
+
+func TestParseKindleEmpty(t *testing.T) {
+    for _, s := range []string{"", "==========\n", "just some text\n"} {
+        if _, err := ParseKindle(strings.NewReader(s)); err == nil {
+            t.Errorf("ParseKindle(%q) found clippings", s)
+        }
+    }
+}
+
+func TestKindleBooks(t *testing.T) {
+    parsed, err := ParseKindle(strings.NewReader(clippings))
+    if err != nil {
+        t.Fatal(err)
+    }
+    rows, grouped := KindleBooks(parsed)
+    if len(rows) != 3 || rows[0].Title != "Dune" || len(grouped[0]) != 2 || rows[2].Title != "L'Étranger" {
+        t.Fatalf("rows %+v", rows)
+    }
+
+    items := []Item{
+        {Row: rows[0], Status: Matched, Book: types.Book{ID: 1}, Shelf: "read"},
+        {Row: rows[1], Status: Unmatched, Shelf: "read"},
+        {Row: rows[2], Status: Matched, Book: types.Book{ID: 9}, Shelf: "read"},
+    }
+    highlights, skipped := KindleHighlights(items, grouped)
+    if len(highlights) != 3 || len(skipped) != 1 || skipped[0].Row.Title != "Der Hobbit (Der Herr der Ringe)" {
+        t.Fatalf("highlights %+v, skipped %+v", highlights, skipped)
+    }
+    for _, h := range highlights {
+        if h.Source != "kindle" || (h.BookID != 1 && h.BookID != 9) {
+            t.Errorf("highlight %+v", h)
+        }
+    }
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+    "sort"
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/unicode/norm"
+    "tui/types"
+)
+
+const (
+    // SureScore is the score from which the best candidate is taken
+    // without asking, provided no other one comes close.
+    SureScore = 0.92
+    // LoneScore is enough when there is no other candidate at all.
+    LoneScore = 0.8
+    // MinScore is the lowest score still offered as a candidate.
+    MinScore = 0.6
+    // maxCandidates caps the choices shown for an ambiguous row.
+    maxCandidates = 5
+)
+
+// Status says how sure the importer is about a row's book.
+type Status int
+
+const (
+    Unmatched Status = iota
+    Ambiguous
+    Matched
+)
+
+func (s Status) String() string {
+    switch s {
+    case Matched:
+        return "matched"
+    case Ambiguous:
+        return "ambiguous"
+    }
+    return "unmatched"
+}
+
+// Candidate is a catalog book a row might be.
+type Candidate struct {
+    Book  types.Book
+    Score float64 // 0..1
+}
+
+// Item is a row with its shelf and the book it was matched to.
+type Item struct {
+    Row        Row
+    Shelf      string // ours, "" when the source shelf has no mapping
+    Status     Status
+    Book       types.Book // set when Matched
+    Candidates []Candidate
+    Skip       bool // left out by the user while resolving
+}
+
+// Resolve settles an ambiguous item on one of its candidates.
+func (it *Item) Resolve(book types.Book) {
+    it.Book = book
+    it.Status = Matched
+    it.Skip = false
+}
+
+// Plan matches rows against the catalog and maps their shelves. Shelves
+// missing from shelves leave the item's Shelf empty, Apply skips those;
+// a row without any shelf counts as read.
+func Plan(rows []Row, catalog []types.Book, shelves map[string]string) []Item {
+    matcher := NewMatcher(catalog)
+    items := make([]Item, 0, len(rows))
+    for _, row := range rows {
+        item := matcher.Match(row)
+        item.Shelf = shelves[row.Shelf]
+        if row.Shelf == "" {
+            item.Shelf = "read"
+        }
+        items = append(items, item)
+    }
+    return items
+}
+
+// Matcher finds catalog books by title and author.
+type Matcher struct {
+    catalog []types.Book
+    keys    []matchKey
+}
+
+func NewMatcher(catalog []types.Book) *Matcher {
+    keys := make([]matchKey, len(catalog))
+    for i, book := range catalog {
+        keys[i] = newMatchKey(book.Name, book.Author)
+    }
+    return &Matcher{catalog: catalog, keys: keys}
+}
+
+// Match scores the catalog against a row. The book is taken when it wins
+// clearly, the close calls are left Ambiguous for the user.
+func (m *Matcher) Match(row Row) Item {
+    item := Item{Row: row, Candidates: m.candidates(newMatchKey(row.Title, row.Author))}
+    switch c := item.Candidates; {
+    case len(c) == 0:
+        item.Status = Unmatched
+    case len(c) == 1 && c[0].Score >= LoneScore,
+        c[0].Score >= SureScore && c[1].Score < c[0].Score-0.05:
+        item.Status = Matched
+        item.Book = c[0].Book
+    default:
+        item.Status = Ambiguous
+    }
+    return item
+}
+
+// Unmapped lists the source shelves that no item could be mapped from.
+func Unmapped(items []Item) []string {
+    seen := map[string]bool{}
+    var shelves []string
+    for _, item := range items {
+        if item.Shelf == "" && !seen[item.Row.Shelf] {
+            seen[item.Row.Shelf] = true
+            shelves = append(shelves, item.Row.Shelf)
+        }
+    }
+    sort.Strings(shelves)
+    return shelves
+}
+
+// Remap sets the shelf of every item from the source shelf from.
+func Remap(items []Item, from, to string) {
+    for i := range items {
+        if items[i].Row.Shelf == from {
+            items[i].Shelf = to
+        }
+    }
+}
+
+func (m *Matcher) candidates(key matchKey) []Candidate {
+    var found []Candidate
+    for i, k := range m.keys {
+        if score := key.score(k); score >= MinScore {
+            found = append(found, Candidate{Book: m.catalog[i], Score: score})
+        }
+    }
+    sort.SliceStable(found, func(i, j int) bool { return found[i].Score > found[j].Score })
+    if len(found) > maxCandidates {
+        found = found[:maxCandidates]
+    }
+    return found
+}
+
+// matchKey is a title and author boiled down for comparison.
+type matchKey struct {
+    title, short, author string
+}
+
+func newMatchKey(title, author string) matchKey {
+    // "Leviathan Wakes (The Expanse, #1)" and "Dune: Deluxe Edition"
+    // should both find the plain title
+    short := title
+    if i := strings.Index(short, " ("); i > 0 {
+        short = short[:i]
+    }
+    if i := strings.Index(short, ":"); i > 0 {
+        short = short[:i]
+    }
+    return matchKey{title: fold(title), short: fold(short), author: fold(author)}
+}
+
+// score weighs the title three times the author. An export without
+// authors is scored on the title alone.
+func (k matchKey) score(other matchKey) float64 {
+    title := max(similarity(k.title, other.title), similarity(k.short, other.short),
+        similarity(k.short, other.title), similarity(k.title, other.short))
+    if k.author == "" || other.author == "" {
+        return title
+    }
+    return 0.75*title + 0.25*similarity(k.author, other.author)
+}
+
+// fold lowercases s and drops accents, apostrophes and punctuation, so
+// "Cien Años" and "cien anos" compare equal.
+func fold(s string) string {
+    var b strings.Builder
+    space := false
+    for _, r := range norm.NFD.String(strings.ToLower(s)) {
+        switch {
+        case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+        case unicode.IsLetter(r) || unicode.IsDigit(r):
+            if space && b.Len() > 0 {
+                b.WriteByte(' ')
+            }
+            space = false
+            b.WriteRune(r)
+        default:
+            space = true
+        }
+    }
+    return b.String()
+}
+
+// similarity is 1 minus the edit distance relative to the longer string,
+// also trying the words sorted, so "Tolkien J R R" finds "J R R Tolkien".
+func similarity(a, b string) float64 {
+    if a == "" || b == "" {
+        return 0
+    }
+    return max(ratio(a, b), ratio(sortWords(a), sortWords(b)))
+}
+
+func sortWords(s string) string {
+    words := strings.Fields(s)
+    sort.Strings(words)
+    return strings.Join(words, " ")
+}
+
+func ratio(a, b string) float64 {
+    ra, rb := []rune(a), []rune(b)
+    longest := max(len(ra), len(rb))
+    if longest == 0 {
+        return 1
+    }
+    return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+    prev := make([]int, len(b)+1)
+    cur := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+    for i := 1; i <= len(a); i++ {
+        cur[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+        }
+        prev, cur = cur, prev
+    }
+    return prev[len(b)]
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+    "testing"
+
+    "tui/types"
+)
+
+var catalog = []types.Book{
+    {ID: 1, Name: "Dune", Author: "Frank Herbert"},
+    {ID: 2, Name: "Dune Messiah", Author: "Frank Herbert"},
+    {ID: 3, Name: "Cien años de soledad", Author: "Gabriel García Márquez"},
+    {ID: 4, Name: "Leviathan Wakes", Author: "James S. A. Corey"},
+    {ID: 5, Name: "The Hobbit", Author: "J.R.R. Tolkien"},
+    {ID: 6, Name: "Collected Poems", Author: "Philip Larkin"},
+    {ID: 7, Name: "Collected Poems", Author: "Sylvia Plath"},
+}
+
+func TestFold(t *testing.T) {
+    tests := []struct{ in, want string }{
+        {"Cien Años de Soledad", "cien anos de soledad"},
+        {"J.R.R. Tolkien", "j r r tolkien"},
+        {"Ender's Game", "enders game"},
+        {"  Dune:  Deluxe   Edition ", "dune deluxe edition"},
+        {"L’Étranger", "letranger"},
+    }
+    for _, tt := range tests {
+        if got := fold(tt.in); got != tt.want {
+            t.Errorf("fold(%q) = %q, want %q", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestMatch(t *testing.T) {
+    m := NewMatcher(catalog)
+    tests := []struct {
+        name          string
+        title, author string
+        status        Status
+        book          int // the matched book, or the best candidate
+    }{
+        {"exact", "Dune", "Frank Herbert", Matched, 1},
+        {"accents and case", "Cien Anos de Soledad", "Gabriel Garcia Marquez", Matched, 3},
+        {"series in brackets", "Leviathan Wakes (The Expanse, #1)", "James S.A. Corey", Matched, 4},
+        {"subtitle", "The Hobbit: or There and Back Again", "Tolkien, J.R.R.", Matched, 5},
+        {"author last name first", "The Hobbit", "Tolkien J R R", Matched, 5},
+        {"no author", "Dune Messiah", "", Matched, 2},
+        {"small typo, lone candidate", "Dun", "Frank Herbert", Matched, 1},
+        {"two books of that title", "Collected Poems", "", Ambiguous, 6},
+        {"weak lone candidate", "Dine", "", Ambiguous, 1},
+        {"not in the catalog", "Ancillary Justice", "Ann Leckie", Unmatched, 0},
+    }
+    for _, tt := range tests {
+        item := m.Match(Row{Title: tt.title, Author: tt.author})
+        if item.Status != tt.status {
+            t.Errorf("%s: status %s, want %s (candidates %+v)", tt.name, item.Status, tt.status, item.Candidates)
+            continue
+        }
+        got := item.Book.ID
+        if item.Status == Ambiguous {
+            got = item.Candidates[0].Book.ID
+        }
+        if got != tt.book {
+            t.Errorf("%s: book %d, want %d", tt.name, got, tt.book)
+        }
+    }
+}
+
+func TestPlan(t *testing.T) {
+    rows := []Row{
+        {Line: 2, Title: "Dune", Author: "Frank Herbert", Shelf: "to-read"},
+        {Line: 3, Title: "The Hobbit", Author: "J.R.R. Tolkien", Shelf: "owned"},
+        {Line: 4, Title: "Dune Messiah", Author: "Frank Herbert", Shelf: "wishlist"},
+        {Line: 5, Title: "Leviathan Wakes", Author: "James S. A. Corey"},
+    }
+    items := Plan(rows, catalog, GoodreadsShelves)
+
+    wantShelves := []string{"to_read", "", "", "read"}
+    for i, want := range wantShelves {
+        if items[i].Shelf != want {
+            t.Errorf("line %d on %q, want %q", items[i].Row.Line, items[i].Shelf, want)
+        }
+    }
+    if got := Unmapped(items); len(got) != 2 || got[0] != "owned" || got[1] != "wishlist" {
+        t.Fatalf("Unmapped = %q", got)
+    }
+
+    Remap(items, "owned", "read")
+    if items[1].Shelf != "read" || items[2].Shelf != "" {
+        t.Fatalf("after Remap: %q, %q", items[1].Shelf, items[2].Shelf)
+    }
+
+    items[2].Status = Ambiguous
+    items[2].Skip = true
+    items[2].Resolve(catalog[0])
+    if items[2].Status != Matched || items[2].Book.ID != 1 || items[2].Skip {
+        t.Fatalf("Resolve left %+v", items[2])
+    }
+}
@@ -0,0 +1,515 @@
+package keymap
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "tui/types"
+)
+
+// Action is what a key press means, independent of which key triggered it.
+type Action string
+
+const (
+    None Action = ""
+
+    // Global
+    Quit    Action = "quit"
+    Help    Action = "help"
+    NavNext Action = "nav_next"
+    NavPrev Action = "nav_prev"
+    Back    Action = "back"
+
+    // Login
+    NextField Action = "next_field"
+    PrevField Action = "prev_field"
+    Submit    Action = "submit"
+
+    // Library
+    BookPrev  Action = "book_prev"
+    BookNext  Action = "book_next"
+    ShelfPrev Action = "shelf_prev"
+    ShelfNext Action = "shelf_next"
+    OpenBook  Action = "open_book"
+    Search    Action = "search"
+    Refresh   Action = "refresh"
+    Bookcase  Action = "toggle_bookcase"
+    Sidebar   Action = "toggle_sidebar"
+    Import    Action = "import"
+    Export    Action = "export"
+
+    // Discover
+    ItemPrev Action = "item_prev"
+    ItemNext Action = "item_next"
+
+    // Reading
+    PagePrev Action = "page_prev"
+    PageNext Action = "page_next"
+
+    // Book details
+    StartReading Action = "start_reading"
+    AddReview    Action = "add_review"
+
+    // Notes, in the reading view and book details
+    AddNote     Action = "add_note"
+    AddQuote    Action = "add_quote"
+    AddBookmark Action = "add_bookmark"
+    NotesOrder  Action = "notes_order"
+    Timeline    Action = "timeline"
+
+    // Search bars, forms and the import wizard
+    Cancel     Action = "cancel"
+    RatingDown Action = "rating_down"
+    RatingUp   Action = "rating_up"
+    ChoicePrev Action = "choice_prev"
+    ChoiceNext Action = "choice_next"
+    Skip       Action = "skip"
+)
+
+// Mode is a search bar, form or wizard step that takes the keys while it
+// is open, in place of the bindings of the view under it.
+type Mode string
+
+const (
+    ModeSearch        Mode = "search"
+    ModeReview        Mode = "review"
+    ModeNote          Mode = "note"
+    ModeNotesSearch   Mode = "notes_search"
+    ModeImportPath    Mode = "import_path"
+    ModeImportShelves Mode = "import_shelves"
+    ModeImportResolve Mode = "import_resolve"
+    ModeImportConfirm Mode = "import_confirm"
+    ModeImportDone    Mode = "import_done"
+)
+
+// Binding ties an action to the keys that trigger it in one scope.
+type Binding struct {
+    Action Action
+    Keys   []string
+    Help   string
+}
+
+// Scope is a named group of bindings, either global or tied to a view.
+type Scope struct {
+    Name     string
+    Bindings []Binding
+}
+
+// KeyMap is the registry of every key binding in the app.
+type KeyMap struct {
+    Global Scope
+    Views  map[types.View]Scope
+    Modes  map[Mode]Scope
+}
+
+var Presets = []string{"default", "vim", "emacs"}
+
+// Default returns the stock bindings.
+func Default() KeyMap {
+    return KeyMap{
+        Global: Scope{Name: "Global", Bindings: []Binding{
+            {Action: NavNext, Keys: []string{"tab"}, Help: "Next view"},
+            {Action: NavPrev, Keys: []string{"shift+tab"}, Help: "Previous view"},
+            {Action: Back, Keys: []string{"esc"}, Help: "Back"},
+            {Action: Help, Keys: []string{"?"}, Help: "Help"},
+            {Action: Quit, Keys: []string{"q", "ctrl+c"}, Help: "Quit"},
+        }},
+        Views: map[types.View]Scope{
+            types.ViewLogin: {Name: "Login", Bindings: []Binding{
+                {Action: NextField, Keys: []string{"tab", "down"}, Help: "Next field"},
+                {Action: PrevField, Keys: []string{"shift+tab", "up"}, Help: "Previous field"},
+                {Action: Submit, Keys: []string{"enter"}, Help: "Login"},
+            }},
+            types.ViewLibrary: {Name: "Library", Bindings: []Binding{
+                {Action: BookPrev, Keys: []string{"left"}, Help: "Previous book"},
+                {Action: BookNext, Keys: []string{"right"}, Help: "Next book"},
+                {Action: ShelfPrev, Keys: []string{"up"}, Help: "Previous shelf"},
+                {Action: ShelfNext, Keys: []string{"down"}, Help: "Next shelf"},
+                {Action: OpenBook, Keys: []string{"enter"}, Help: "Open book"},
+                {Action: Search, Keys: []string{"s"}, Help: "Search"},
+                {Action: Refresh, Keys: []string{"r"}, Help: "Refresh"},
+                {Action: Bookcase, Keys: []string{"v"}, Help: "Boxes/bookcase"},
+                {Action: Sidebar, Keys: []string{"b"}, Help: "Sidebar"},
+                {Action: Import, Keys: []string{"i"}, Help: "Import (Goodreads, Kindle, Calibre)"},
+                {Action: Export, Keys: []string{"x"}, Help: "Export"},
+            }},
+            types.ViewDiscover: {Name: "Discover", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up"}, Help: "Previous book"},
+                {Action: ItemNext, Keys: []string{"down"}, Help: "Next book"},
+                {Action: OpenBook, Keys: []string{"enter"}, Help: "Open book"},
+            }},
+            types.ViewReading: {Name: "Reading", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up"}, Help: "Previous book"},
+                {Action: ItemNext, Keys: []string{"down"}, Help: "Next book"},
+                {Action: PagePrev, Keys: []string{"left"}, Help: "Page back"},
+                {Action: PageNext, Keys: []string{"right"}, Help: "Page forward"},
+                {Action: OpenBook, Keys: []string{"enter"}, Help: "Open book"},
+                {Action: AddNote, Keys: []string{"n"}, Help: "Note"},
+                {Action: AddQuote, Keys: []string{"\""}, Help: "Quote"},
+                {Action: AddBookmark, Keys: []string{"m"}, Help: "Bookmark"},
+                {Action: Timeline, Keys: []string{"t"}, Help: "Timeline"},
+            }},
+            types.ViewBookDetails: {Name: "Book details", Bindings: []Binding{
+                {Action: StartReading, Keys: []string{"r"}, Help: "Start reading"},
+                {Action: AddReview, Keys: []string{"a"}, Help: "Add review"},
+                {Action: AddNote, Keys: []string{"n"}, Help: "Note"},
+                {Action: AddQuote, Keys: []string{"\""}, Help: "Quote"},
+                {Action: NotesOrder, Keys: []string{"o"}, Help: "Notes by page/time"},
+                {Action: AddBookmark, Keys: []string{"m"}, Help: "Bookmark"},
+                {Action: Timeline, Keys: []string{"t"}, Help: "Timeline"},
+                {Action: Back, Keys: []string{"esc", "backspace"}, Help: "Back"},
+            }},
+            types.ViewTimeline: {Name: "Timeline", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up"}, Help: "Scroll up"},
+                {Action: ItemNext, Keys: []string{"down"}, Help: "Scroll down"},
+                {Action: Back, Keys: []string{"esc", "backspace"}, Help: "Back"},
+            }},
+            types.ViewNotes: {Name: "Notes", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up"}, Help: "Previous note"},
+                {Action: ItemNext, Keys: []string{"down"}, Help: "Next note"},
+                {Action: OpenBook, Keys: []string{"enter"}, Help: "Open book"},
+                {Action: Search, Keys: []string{"/"}, Help: "Search"},
+                {Action: Export, Keys: []string{"x"}, Help: "Export for Obsidian"},
+            }},
+        },
+        Modes: map[Mode]Scope{
+            ModeSearch: {Name: "Search", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up"}, Help: "Previous result"},
+                {Action: ItemNext, Keys: []string{"down"}, Help: "Next result"},
+                {Action: OpenBook, Keys: []string{"enter"}, Help: "Open"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Close search"},
+            }},
+            ModeReview: {Name: "Review", Bindings: []Binding{
+                {Action: NextField, Keys: []string{"tab"}, Help: "Switch field"},
+                {Action: PrevField, Keys: []string{"shift+tab"}, Help: "Switch field"},
+                {Action: RatingDown, Keys: []string{"left", "down", "-"}, Help: "Lower rating"},
+                {Action: RatingUp, Keys: []string{"right", "up", "+"}, Help: "Higher rating"},
+                {Action: Submit, Keys: []string{"enter"}, Help: "Submit"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeNote: {Name: "Note", Bindings: []Binding{
+                {Action: NextField, Keys: []string{"tab"}, Help: "Text/page"},
+                {Action: PrevField, Keys: []string{"shift+tab"}, Help: "Text/page"},
+                {Action: Submit, Keys: []string{"enter"}, Help: "Save"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeNotesSearch: {Name: "Notes search", Bindings: []Binding{
+                {Action: Submit, Keys: []string{"enter"}, Help: "Done"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Done"},
+            }},
+            ModeImportPath: {Name: "Import file", Bindings: []Binding{
+                {Action: Submit, Keys: []string{"enter"}, Help: "Read file"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeImportShelves: {Name: "Import shelves", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up", "k"}, Help: "Previous shelf"},
+                {Action: ItemNext, Keys: []string{"down", "j"}, Help: "Next shelf"},
+                {Action: ChoicePrev, Keys: []string{"left", "h"}, Help: "Map to previous"},
+                {Action: ChoiceNext, Keys: []string{"right", "l", " "}, Help: "Map to next"},
+                {Action: Submit, Keys: []string{"enter"}, Help: "Continue"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeImportResolve: {Name: "Import matches", Bindings: []Binding{
+                {Action: ItemPrev, Keys: []string{"up", "k"}, Help: "Previous choice"},
+                {Action: ItemNext, Keys: []string{"down", "j"}, Help: "Next choice"},
+                {Action: Submit, Keys: []string{"enter"}, Help: "Pick"},
+                {Action: Skip, Keys: []string{"s"}, Help: "Skip book"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeImportConfirm: {Name: "Import", Bindings: []Binding{
+                {Action: Submit, Keys: []string{"enter"}, Help: "Import"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Cancel"},
+            }},
+            ModeImportDone: {Name: "Import done", Bindings: []Binding{
+                {Action: Submit, Keys: []string{"enter"}, Help: "Back to the library"},
+                {Action: Cancel, Keys: []string{"esc"}, Help: "Back to the library"},
+            }},
+        },
+    }
+}
+
+// presetKeys are extra keys layered on top of the defaults by each preset.
+var presetKeys = map[string]map[Action][]string{
+    "vim": {
+        BookPrev:  {"h"},
+        BookNext:  {"l"},
+        ShelfPrev: {"k"},
+        ShelfNext: {"j"},
+        ItemPrev:  {"k"},
+        ItemNext:  {"j"},
+        PagePrev:  {"h"},
+        PageNext:  {"l"},
+        NavNext:   {"L"},
+        NavPrev:   {"H"},
+    },
+    "emacs": {
+        BookPrev:  {"ctrl+b"},
+        BookNext:  {"ctrl+f"},
+        ShelfPrev: {"ctrl+p"},
+        ShelfNext: {"ctrl+n"},
+        ItemPrev:  {"ctrl+p"},
+        ItemNext:  {"ctrl+n"},
+        PagePrev:  {"ctrl+b"},
+        PageNext:  {"ctrl+f"},
+        NavNext:   {"alt+f"},
+        NavPrev:   {"alt+b"},
+        Back:      {"ctrl+g"},
+        Quit:      {"ctrl+x"},
+    },
+}
+
+// New builds a key map from a preset and user overrides.
+// Overrides map an action name to a comma separated list of keys and
+// replace the keys of that action in every scope it appears in.
+func New(preset string, overrides map[string]string) (KeyMap, error) {
+    km := Default()
+
+    switch preset {
+    case "", "default":
+    case "vim", "emacs":
+        for action, keys := range presetKeys[preset] {
+            km.update(action, func(b *Binding) {
+                extra := []string{}
+                for _, key := range keys {
+                    if !contains(b.Keys, key) {
+                        extra = append(extra, key)
+                    }
+                }
+                b.Keys = append(append([]string{}, b.Keys...), extra...)
+            })
+        }
+    default:
+        return km, fmt.Errorf("unknown keymap preset %q (expected one of %s)", preset, strings.Join(Presets, ", "))
+    }
+
+    var problems []string
+    for name, value := range overrides {
+        action := Action(name)
+        if !km.has(action) {
+            problems = append(problems, fmt.Sprintf("unknown action %q", name))
+            continue
+        }
+
+        var keys []string
+        for _, key := range strings.Split(value, ",") {
+            if key = strings.TrimSpace(key); key != "" {
+                keys = append(keys, key)
+            }
+        }
+        if len(keys) == 0 {
+            problems = append(problems, fmt.Sprintf("action %q has no keys", name))
+            continue
+        }
+
+        km.update(action, func(b *Binding) { b.Keys = keys })
+    }
+    if len(problems) > 0 {
+        sort.Strings(problems)
+        return km, fmt.Errorf("keymap: %s", strings.Join(problems, "; "))
+    }
+
+    return km, nil
+}
+
+// Actions lists every action name that can be overridden.
+func Actions() []string {
+    seen := map[Action]bool{}
+    var names []string
+    km := Default()
+    scopes := km.Sections(types.ViewLogin)
+    for _, scope := range km.Modes {
+        scopes = append(scopes, scope)
+    }
+    for _, scope := range scopes {
+        for _, b := range scope.Bindings {
+            if !seen[b.Action] {
+                seen[b.Action] = true
+                names = append(names, string(b.Action))
+            }
+        }
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Match resolves a key press in a view. View bindings shadow global ones.
+func (k KeyMap) Match(view types.View, key string) Action {
+    if scope, ok := k.Views[view]; ok {
+        if action := scope.match(key); action != None {
+            return action
+        }
+    }
+    return k.Global.match(key)
+}
+
+// MatchMode resolves a key press while a mode is open. Only the mode's own
+// bindings count, the view's and the global ones wait until it closes.
+func (k KeyMap) MatchMode(mode Mode, key string) Action {
+    return k.Modes[mode].match(key)
+}
+
+// Keys are the keys that trigger an action in a view, as Match sees them.
+func (k KeyMap) Keys(view types.View, action Action) []string {
+    for _, b := range k.Views[view].Bindings {
+        if b.Action == action {
+            return b.Keys
+        }
+    }
+    for _, b := range k.Global.Bindings {
+        if b.Action == action {
+            return b.Keys
+        }
+    }
+    return nil
+}
+
+// ShortHelp is the one-line help shown in the footer for a view.
+func (k KeyMap) ShortHelp(view types.View) string {
+    var parts []string
+    if scope, ok := k.Views[view]; ok {
+        for _, b := range scope.Bindings {
+            parts = append(parts, b.String())
+        }
+    }
+    for _, b := range k.Global.Bindings {
+        if b.Action == Help || b.Action == Quit {
+            parts = append(parts, b.String())
+        }
+    }
+    return strings.Join(parts, " | ")
+}
+
+// ModeHelp is the one-line help shown in the footer while a mode is open.
+// Actions bound under the same help text share one entry, e.g. "Tab/Shift+Tab: Switch field".
+func (k KeyMap) ModeHelp(mode Mode) string {
+    var parts []string
+    var keys [][]string
+    for _, b := range k.Modes[mode].Bindings {
+        found := false
+        for i, p := range parts {
+            if p == b.Help {
+                keys[i] = append(keys[i], b.Keys...)
+                found = true
+            }
+        }
+        if !found {
+            parts = append(parts, b.Help)
+            keys = append(keys, append([]string{}, b.Keys...))
+        }
+    }
+    for i := range parts {
+        parts[i] = KeyLabel(keys[i]) + ": " + parts[i]
+    }
+    return strings.Join(parts, " | ")
+}
+
+// Sections returns the scopes to show in the help overlay, the given view first.
+func (k KeyMap) Sections(view types.View) []Scope {
+    var sections []Scope
+    if scope, ok := k.Views[view]; ok {
+        sections = append(sections, scope)
+    }
+    sections = append(sections, k.Global)
+
+    views := make([]types.View, 0, len(k.Views))
+    for v := range k.Views {
+        if v != view {
+            views = append(views, v)
+        }
+    }
+    sort.Slice(views, func(i, j int) bool { return views[i] < views[j] })
+    for _, v := range views {
+        sections = append(sections, k.Views[v])
+    }
+    return sections
+}
+
+func (b Binding) String() string {
+    return KeyLabel(b.Keys) + ": " + b.Help
+}
+
+// KeyLabel renders keys the way the footer shows them, e.g. "←/h".
+func KeyLabel(keys []string) string {
+    labels := make([]string, 0, len(keys))
+    for _, key := range keys {
+        labels = append(labels, keyName(key))
+    }
+    return strings.Join(labels, "/")
+}
+
+func keyName(key string) string {
+    switch key {
+    case "left":
+        return "←"
+    case "right":
+        return "→"
+    case "up":
+        return "↑"
+    case "down":
+        return "↓"
+    case "enter":
+        return "Enter"
+    case "esc":
+        return "Esc"
+    case "tab":
+        return "Tab"
+    case "shift+tab":
+        return "Shift+Tab"
+    case "backspace":
+        return "Backspace"
+    case " ":
+        return "Space"
+    }
+    return key
+}
+
+func (s Scope) match(key string) Action {
+    for _, b := range s.Bindings {
+        for _, k := range b.Keys {
+            if k == key {
+                return b.Action
+            }
+        }
+    }
+    return None
+}
+
+func contains(keys []string, key string) bool {
+    for _, k := range keys {
+        if k == key {
+            return true
+        }
+    }
+    return false
+}
+
+func (k *KeyMap) has(action Action) bool {
+    found := false
+    k.update(action, func(*Binding) { found = true })
+    return found
+}
+
+func (k *KeyMap) update(action Action, apply func(*Binding)) {
+    for i := range k.Global.Bindings {
+        if k.Global.Bindings[i].Action == action {
+            apply(&k.Global.Bindings[i])
+        }
+    }
+    for view, scope := range k.Views {
+        for i := range scope.Bindings {
+            if scope.Bindings[i].Action == action {
+                apply(&scope.Bindings[i])
+            }
+        }
+        k.Views[view] = scope
+    }
+    for mode, scope := range k.Modes {
+        for i := range scope.Bindings {
+            if scope.Bindings[i].Action == action {
+                apply(&scope.Bindings[i])
+            }
+        }
+        k.Modes[mode] = scope
+    }
+}
@@ -0,0 +1,160 @@
+package keymap
+
+import (
+    "strings"
+    "testing"
+
+    "tui/types"
+)
+
+func TestMatch(t *testing.T) {
+    tests := []struct {
+        name      string
+        preset    string
+        overrides map[string]string
+        view      types.View
+        key       string
+        want      Action
+    }{
+        {"view binding", "", nil, types.ViewLibrary, "left", BookPrev},
+        {"global binding", "", nil, types.ViewLibrary, "q", Quit},
+        {"view shadows global", "", nil, types.ViewLogin, "tab", NextField},
+        {"same key, other view", "", nil, types.ViewBookDetails, "r", StartReading},
+        {"unbound", "", nil, types.ViewLibrary, "h", None},
+
+        {"vim adds keys", "vim", nil, types.ViewLibrary, "h", BookPrev},
+        {"vim keeps the arrows", "vim", nil, types.ViewLibrary, "left", BookPrev},
+        {"vim per view", "vim", nil, types.ViewLibrary, "k", ShelfPrev},
+        {"vim global", "vim", nil, types.ViewProfile, "L", NavNext},
+        {"emacs", "emacs", nil, types.ViewBookDetails, "ctrl+g", Back},
+
+        {"override replaces", "", map[string]string{"quit": "ctrl+q"}, types.ViewLibrary, "q", None},
+        {"override binds", "", map[string]string{"quit": "ctrl+q"}, types.ViewLibrary, "ctrl+q", Quit},
+        {"override lists keys", "", map[string]string{"book_next": " n, right "}, types.ViewLibrary, "n", BookNext},
+        {"override in every scope", "", map[string]string{"open_book": "o"}, types.ViewNotes, "o", OpenBook},
+        {"override replaces preset keys", "vim", map[string]string{"book_prev": "a"}, types.ViewLibrary, "h", None},
+        {"override on top of a preset", "vim", map[string]string{"book_prev": "a"}, types.ViewLibrary, "l", BookNext},
+    }
+    for _, tt := range tests {
+        km, err := New(tt.preset, tt.overrides)
+        if err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        if got := km.Match(tt.view, tt.key); got != tt.want {
+            t.Errorf("%s: Match(%v, %q) = %q, want %q", tt.name, tt.view, tt.key, got, tt.want)
+        }
+    }
+}
+
+func TestNewErrors(t *testing.T) {
+    tests := []struct {
+        preset    string
+        overrides map[string]string
+        err       string
+    }{
+        {"helix", nil, `unknown keymap preset "helix"`},
+        {"", map[string]string{"jump": "j"}, `unknown action "jump"`},
+        {"", map[string]string{"quit": " , "}, `action "quit" has no keys`},
+        {"", map[string]string{"zap": "z", "quit": ""}, `action "quit" has no keys; unknown action "zap"`},
+    }
+    for _, tt := range tests {
+        _, err := New(tt.preset, tt.overrides)
+        if err == nil || !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("New(%q, %v) = %v, want %q", tt.preset, tt.overrides, err, tt.err)
+        }
+    }
+}
+
+// Overriding one key map leaves the defaults of the next one alone.
+func TestNewDoesNotShareBindings(t *testing.T) {
+    if _, err := New("vim", map[string]string{"quit": "x"}); err != nil {
+        t.Fatal(err)
+    }
+    km, _ := New("", nil)
+    if km.Match(types.ViewLibrary, "q") != Quit || km.Match(types.ViewLibrary, "h") != None {
+        t.Fatal("an earlier New changed the defaults")
+    }
+}
+
+func TestHelp(t *testing.T) {
+    km, _ := New("vim", map[string]string{"help": "f1"})
+    help := km.ShortHelp(types.ViewLibrary)
+    for _, want := range []string{"←/h: Previous book", "→/l: Next book", "f1: Help", "q/ctrl+c: Quit"} {
+        if !strings.Contains(help, want) {
+            t.Errorf("%q not in %q", want, help)
+        }
+    }
+    if strings.Contains(help, "Next view") {
+        t.Errorf("footer shows every global binding: %q", help)
+    }
+
+    sections := km.Sections(types.ViewLibrary)
+    if sections[0].Name != "Library" || sections[1].Name != "Global" || len(sections) != len(km.Views)+1 {
+        t.Errorf("sections start with %q, %q of %d", sections[0].Name, sections[1].Name, len(sections))
+    }
+
+    labels := []struct {
+        keys []string
+        want string
+    }{
+        {[]string{"up", "k"}, "↑/k"},
+        {[]string{"shift+tab"}, "Shift+Tab"},
+        {[]string{"esc", "backspace"}, "Esc/Backspace"},
+        {nil, ""},
+    }
+    for _, tt := range labels {
+        if got := KeyLabel(tt.keys); got != tt.want {
+            t.Errorf("KeyLabel(%q) = %q, want %q", tt.keys, got, tt.want)
+        }
+    }
+}
+
+func TestModes(t *testing.T) {
+    km, _ := New("vim", map[string]string{"cancel": "ctrl+g"})
+    tests := []struct {
+        mode Mode
+        key  string
+        want Action
+    }{
+        {ModeSearch, "ctrl+g", Cancel},
+        {ModeSearch, "esc", None},
+        {ModeSearch, "k", ItemPrev},
+        {ModeSearch, "q", None}, // global bindings wait until the mode closes
+        {ModeReview, "+", RatingUp},
+        {ModeImportShelves, " ", ChoiceNext},
+        {ModeImportDone, "ctrl+g", Cancel},
+    }
+    for _, tt := range tests {
+        if got := km.MatchMode(tt.mode, tt.key); got != tt.want {
+            t.Errorf("MatchMode(%q, %q) = %q, want %q", tt.mode, tt.key, got, tt.want)
+        }
+    }
+
+    helps := []struct {
+        mode Mode
+        want string
+    }{
+        {ModeNote, "Tab/Shift+Tab: Text/page | Enter: Save | ctrl+g: Cancel"},
+        {ModeNotesSearch, "Enter/ctrl+g: Done"},
+        // The preset adds no key a mode already had
+        {ModeImportShelves, "↑/k: Previous shelf | ↓/j: Next shelf | ←/h: Map to previous | →/l/Space: Map to next | Enter: Continue | ctrl+g: Cancel"},
+    }
+    for _, tt := range helps {
+        if got := km.ModeHelp(tt.mode); got != tt.want {
+            t.Errorf("ModeHelp(%q) = %q, want %q", tt.mode, got, tt.want)
+        }
+    }
+}
+
+func TestActions(t *testing.T) {
+    actions := Actions()
+    for _, want := range []string{"quit", "book_next", "submit", "timeline", "cancel", "rating_up"} {
+        found := false
+        for _, a := range actions {
+            found = found || a == want
+        }
+        if !found {
+            t.Errorf("%q not in Actions() = %q", want, actions)
+        }
+    }
+}
@@ -1,20 +1,45 @@
 package main
 
 import (
+    "errors"
+    "flag"
     "fmt"
     "os"
     tea "github.com/charmbracelet/bubbletea"
+    "tui/api"
     "tui/app"
+    "tui/cli"
+    "tui/config"
 )
 
 func main() {
-    // Set API URL from environment or use default
-    apiURL := "http://localhost:8000"
-    if url := os.Getenv("BOOKTRACKER_API_URL"); url != "" {
-        apiURL = url
+    // Defaults -> config file -> environment -> flags
+    cfg, err := config.Load(os.Args[1:])
+    if errors.Is(err, flag.ErrHelp) {
+        os.Exit(0)
+    }
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        os.Exit(2)
+    }
+
+    if cfg.PrintConfig {
+        cfg.Print(os.Stdout)
+        return
     }
 
-    m := app.NewModel(apiURL)
+    service, err := newService(cfg)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        os.Exit(1)
+    }
+
+    // Subcommands run non-interactively, the TUI is the default
+    if len(cfg.Args) > 0 {
+        os.Exit(cli.Run(cfg, service, cfg.Args, os.Stdout, os.Stderr))
+    }
+
+    m := app.NewModel(cfg, service)
 
     p := tea.NewProgram(m,
         tea.WithAltScreen(),
@@ -26,4 +51,22 @@ func main() {
         fmt.Printf("Error: %v\n", err)
         os.Exit(1)
     }
-}
\ No newline at end of file
+}
+
+
+// newService picks the backend implementation named in the config.
+func newService(cfg config.Config) (api.Service, error) {
+    switch cfg.Backend {
+    case "demo":
+        return api.NewFake(), nil
+    case "local":
+        return api.OpenLocal(cfg.Database)
+    default:
+        client := api.NewClient(cfg.APIURL, cfg.Timeout)
+        client.StrictDecode = cfg.StrictDecode
+        if !cfg.HTTPCache {
+            client.Cache = nil
+        }
+        return client, nil
+    }
+}
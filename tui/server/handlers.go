@@ -0,0 +1,392 @@
+package server
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "tui/api"
+    "tui/types"
+)
+
+// args says where FastAPI reads an endpoint's arguments from and which it
+// wants: scalar parameters come from the query string, a Pydantic model
+// from the JSON body, never both. Fields are "name", "name:int", and end
+// in "?" when they have a default.
+type args struct {
+    in     string
+    fields []string
+}
+
+func query(fields ...string) args { return args{in: "query", fields: fields} }
+func body(fields ...string) args  { return args{in: "body", fields: fields} }
+
+// fieldError is one entry of a FastAPI 422 answer.
+type fieldError struct {
+    Loc  []string `json:"loc"`
+    Msg  string   `json:"msg"`
+    Type string   `json:"type"`
+}
+
+// request holds the path parameters and the arguments read from where the
+// endpoint takes them. Arguments sent anywhere else are ignored, as
+// FastAPI ignores them, so a client sending them there fails here too.
+type request struct {
+    params map[string]string
+    values map[string]interface{}
+}
+
+// newRequest reads an endpoint's arguments and checks them like FastAPI
+// does, returning the problems for a 422 when there are any.
+func newRequest(r *http.Request, params map[string]string, a args) (*request, []fieldError, error) {
+    req := &request{params: params, values: map[string]interface{}{}}
+
+    switch a.in {
+    case "query":
+        for key, vals := range r.URL.Query() {
+            if len(vals) > 0 {
+                req.values[key] = vals[0]
+            }
+        }
+    case "body":
+        data, err := io.ReadAll(r.Body)
+        if err != nil {
+            return nil, nil, err
+        }
+        if len(bytes.TrimSpace(data)) == 0 {
+            return nil, []fieldError{{Loc: []string{"body"}, Msg: "field required", Type: "value_error.missing"}}, nil
+        }
+        if err := json.Unmarshal(data, &req.values); err != nil {
+            return nil, []fieldError{{Loc: []string{"body"}, Msg: "invalid JSON body: " + err.Error(), Type: "value_error.jsondecode"}}, nil
+        }
+    }
+
+    var problems []fieldError
+    for _, field := range a.fields {
+        name, optional := strings.CutSuffix(field, "?")
+        name, kind, _ := strings.Cut(name, ":")
+        v, ok := req.values[name]
+        switch {
+        case !ok || v == nil:
+            if !optional {
+                problems = append(problems, fieldError{Loc: []string{a.in, name}, Msg: "field required", Type: "value_error.missing"})
+            }
+        case kind == "int" && !isInt(v):
+            problems = append(problems, fieldError{Loc: []string{a.in, name}, Msg: "value is not a valid integer", Type: "type_error.integer"})
+        }
+    }
+    return req, problems, nil
+}
+
+// isInt reports whether a query value or a JSON value is a whole number.
+func isInt(v interface{}) bool {
+    switch v := v.(type) {
+    case string:
+        _, err := strconv.Atoi(v)
+        return err == nil
+    case float64:
+        return v == float64(int(v))
+    }
+    return false
+}
+
+func (r *request) str(name string) string {
+    if v, ok := r.params[name]; ok {
+        return v
+    }
+    switch v := r.values[name].(type) {
+    case string:
+        return v
+    case nil:
+        return ""
+    default:
+        return fmt.Sprint(v)
+    }
+}
+
+func (r *request) int(name string) int {
+    n, _ := strconv.Atoi(r.str(name))
+    return n
+}
+
+// fail maps errors from the fake onto HTTP answers.
+func fail(w http.ResponseWriter, err error) {
+    var apiErr *api.APIError
+    if errors.As(err, &apiErr) {
+        writeJSON(w, apiErr.Status, map[string]string{"detail": apiErr.Detail})
+        return
+    }
+    writeJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+}
+
+func bookJSON(book types.Book) map[string]interface{} {
+    var rating interface{}
+    if book.Rating > 0 {
+        rating = book.Rating
+    }
+    return map[string]interface{}{
+        "id":         book.ID,
+        "name":       book.Name,
+        "author":     book.Author,
+        "year":       book.Year,
+        "language":   book.Language,
+        "publisher":  book.Publisher,
+        "pages":      book.Pages,
+        "avg_rating": rating,
+    }
+}
+
+func userJSON(user types.User) map[string]interface{} {
+    friends := append([]string{}, user.Friends...)
+    libraries := []string{}
+    for _, lib := range user.Libraries {
+        libraries = append(libraries, lib.Name)
+    }
+    return map[string]interface{}{
+        "username":     user.Username,
+        "display_name": user.DisplayName,
+        "friends":      friends,
+        "libraries":    libraries,
+    }
+}
+
+// session finds the reading session row for a user's book, if still active.
+func (s *Server) session(username string, bookID int) map[string]interface{} {
+    s.state.SetToken(username)
+    sessions, _ := s.state.GetActiveReading()
+    for _, session := range sessions {
+        if session["book_id"] == bookID {
+            return session
+        }
+    }
+    return nil
+}
+
+func (s *Server) health(w http.ResponseWriter, r *request) {
+    writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *request) {
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "openapi": "3.1.0",
+        "info":    map[string]string{"title": "Book Tracker stand-in", "version": "0.1.0"},
+    })
+}
+
+// Users
+
+func (s *Server) login(w http.ResponseWriter, r *request) {
+    token, err := s.state.Login(r.str("username"), r.str("password"))
+    if err != nil {
+        writeError(w, http.StatusUnauthorized)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"token": token})
+}
+
+func (s *Server) me(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("token"))
+    user, err := s.state.GetCurrentUser()
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, userJSON(user))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *request) {
+    user, err := s.state.GetUser(r.str("u"))
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, userJSON(user))
+}
+
+func (s *Server) addFriend(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("u"))
+    if err := s.state.AddFriend(r.str("v")); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "friends"})
+}
+
+// Books
+
+// listBooks serves the whole catalog, or a page of it with ?offset= and
+// ?limit=. The size of the catalog goes in X-Total-Count either way.
+func (s *Server) listBooks(w http.ResponseWriter, r *request) {
+    books, err := s.state.ListBooks()
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    w.Header().Set("X-Total-Count", strconv.Itoa(len(books)))
+    if limit := r.int("limit"); limit > 0 {
+        books = api.PageOf(books, r.int("offset"), limit).Books
+    }
+    out := make([]map[string]interface{}, 0, len(books))
+    for _, book := range books {
+        out = append(out, bookJSON(book))
+    }
+    writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getBook(w http.ResponseWriter, r *request) {
+    data, err := s.state.GetBookDetails(r.int("id"))
+    if err != nil {
+        fail(w, err)
+        return
+    }
+
+    out := bookJSON(data.Book)
+    reviews := []map[string]interface{}{}
+    for _, review := range data.Reviews {
+        reviews = append(reviews, map[string]interface{}{
+            "user":   review.User,
+            "rating": review.Rating,
+            "text":   review.Text,
+            "likes":  review.Likes,
+        })
+    }
+    out["reviews"] = reviews
+    writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) addReview(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("username"))
+    rating := r.int("rating")
+    if err := s.state.AddReview(r.int("id"), r.str("text"), rating); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "rating": rating})
+}
+
+// Libraries
+
+func (s *Server) createLibrary(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("username"))
+    name := r.str("name")
+    id, err := s.state.CreateLibrary(name)
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "name": name})
+}
+
+func (s *Server) addBookToLibrary(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("username"))
+    if err := s.state.AddBookToLibrary(r.int("id"), r.int("book_id"), r.str("shelf")); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "added"})
+}
+
+func (s *Server) moveBook(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("username"))
+    if err := s.state.MoveBook(r.int("id"), r.int("book_id"), r.str("shelf")); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "moved"})
+}
+
+func (s *Server) listLibraries(w http.ResponseWriter, r *request) {
+    libraries, err := s.state.GetUserLibraries(r.str("u"))
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    out := make([]map[string]interface{}, 0, len(libraries))
+    for _, lib := range libraries {
+        out = append(out, map[string]interface{}{"id": lib.ID, "name": lib.Name, "shelves": lib.Books})
+    }
+    writeJSON(w, http.StatusOK, out)
+}
+
+// Reading
+
+func (s *Server) startReading(w http.ResponseWriter, r *request) {
+    username, bookID := r.str("username"), r.int("book_id")
+    s.state.SetToken(username)
+    if err := s.state.StartReading(bookID); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, s.session(username, bookID))
+}
+
+func (s *Server) turnPage(w http.ResponseWriter, r *request) {
+    username, bookID := r.str("username"), r.int("book_id")
+    count := r.int("count")
+    if count == 0 {
+        count = 1
+    }
+
+    s.state.SetToken(username)
+    if err := s.state.TurnPage(bookID, r.str("direction"), count); err != nil {
+        fail(w, err)
+        return
+    }
+
+    session := s.session(username, bookID)
+    if session == nil {
+        // Reaching the last page ends the session, report where it ended
+        book, _ := s.state.GetBook(bookID)
+        session = map[string]interface{}{"user": username, "book_id": bookID, "current_page": book.Pages}
+    }
+    writeJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) listReading(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("u"))
+    sessions, err := s.state.GetActiveReading()
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, sessions)
+}
+
+// Recommendations
+
+func (s *Server) recommend(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("from_user"))
+    if err := s.state.RecommendBook(r.str("to_user"), r.int("book_id"), r.str("message")); err != nil {
+        fail(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+func (s *Server) listRecommendations(w http.ResponseWriter, r *request) {
+    s.state.SetToken(r.str("u"))
+    recommendations, err := s.state.GetRecommendations()
+    if err != nil {
+        fail(w, err)
+        return
+    }
+    out := make([]map[string]interface{}, 0, len(recommendations))
+    for _, rec := range recommendations {
+        var message interface{}
+        if rec.Message != "" {
+            message = rec.Message
+        }
+        out = append(out, map[string]interface{}{
+            "from":    rec.From,
+            "book":    rec.Book,
+            "message": message,
+            "date":    rec.Date,
+        })
+    }
+    writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,252 @@
+// Package server is a stand-in for the FastAPI backend, written in Go.
+//
+// It serves the routes api.Client uses from in-memory state (an api.Fake)
+// and can inject latency and failures, so the client can be exercised
+// end to end without Python:
+//
+//    srv := server.New(server.Options{})
+//    ts := httptest.NewServer(srv)
+//    client := api.NewClient(ts.URL, time.Second)
+//
+//    srv.Inject("GET /books", server.Fault{Status: 500, Times: 1})
+package server
+
+import (
+    "bytes"
+    "crypto/sha1"
+    "encoding/json"
+    "fmt"
+    "math/rand"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "tui/api"
+)
+
+// Options configure behaviour applied to every request.
+type Options struct {
+    Latency       time.Duration // added before every response
+    FailureRate   float64       // share of requests, 0..1, answered with FailureStatus
+    FailureStatus int           // defaults to 500
+    Seed          int64         // seeds the failure dice, so runs are repeatable
+}
+
+// Fault is injected into one route until it has fired Times times (0 = forever).
+// A Delay longer than the client timeout reproduces a timeout.
+type Fault struct {
+    Status int
+    Delay  time.Duration
+    Times  int
+}
+
+type Server struct {
+    opts Options
+
+    mu     sync.Mutex // serialises requests, the fake's token is shared state
+    state  *api.Fake
+    rand   *rand.Rand
+    faults map[string]*Fault
+}
+
+func New(opts Options) *Server {
+    if opts.FailureStatus == 0 {
+        opts.FailureStatus = http.StatusInternalServerError
+    }
+    return &Server{
+        opts:   opts,
+        state:  api.NewFake(),
+        rand:   rand.New(rand.NewSource(opts.Seed)),
+        faults: map[string]*Fault{},
+    }
+}
+
+// Inject adds a fault to a route, named like "GET /books/{id}".
+func (s *Server) Inject(route string, fault Fault) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    f := fault
+    s.faults[route] = &f
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.faults = map[string]*Fault{}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    route, params, handler, a := s.route(r.Method, r.URL.Path)
+
+    delay, status := s.plan(route)
+    if delay > 0 {
+        select {
+        case <-time.After(delay):
+        case <-r.Context().Done():
+            return
+        }
+    }
+    if status != 0 {
+        writeError(w, status)
+        return
+    }
+
+    if handler == nil {
+        writeError(w, http.StatusNotFound)
+        return
+    }
+
+    req, problems, err := newRequest(r, params, a)
+    if err != nil {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+        return
+    }
+    if len(problems) > 0 {
+        writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"detail": problems})
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if r.Method != http.MethodGet {
+        handler(w, req)
+        return
+    }
+
+    // GETs carry an ETag, a client sending it back gets a bodiless 304
+    // while nothing changed
+    rec := &recorder{header: http.Header{}, status: http.StatusOK}
+    handler(rec, req)
+    etag := fmt.Sprintf(`"%x"`, sha1.Sum(rec.body.Bytes()))
+    for name, values := range rec.header {
+        w.Header()[name] = values
+    }
+    if rec.status == http.StatusOK {
+        w.Header().Set("ETag", etag)
+        if r.Header.Get("If-None-Match") == etag {
+            w.WriteHeader(http.StatusNotModified)
+            return
+        }
+    }
+    w.WriteHeader(rec.status)
+    w.Write(rec.body.Bytes())
+}
+
+// recorder holds a handler's answer back until its ETag is known.
+type recorder struct {
+    header http.Header
+    status int
+    body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header         { return r.header }
+func (r *recorder) WriteHeader(status int)      { r.status = status }
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+
+// plan decides the latency and forced status for one request.
+func (s *Server) plan(route string) (time.Duration, int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delay := s.opts.Latency
+    status := 0
+
+    if fault, ok := s.faults[route]; ok {
+        delay += fault.Delay
+        status = fault.Status
+        if fault.Times > 0 {
+            fault.Times--
+            if fault.Times == 0 {
+                delete(s.faults, route)
+            }
+        }
+    }
+
+    if status == 0 && s.opts.FailureRate > 0 && s.rand.Float64() < s.opts.FailureRate {
+        status = s.opts.FailureStatus
+    }
+
+    return delay, status
+}
+
+type handlerFunc func(w http.ResponseWriter, r *request)
+
+// route matches a path against the backend's routes. It returns the route
+// name used for fault injection, the path parameters, the handler and the
+// arguments it takes, declared as api.py declares them.
+func (s *Server) route(method, path string) (string, map[string]string, handlerFunc, args) {
+    segments := strings.Split(strings.Trim(path, "/"), "/")
+
+    routes := []struct {
+        pattern string
+        handler handlerFunc
+        args    args
+    }{
+        {"GET /health", s.health, query()},
+        {"GET /openapi.json", s.openAPI, query()},
+        {"POST /auth/login", s.login, body("username", "password")},
+        {"GET /me", s.me, query("token")},
+        {"GET /books", s.listBooks, query("offset:int?", "limit:int?")},
+        {"GET /books/{id}", s.getBook, query()},
+        {"POST /books/{id}/reviews", s.addReview, body("username", "text", "rating:int")},
+        {"POST /libraries", s.createLibrary, query("username", "name")},
+        {"POST /libraries/{id}/books", s.addBookToLibrary, query("username", "book_id:int", "shelf")},
+        {"POST /libraries/{id}/move", s.moveBook, query("username", "book_id:int", "shelf")},
+        {"POST /reading/start", s.startReading, body("username", "book_id:int")},
+        {"POST /reading/turn", s.turnPage, body("username", "book_id:int", "direction", "count:int?")},
+        {"POST /recommend", s.recommend, body("from_user", "to_user", "book_id:int", "message?")},
+        {"GET /users/{u}", s.getUser, query()},
+        {"GET /users/{u}/libraries", s.listLibraries, query()},
+        {"GET /users/{u}/reading", s.listReading, query()},
+        {"GET /users/{u}/recommendations", s.listRecommendations, query()},
+        {"POST /users/{u}/friends/{v}", s.addFriend, query()},
+    }
+
+    wrongMethod := ""
+    for _, rt := range routes {
+        m, p, _ := strings.Cut(rt.pattern, " ")
+        parts := strings.Split(strings.Trim(p, "/"), "/")
+        if len(parts) != len(segments) {
+            continue
+        }
+
+        params := map[string]string{}
+        matched := true
+        for i, part := range parts {
+            if strings.HasPrefix(part, "{") {
+                params[strings.Trim(part, "{}")] = segments[i]
+            } else if part != segments[i] {
+                matched = false
+                break
+            }
+        }
+        if !matched {
+            continue
+        }
+        if m != method {
+            wrongMethod = rt.pattern
+            continue
+        }
+        return rt.pattern, params, rt.handler, rt.args
+    }
+
+    if wrongMethod != "" {
+        return wrongMethod, nil, func(w http.ResponseWriter, r *request) {
+            writeError(w, http.StatusMethodNotAllowed)
+        }, query()
+    }
+    return method + " " + path, nil, nil, query()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers like FastAPI's HTTPException without a detail.
+func writeError(w http.ResponseWriter, status int) {
+    writeJSON(w, status, map[string]string{"detail": http.StatusText(status)})
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "tui/api"
+)
+
+// start runs the stand-in behind httptest and logs a client in as the demo user.
+func start(t *testing.T, opts Options) (*Server, *api.Client, string) {
+    t.Helper()
+    srv := New(opts)
+    ts := httptest.NewServer(srv)
+    t.Cleanup(ts.Close)
+
+    client := api.NewClient(ts.URL, 2*time.Second)
+    if _, err := client.Login(api.DemoUsername, api.DemoPassword); err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    return srv, client, ts.URL
+}
+
+func TestClientEndToEnd(t *testing.T) {
+    _, client, _ := start(t, Options{})
+
+    user, err := client.GetCurrentUser()
+    if err != nil || user.Username != api.DemoUsername {
+        t.Fatalf("GetCurrentUser = %+v, %v", user, err)
+    }
+
+    books, err := client.ListBooks()
+    if err != nil || len(books) != len(api.SeedBooks) {
+        t.Fatalf("ListBooks = %d books, %v", len(books), err)
+    }
+    page, err := client.ListBooksPage(10, 5)
+    if err != nil || len(page.Books) != 5 || page.Books[0].ID != 11 || page.Total != len(api.SeedBooks) {
+        t.Fatalf("ListBooksPage = %+v, %v", page, err)
+    }
+
+    if err := client.AddReview(2, "Gripping", 4); err != nil {
+        t.Fatalf("AddReview: %v", err)
+    }
+    details, err := client.GetBookDetails(2)
+    if err != nil || len(details.Reviews) != 1 || details.Reviews[0].Text != "Gripping" {
+        t.Fatalf("GetBookDetails = %+v, %v", details, err)
+    }
+
+    libID, err := client.CreateLibrary("Holidays")
+    if err != nil || libID == 0 {
+        t.Fatalf("CreateLibrary = %d, %v", libID, err)
+    }
+    if err := client.AddBookToLibrary(libID, 4, "to_read"); err != nil {
+        t.Fatalf("AddBookToLibrary: %v", err)
+    }
+    if err := client.MoveBook(libID, 4, "read"); err != nil {
+        t.Fatalf("MoveBook: %v", err)
+    }
+    libraries, err := client.GetUserLibraries(api.DemoUsername)
+    if err != nil {
+        t.Fatalf("GetUserLibraries: %v", err)
+    }
+    moved := false
+    for _, lib := range libraries {
+        if lib.ID == libID {
+            moved = len(lib.Books["read"]) == 1 && lib.Books["read"][0] == 4 && len(lib.Books["to_read"]) == 0
+        }
+    }
+    if !moved {
+        t.Fatalf("book 4 not moved to read: %+v", libraries)
+    }
+
+    if err := client.StartReading(9); err != nil {
+        t.Fatalf("StartReading: %v", err)
+    }
+    if err := client.TurnPage(9, "forward", 10); err != nil {
+        t.Fatalf("TurnPage: %v", err)
+    }
+    sessions, err := client.GetActiveReading()
+    if err != nil {
+        t.Fatalf("GetActiveReading: %v", err)
+    }
+    page9 := 0.0
+    for _, s := range sessions {
+        if s["book_id"] == float64(9) {
+            page9, _ = s["current_page"].(float64)
+        }
+    }
+    if page9 != 11 {
+        t.Fatalf("book 9 at page %v, want 11", page9)
+    }
+
+    if err := client.RecommendBook("ada", 9, "For the train"); err != nil {
+        t.Fatalf("RecommendBook: %v", err)
+    }
+    recs, err := client.GetRecommendations()
+    if err != nil || len(recs) == 0 {
+        t.Fatalf("GetRecommendations = %+v, %v", recs, err)
+    }
+}
+
+// The client keeps the paging contract over HTTP too, see api.TestListBooksPage.
+func TestClientPaging(t *testing.T) {
+    _, client, _ := start(t, Options{})
+
+    page, err := client.ListBooksPage(len(api.SeedBooks), 5)
+    if err != nil || len(page.Books) != 0 || page.Total != len(api.SeedBooks) {
+        t.Fatalf("page past the end = %+v, %v", page, err)
+    }
+    books, err := api.AllBooks(client, 7)
+    if err != nil || len(books) != len(api.SeedBooks) || books[49].ID != 50 {
+        t.Fatalf("AllBooks = %d books, %v", len(books), err)
+    }
+    some, err := api.GetBooks(client, []int{3, 9999, 1})
+    if err != nil || len(some) != 2 || some[0].ID != 3 || some[1].ID != 1 {
+        t.Fatalf("GetBooks = %+v, %v", some, err)
+    }
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+    ts := httptest.NewServer(New(Options{}))
+    defer ts.Close()
+
+    client := api.NewClient(ts.URL, time.Second)
+    if _, err := client.Login(api.DemoUsername, "nope"); err == nil {
+        t.Fatal("Login with a wrong password succeeded")
+    }
+}
+
+// The stand-in reads each argument from where FastAPI does, so a client
+// sending it elsewhere gets the 422 the real backend would answer.
+func TestArgumentsFromWhereFastAPIReadsThem(t *testing.T) {
+    _, _, url := start(t, Options{})
+
+    tests := []struct {
+        name   string
+        path   string
+        body   string
+        status int
+        loc    string
+    }{
+        {"query route with a JSON body", "/libraries/1/move", `{"username":"demo","book_id":1,"shelf":"read"}`, 422, "query.username"},
+        {"query route", "/libraries/1/move?username=demo&book_id=1&shelf=read", "", 200, ""},
+        {"query int", "/libraries/1/move?username=demo&book_id=one&shelf=read", "", 422, "query.book_id"},
+        {"body route with a query", "/reading/start?username=demo&book_id=2", "", 422, "body"},
+        {"body route", "/reading/start", `{"username":"demo","book_id":2}`, 200, ""},
+        {"body field missing", "/reading/turn", `{"username":"demo","book_id":2}`, 422, "body.direction"},
+        {"body default", "/reading/turn", `{"username":"demo","book_id":2,"direction":"forward"}`, 200, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            resp, err := http.Post(url+tt.path, "application/json", strings.NewReader(tt.body))
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer resp.Body.Close()
+            if resp.StatusCode != tt.status {
+                t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+            }
+            if tt.loc == "" {
+                return
+            }
+            var out struct {
+                Detail []fieldError `json:"detail"`
+            }
+            if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || len(out.Detail) == 0 {
+                t.Fatalf("422 detail: %+v, %v", out, err)
+            }
+            if loc := strings.Join(out.Detail[0].Loc, "."); loc != tt.loc {
+                t.Fatalf("loc = %s, want %s", loc, tt.loc)
+            }
+        })
+    }
+}
+
+func TestInjectedFault(t *testing.T) {
+    srv, client, _ := start(t, Options{})
+    client.Cache = nil
+
+    srv.Inject("GET /books/{id}", Fault{Status: http.StatusServiceUnavailable, Times: 1})
+    _, err := client.GetBook(1)
+    var apiErr *api.APIError
+    if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+        t.Fatalf("first GetBook: %v, want a 503", err)
+    }
+    if _, err := client.GetBook(1); err != nil {
+        t.Fatalf("second GetBook: %v, the fault fires once", err)
+    }
+}
+
+func TestInjectedDelayTimesOut(t *testing.T) {
+    srv, client, _ := start(t, Options{})
+    client.Cache = nil
+    client.HTTPClient.Timeout = 50 * time.Millisecond
+
+    srv.Inject("GET /books", Fault{Delay: time.Second, Times: 1})
+    if _, err := client.ListBooks(); err == nil {
+        t.Fatal("ListBooks beat a one second delay with a 50ms timeout")
+    }
+}
+
+func TestPing(t *testing.T) {
+    srv, client, _ := start(t, Options{})
+
+    if _, err := client.Ping(); err != nil {
+        t.Fatalf("Ping: %v", err)
+    }
+    srv.Inject("GET /health", Fault{Status: http.StatusBadGateway, Times: 1})
+    if _, err := client.Ping(); err == nil {
+        t.Fatal("Ping of a failing backend succeeded")
+    }
+    // Only /health is asked, a broken catalog doesn't make the backend look down
+    srv.Inject("GET /books", Fault{Status: http.StatusInternalServerError})
+    srv.Inject("GET /openapi.json", Fault{Status: http.StatusInternalServerError})
+    if _, err := client.Ping(); err != nil {
+        t.Fatalf("Ping: %v", err)
+    }
+}
+
+// A backend from before /health answers 404, which still means it is up.
+func TestPingWithoutHealthRoute(t *testing.T) {
+    ts := httptest.NewServer(http.NotFoundHandler())
+    defer ts.Close()
+    if _, err := api.NewClient(ts.URL, time.Second).Ping(); err != nil {
+        t.Fatalf("Ping: %v", err)
+    }
+}
+
+func TestRevalidationAnswers304(t *testing.T) {
+    _, client, url := start(t, Options{})
+
+    if _, err := client.GetBook(1); err != nil {
+        t.Fatal(err)
+    }
+    resp, err := http.Get(url + "/books/1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    etag := resp.Header.Get("ETag")
+    if etag == "" {
+        t.Fatal("GET /books/1 has no ETag")
+    }
+
+    req, _ := http.NewRequest("GET", url+"/books/1", nil)
+    req.Header.Set("If-None-Match", etag)
+    resp, err = http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusNotModified {
+        t.Fatalf("status = %d, want 304", resp.StatusCode)
+    }
+
+    // The client revalidates after Refresh and still gets the book
+    client.Refresh()
+    book, err := client.GetBook(1)
+    if err != nil || book.ID != 1 {
+        t.Fatalf("GetBook after Refresh = %+v, %v", book, err)
+    }
+}
+
+func TestFailStatus(t *testing.T) {
+    tests := []struct {
+        err    error
+        status int
+    }{
+        {&api.APIError{Status: http.StatusNotFound}, http.StatusNotFound},
+        {&api.APIError{Status: http.StatusUnauthorized}, http.StatusUnauthorized},
+        {errors.New("disk full"), http.StatusInternalServerError},
+    }
+    for _, tt := range tests {
+        w := httptest.NewRecorder()
+        fail(w, tt.err)
+        if w.Code != tt.status {
+            t.Errorf("fail(%v) = %d, want %d", tt.err, w.Code, tt.status)
+        }
+    }
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// Kinds of highlight, as the Kindle names them, and quotes typed in.
+const (
+    KindHighlight = "highlight"
+    KindNote      = "note"
+    KindBookmark  = "bookmark"
+    KindQuote     = "quote"
+)
+
+// Order is how the highlights of a book are listed.
+type Order int
+
+const (
+    ByPage Order = iota
+    ByTime       // by the date they were added, wherever they came from
+)
+
+// AddedFormat is how highlights written in the app are dated.
+const AddedFormat = "2006-01-02 15:04"
+
+// Highlight is a passage marked in a book, a note on it, a quote or a
+// bookmark.
+type Highlight struct {
+    BookID   int    `json:"book_id"`
+    Kind     string `json:"kind"`
+    Text     string `json:"text,omitempty"`
+    Page     int    `json:"page,omitempty"`     // 0 when the device only knows locations
+    Location string `json:"location,omitempty"` // e.g. "123-125"
+    Added    string `json:"added,omitempty"`    // as the device wrote it
+    Source   string `json:"source"`             // e.g. "kindle"
+}
+
+// key is what makes two highlights the same, wherever they came from.
+type key struct {
+    bookID               int
+    kind, text, location string
+    page                 int
+}
+
+func (h Highlight) key() key {
+    return key{bookID: h.BookID, kind: h.Kind, text: h.Text, location: h.Location, page: h.Page}
+}
+
+// Highlights of a book, in order.
+func (s *Store) Highlights(bookID int, order Order) []Highlight {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var out []Highlight
+    for _, h := range s.data.Highlights {
+        if h.BookID == bookID {
+            out = append(out, h)
+        }
+    }
+    switch order {
+    case ByPage:
+        sort.SliceStable(out, func(i, j int) bool { return out[i].Page < out[j].Page })
+    case ByTime:
+        sortByTime(out)
+    }
+    return out
+}
+
+// sortByTime orders highlights by their Added dates, keeping the order
+// they were stored in for equal dates. A date that can't be read counts as
+// the one stored before it, so the highlight stays next to its neighbours.
+func sortByTime(highlights []Highlight) {
+    type dated struct {
+        h Highlight
+        t time.Time
+    }
+    all := make([]dated, len(highlights))
+    var last time.Time
+    for i, h := range highlights {
+        if t, ok := addedTime(h.Added); ok {
+            last = t
+        }
+        all[i] = dated{h, last}
+    }
+    sort.SliceStable(all, func(i, j int) bool { return all[i].t.Before(all[j].t) })
+    for i, d := range all {
+        highlights[i] = d.h
+    }
+}
+
+// months are the month names of the languages a Kindle writes dates in.
+var months = map[string]time.Month{}
+
+func init() {
+    for _, names := range [][]string{
+        {"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"},
+        {"januar", "februar", "märz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+        {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+        {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+        {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+        {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+        {"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+    } {
+        for i, name := range names {
+            months[name] = time.Month(i + 1)
+        }
+    }
+}
+
+// addedTime reads the date of a highlight: AddedFormat for the app's, or
+// a Kindle's in any of its languages, e.g. "Sunday, January 14, 2024
+// 9:02:11 PM" or "Samstag, 13. Januar 2024 10:15:32". It picks out the
+// month name, the year, the day and the time, whatever their order.
+func addedTime(added string) (time.Time, bool) {
+    if t, err := time.ParseInLocation(AddedFormat, added, time.Local); err == nil {
+        return t, true
+    }
+
+    var month time.Month
+    year, day, clock := 0, 0, ""
+    pm, am := false, false
+    tokens := strings.FieldsFunc(strings.ToLower(added), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':'
+    })
+    for _, tok := range tokens {
+        n, err := strconv.Atoi(tok)
+        switch {
+        case strings.Contains(tok, ":"):
+            clock = tok
+        case err == nil && len(tok) == 4:
+            year = n
+        case err == nil && day == 0:
+            day = n
+        case tok == "pm" || tok == "p":
+            pm = true
+        case tok == "am" || tok == "a":
+            am = true
+        case months[tok] != 0:
+            month = months[tok]
+        }
+    }
+    if month == 0 || year == 0 || day < 1 || day > 31 {
+        return time.Time{}, false
+    }
+
+    var hour, minute, second int
+    if clock != "" {
+        parts := strings.Split(clock, ":")
+        for i, p := range parts {
+            n, err := strconv.Atoi(p)
+            if err != nil || i > 2 {
+                return time.Time{}, false
+            }
+            switch i {
+            case 0:
+                hour = n
+            case 1:
+                minute = n
+            case 2:
+                second = n
+            }
+        }
+        switch {
+        case pm && hour < 12:
+            hour += 12
+        case am && hour == 12:
+            hour = 0
+        }
+    }
+    return time.Date(year, month, day, hour, minute, second, 0, time.Local), true
+}
+
+// All highlights of every book, as they were added.
+func (s *Store) All() []Highlight {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]Highlight(nil), s.data.Highlights...)
+}
+
+// Search finds the highlights of any book whose text has every word of
+// query, ignoring case, oldest first.
+func (s *Store) Search(query string) []Highlight {
+    words := strings.Fields(strings.ToLower(query))
+    var out []Highlight
+    for _, h := range s.All() {
+        text := strings.ToLower(h.Text)
+        found := true
+        for _, w := range words {
+            if !strings.Contains(text, w) {
+                found = false
+                break
+            }
+        }
+        if found && h.Text != "" {
+            out = append(out, h)
+        }
+    }
+    sortByTime(out)
+    return out
+}
+
+// Add stores a note, quote or bookmark written in the app, dated now.
+// Unlike AddHighlights it doesn't look for duplicates: writing the same
+// note twice is the user's call.
+func (s *Store) Add(h Highlight) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if h.Added == "" {
+        h.Added = time.Now().Format(AddedFormat)
+    }
+    if h.Source == "" {
+        h.Source = "app"
+    }
+    s.data.Highlights = append(s.data.Highlights, h)
+    return s.save()
+}
+
+// AddHighlights stores the highlights not stored yet and reports how many
+// were new. Importing the same clippings file twice adds nothing.
+func (s *Store) AddHighlights(highlights []Highlight) (int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    seen := make(map[key]bool, len(s.data.Highlights))
+    for _, h := range s.data.Highlights {
+        seen[h.key()] = true
+    }
+    added := 0
+    for _, h := range highlights {
+        if !seen[h.key()] {
+            seen[h.key()] = true
+            s.data.Highlights = append(s.data.Highlights, h)
+            added++
+        }
+    }
+    if added == 0 {
+        return 0, nil
+    }
+    return added, s.save()
+}
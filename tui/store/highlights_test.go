@@ -0,0 +1,147 @@
+package store
+
+import (
+    "testing"
+    "time"
+)
+
+func openStore(t *testing.T) *Store {
+    t.Helper()
+    s, err := Open(t.TempDir(), "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    return s
+}
+
+func TestAddedTime(t *testing.T) {
+    tests := []struct {
+        added string
+        want  time.Time
+    }{
+        {"2024-03-02 10:15", time.Date(2024, 3, 2, 10, 15, 0, 0, time.Local)},
+        {"Sunday, January 14, 2024 9:02:11 PM", time.Date(2024, 1, 14, 21, 2, 11, 0, time.Local)},
+        {"Sunday, January 14, 2024 12:30:00 AM", time.Date(2024, 1, 14, 0, 30, 0, 0, time.Local)},
+        {"Saturday, 13 January 2024 10:15:32", time.Date(2024, 1, 13, 10, 15, 32, 0, time.Local)},
+        {"Samstag, 13. Januar 2024 10:15:32", time.Date(2024, 1, 13, 10, 15, 32, 0, time.Local)},
+        {"samedi 13 janvier 2024 10:15:32", time.Date(2024, 1, 13, 10, 15, 32, 0, time.Local)},
+        {"sábado, 13 de enero de 2024 10:15:32", time.Date(2024, 1, 13, 10, 15, 32, 0, time.Local)},
+        {"sabato 13 marzo 2024 10:15:32", time.Date(2024, 3, 13, 10, 15, 32, 0, time.Local)},
+        {"sábado, 13 de março de 2024 10:15:32", time.Date(2024, 3, 13, 10, 15, 32, 0, time.Local)},
+        {"zaterdag 13 maart 2024 10:15:32", time.Date(2024, 3, 13, 10, 15, 32, 0, time.Local)},
+        {"Friday, August 2, 2019", time.Date(2019, 8, 2, 0, 0, 0, 0, time.Local)},
+    }
+    for _, tt := range tests {
+        got, ok := addedTime(tt.added)
+        if !ok || !got.Equal(tt.want) {
+            t.Errorf("addedTime(%q) = %v, %v; want %v", tt.added, got, ok, tt.want)
+        }
+    }
+
+    for _, bad := range []string{"", "yesterday", "2024", "13 Smarch 2024 10:00", "January 2024"} {
+        if got, ok := addedTime(bad); ok {
+            t.Errorf("addedTime(%q) = %v, want no date", bad, got)
+        }
+    }
+}
+
+func TestHighlightsByTime(t *testing.T) {
+    s := openStore(t)
+    for _, h := range []Highlight{
+        {BookID: 1, Kind: KindNote, Text: "written in the app", Page: 40, Added: "2024-03-02 10:15"},
+        {BookID: 1, Kind: KindQuote, Text: "same minute", Page: 10, Added: "2024-03-02 10:15"},
+        {BookID: 2, Kind: KindNote, Text: "another book", Added: "2020-01-01 00:00"},
+    } {
+        if err := s.Add(h); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // Imported later, but highlighted on the Kindle long before
+    _, err := s.AddHighlights([]Highlight{
+        {BookID: 1, Kind: KindHighlight, Text: "from the kindle", Page: 90, Added: "Sunday, January 14, 2024 9:02:11 PM", Source: "kindle"},
+        {BookID: 1, Kind: KindHighlight, Text: "undated", Page: 91, Added: "le jour d'après", Source: "kindle"},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        order Order
+        want  []string
+    }{
+        {ByTime, []string{"from the kindle", "undated", "written in the app", "same minute"}},
+        {ByPage, []string{"same minute", "written in the app", "from the kindle", "undated"}},
+    }
+    for _, tt := range tests {
+        got := s.Highlights(1, tt.order)
+        if len(got) != len(tt.want) {
+            t.Fatalf("order %d: %d highlights, want %d", tt.order, len(got), len(tt.want))
+        }
+        for i := range got {
+            if got[i].Text != tt.want[i] {
+                t.Errorf("order %d: #%d is %q, want %q", tt.order, i, got[i].Text, tt.want[i])
+            }
+        }
+    }
+}
+
+func TestAddHighlightsSkipsDuplicates(t *testing.T) {
+    dir := t.TempDir()
+    s, err := Open(dir, "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    clip := []Highlight{
+        {BookID: 1, Kind: KindHighlight, Text: "once", Location: "10-12", Source: "kindle"},
+        {BookID: 1, Kind: KindHighlight, Text: "twice", Location: "20-22", Source: "kindle"},
+    }
+    for i, want := range []int{2, 0} {
+        n, err := s.AddHighlights(clip)
+        if err != nil || n != want {
+            t.Fatalf("import #%d added %d, %v; want %d", i+1, n, err, want)
+        }
+    }
+
+    // What was saved is what a new Open reads back
+    again, err := Open(dir, "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := len(again.All()); got != 2 {
+        t.Fatalf("reopened store has %d highlights, want 2", got)
+    }
+}
+
+func TestSearch(t *testing.T) {
+    s := openStore(t)
+    for _, h := range []Highlight{
+        {BookID: 1, Kind: KindNote, Text: "The sea as a clock", Added: "2024-03-02 10:15"},
+        {BookID: 2, Kind: KindQuote, Text: "All the tides were late that year.", Added: "2024-01-01 08:00"},
+        {BookID: 2, Kind: KindBookmark, Page: 12},
+    } {
+        s.Add(h)
+    }
+
+    tests := []struct {
+        query string
+        want  []string
+    }{
+        {"sea", []string{"The sea as a clock"}},
+        {"THE", []string{"All the tides were late that year.", "The sea as a clock"}},
+        {"tides late", []string{"All the tides were late that year."}},
+        {"tides clock", nil},
+        {"", []string{"All the tides were late that year.", "The sea as a clock"}},
+    }
+    for _, tt := range tests {
+        got := s.Search(tt.query)
+        if len(got) != len(tt.want) {
+            t.Errorf("Search(%q) = %d results, want %d", tt.query, len(got), len(tt.want))
+            continue
+        }
+        for i := range got {
+            if got[i].Text != tt.want[i] {
+                t.Errorf("Search(%q) #%d = %q, want %q", tt.query, i, got[i].Text, tt.want[i])
+            }
+        }
+    }
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+    "sort"
+    "time"
+)
+
+// Sitting is one stretch of reading a book, from the page it was opened
+// at to the page it was left at.
+type Sitting struct {
+    BookID    int       `json:"book_id"`
+    StartPage int       `json:"start_page"`
+    EndPage   int       `json:"end_page"`
+    Start     time.Time `json:"start"`
+    End       time.Time `json:"end"`
+}
+
+// Pages read in the sitting, negative when it went back.
+func (s Sitting) Pages() int {
+    return s.EndPage - s.StartPage
+}
+
+// Entry is a sitting in a book's timeline, with what came before it.
+type Entry struct {
+    Sitting
+    Pause  time.Duration // since the previous sitting ended, 0 for the first
+    Reread bool          // it went over pages an earlier sitting had read
+}
+
+// AddSitting keeps a sitting of a book.
+func (s *Store) AddSitting(sitting Sitting) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.data.Sittings = append(s.data.Sittings, sitting)
+    return s.save()
+}
+
+// Timeline lists the sittings of a book, oldest first, with the pauses
+// between them and the ones that reread.
+func (s *Store) Timeline(bookID int) []Entry {
+    s.mu.Lock()
+    var sittings []Sitting
+    for _, sitting := range s.data.Sittings {
+        if sitting.BookID == bookID {
+            sittings = append(sittings, sitting)
+        }
+    }
+    s.mu.Unlock()
+    sort.SliceStable(sittings, func(i, j int) bool { return sittings[i].Start.Before(sittings[j].Start) })
+
+    entries := make([]Entry, 0, len(sittings))
+    furthest := 0
+    for i, sitting := range sittings {
+        e := Entry{Sitting: sitting}
+        if i > 0 {
+            e.Pause = sitting.Start.Sub(sittings[i-1].End)
+        }
+        e.Reread = i > 0 && min(sitting.StartPage, sitting.EndPage) < furthest
+        furthest = max(furthest, sitting.StartPage, sitting.EndPage)
+        entries = append(entries, e)
+    }
+    return entries
+}
+
+// Bookmarks of a book, by page.
+func (s *Store) Bookmarks(bookID int) []Highlight {
+    var out []Highlight
+    for _, h := range s.Highlights(bookID, ByPage) {
+        if h.Kind == KindBookmark {
+            out = append(out, h)
+        }
+    }
+    return out
+}
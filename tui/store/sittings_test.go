@@ -0,0 +1,79 @@
+package store
+
+import (
+    "testing"
+    "time"
+)
+
+func TestTimeline(t *testing.T) {
+    dir := t.TempDir()
+    s, err := Open(dir, "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    day := time.Date(2024, 3, 2, 20, 0, 0, 0, time.UTC)
+    sittings := []Sitting{
+        // Kept out of order, the timeline sorts them by start
+        {BookID: 1, StartPage: 30, EndPage: 50, Start: day.Add(24 * time.Hour), End: day.Add(25 * time.Hour)},
+        {BookID: 1, StartPage: 0, EndPage: 30, Start: day, End: day.Add(40 * time.Minute)},
+        {BookID: 2, StartPage: 0, EndPage: 10, Start: day.Add(time.Hour), End: day.Add(2 * time.Hour)},
+        {BookID: 1, StartPage: 50, EndPage: 45, Start: day.Add(26 * time.Hour), End: day.Add(27 * time.Hour)},
+        {BookID: 1, StartPage: 20, EndPage: 60, Start: day.Add(28 * time.Hour), End: day.Add(29 * time.Hour)},
+    }
+    for _, sitting := range sittings {
+        if err := s.AddSitting(sitting); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    // The sittings outlive the store
+    s, err = Open(dir, "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        startPage, pages int
+        pause            time.Duration
+        reread           bool
+    }{
+        {0, 30, 0, false},
+        {30, 20, 23*time.Hour + 20*time.Minute, false},
+        {50, -5, time.Hour, true}, // went back
+        {20, 40, time.Hour, true},
+    }
+    entries := s.Timeline(1)
+    if len(entries) != len(tests) {
+        t.Fatalf("%d entries, want %d: %+v", len(entries), len(tests), entries)
+    }
+    for i, tt := range tests {
+        e := entries[i]
+        if e.StartPage != tt.startPage || e.Pages() != tt.pages || e.Pause != tt.pause || e.Reread != tt.reread {
+            t.Errorf("entry %d = %+v, want %+v", i, e, tt)
+        }
+    }
+    if got := s.Timeline(3); len(got) != 0 {
+        t.Errorf("Timeline of an unread book = %+v", got)
+    }
+}
+
+func TestBookmarks(t *testing.T) {
+    s, err := Open(t.TempDir(), "alice")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, h := range []Highlight{
+        {BookID: 1, Kind: KindBookmark, Page: 40, Text: "Arrakis"},
+        {BookID: 1, Kind: KindNote, Page: 10, Text: "a note"},
+        {BookID: 1, Kind: KindBookmark, Page: 3},
+        {BookID: 2, Kind: KindBookmark, Page: 1},
+    } {
+        if err := s.Add(h); err != nil {
+            t.Fatal(err)
+        }
+    }
+    marks := s.Bookmarks(1)
+    if len(marks) != 2 || marks[0].Page != 3 || marks[1].Text != "Arrakis" {
+        t.Fatalf("Bookmarks = %+v", marks)
+    }
+}
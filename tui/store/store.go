@@ -0,0 +1,78 @@
+package store
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// Store keeps what the backend has no place for, one JSON file per user
+// in the data directory. It works the same with every backend, and the
+// file is small enough to be rewritten whole on every change.
+type Store struct {
+    mu   sync.Mutex
+    path string
+    data data
+}
+
+// data is the file's layout.
+type data struct {
+    Highlights []Highlight `json:"highlights"`
+    Sittings   []Sitting   `json:"sittings,omitempty"`
+}
+
+// DefaultDir returns $XDG_DATA_HOME/booktracker, falling back to
+// ~/.local/share/booktracker.
+func DefaultDir() string {
+    dir := os.Getenv("XDG_DATA_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".local", "share")
+    }
+    return filepath.Join(dir, "booktracker")
+}
+
+// Open loads the store of username in dir. A missing file is an empty store.
+func Open(dir, username string) (*Store, error) {
+    s := &Store{path: filepath.Join(dir, username+".json")}
+
+    raw, err := os.ReadFile(s.path)
+    if errors.Is(err, os.ErrNotExist) {
+        return s, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(raw, &s.data); err != nil {
+        return nil, fmt.Errorf("%s: %w", s.path, err)
+    }
+    return s, nil
+}
+
+// Path is the file the store lives in.
+func (s *Store) Path() string {
+    return s.path
+}
+
+// save writes the file through a temporary one, so a crash never leaves
+// half of it behind. The caller holds mu.
+func (s *Store) save() error {
+    raw, err := json.MarshalIndent(s.data, "", "  ")
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+        return err
+    }
+    tmp := s.path + ".tmp"
+    if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, s.path)
+}
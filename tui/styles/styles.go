@@ -2,26 +2,101 @@ package styles
 
 import "github.com/charmbracelet/lipgloss"
 
+// Current is the theme the styles below were built from.
+var Current Theme
+
 var (
 	// Colors
-	PrimaryColor   = lipgloss.Color("#2563EB")
-	SecondaryColor = lipgloss.Color("#7C3AED")
-	SuccessColor   = lipgloss.Color("#10B981")
-	WarningColor   = lipgloss.Color("#F59E0B")
-	DangerColor    = lipgloss.Color("#EF4444")
-	LightColor     = lipgloss.Color("#F3F4F6")
-	DarkColor      = lipgloss.Color("#1F2937")
+	PrimaryColor   lipgloss.TerminalColor
+	SecondaryColor lipgloss.TerminalColor
+	SuccessColor   lipgloss.TerminalColor
+	WarningColor   lipgloss.TerminalColor
+	DangerColor    lipgloss.TerminalColor
+	LightColor     lipgloss.TerminalColor
+	DarkColor      lipgloss.TerminalColor
 
 	// App styles
+	AppStyle lipgloss.Style
+
+	// Header
+	HeaderStyle      lipgloss.Style
+	HeaderLeftStyle  lipgloss.Style
+	HeaderRightStyle lipgloss.Style
+
+	// Footer
+	FooterStyle      lipgloss.Style
+	FooterLeftStyle  lipgloss.Style
+	FooterRightStyle lipgloss.Style
+
+	// Navigation
+	NavBarStyle          lipgloss.Style
+	NavItemStyle         lipgloss.Style
+	NavItemSelectedStyle lipgloss.Style
+
+	// Sidebar
+	SidebarStyle lipgloss.Style
+
+	// Content
+	ContentStyle lipgloss.Style
+
+	// Cards
+	CardStyle lipgloss.Style
+
+	// Panels float over the content: help, confirmations, the review form
+	PanelStyle lipgloss.Style
+
+	// Buttons
+	ButtonStyle lipgloss.Style
+
+	// Forms
+	FormStyle       lipgloss.Style
+	InputStyle      lipgloss.Style
+	InputLabelStyle lipgloss.Style
+
+	// Text
+	TitleStyle    lipgloss.Style
+	SubtitleStyle lipgloss.Style
+	HintStyle     lipgloss.Style
+	SelectedStyle lipgloss.Style
+
+	// Status
+	LoadingStyle lipgloss.Style
+	ErrorStyle   lipgloss.Style
+	SuccessStyle lipgloss.Style
+
+	// Shelf styles
+	ShelfPlankStyle         lipgloss.Style
+	ShelfFrameStyle         lipgloss.Style
+	ShelfFrameSelectedStyle lipgloss.Style
+
+	BookStyle         lipgloss.Style
+	BookSelectedStyle lipgloss.Style
+)
+
+func init() {
+	Use(DarkPalette.Theme("dark"))
+}
+
+// Use makes t the current theme and rebuilds every style from it.
+func Use(t Theme) {
+	Current = t
+
+	PrimaryColor = t.Primary
+	SecondaryColor = t.Secondary
+	SuccessColor = t.Success
+	WarningColor = t.Warning
+	DangerColor = t.Danger
+	LightColor = t.Text
+	DarkColor = t.Background
+
 	AppStyle = lipgloss.NewStyle().
 		Padding(0, 1).
-		Background(DarkColor).
-		Foreground(LightColor)
+		Background(t.Background).
+		Foreground(t.Text)
 
-	// Header
 	HeaderStyle = lipgloss.NewStyle().
-		Background(PrimaryColor).
-		Foreground(LightColor).
+		Background(t.Primary).
+		Foreground(t.OnPrimary).
 		Padding(0, 2).
 		Height(3).
 		Bold(true)
@@ -33,10 +108,9 @@ var (
 	HeaderRightStyle = lipgloss.NewStyle().
 		Faint(true)
 
-	// Footer
 	FooterStyle = lipgloss.NewStyle().
-		Background(DarkColor).
-		Foreground(LightColor).
+		Background(t.Background).
+		Foreground(t.Text).
 		Padding(0, 2).
 		Height(2)
 
@@ -46,91 +120,110 @@ var (
 	FooterRightStyle = lipgloss.NewStyle().
 		Bold(true)
 
-	// Navigation
 	NavBarStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("#374151")).
+		Background(t.Surface).
 		Padding(0, 2).
 		Height(2)
 
 	NavItemStyle = lipgloss.NewStyle().
 		Padding(0, 2).
-		Foreground(LightColor)
+		Foreground(t.Text)
 
 	NavItemSelectedStyle = NavItemStyle.Copy().
-		Background(SecondaryColor).
-		Bold(true)
+		Background(t.Secondary).
+		Bold(true).
+		Reverse(t.Mono)
 
-	// Sidebar
 	SidebarStyle = lipgloss.NewStyle().
 		Width(25).
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(PrimaryColor)
+		BorderForeground(t.Primary)
 
-	// Content
 	ContentStyle = lipgloss.NewStyle().
 		Padding(1, 2)
 
-	// Cards
 	CardStyle = lipgloss.NewStyle().
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#4B5563")).
-		Background(lipgloss.Color("#374151"))
+		BorderForeground(t.Border).
+		Background(t.Surface)
+
+	PanelStyle = lipgloss.NewStyle().
+		Padding(1, 3).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary)
 
-	// Buttons
 	ButtonStyle = lipgloss.NewStyle().
-		Background(PrimaryColor).
-		Foreground(LightColor).
+		Background(t.Primary).
+		Foreground(t.OnPrimary).
 		Padding(0, 3).
-		Bold(true)
+		Bold(true).
+		Reverse(t.Mono)
 
-	// Forms
 	FormStyle = lipgloss.NewStyle().
 		Width(40).
 		Padding(2, 3).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(PrimaryColor)
+		BorderForeground(t.Primary)
 
 	InputStyle = lipgloss.NewStyle().
 		Padding(0, 1).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#6B7280")).
+		BorderForeground(t.Muted).
 		Width(30)
 
 	InputLabelStyle = lipgloss.NewStyle().
 		Bold(true).
 		MarginBottom(1)
 
-	// Text
 	TitleStyle = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(PrimaryColor).
+		Foreground(t.Primary).
 		MarginBottom(1)
 
 	SubtitleStyle = lipgloss.NewStyle().
 		Faint(true).
 		MarginBottom(2)
 
-	// Status
+	HintStyle = lipgloss.NewStyle().
+		Faint(true)
+
+	SelectedStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Secondary)
+
 	LoadingStyle = lipgloss.NewStyle().
-		Foreground(WarningColor).
+		Foreground(t.Warning).
 		Bold(true)
 
 	ErrorStyle = lipgloss.NewStyle().
-		Foreground(DangerColor).
+		Foreground(t.Danger).
 		Bold(true)
 
 	SuccessStyle = lipgloss.NewStyle().
-		Foreground(SuccessColor).
+		Foreground(t.Success).
 		Bold(true)
 
-	// Shelf styles
 	ShelfPlankStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("#5C4033")).
-		Foreground(lipgloss.Color("#F5DEB3")).
+		Background(t.Plank).
+		Foreground(t.PlankText).
 		Bold(true).
-		Padding(0, 1)
+		Padding(0, 1).
+		Reverse(t.Mono)
+
+	ShelfFrameStyle = lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Background(t.Surface)
+
+	ShelfFrameSelectedStyle = ShelfFrameStyle.Copy().
+		BorderForeground(t.Primary)
+
+	if t.Mono {
+		ShelfFrameSelectedStyle = ShelfFrameSelectedStyle.Border(lipgloss.ThickBorder())
+	}
 
 	BookStyle = lipgloss.NewStyle().
 		Width(6).
@@ -140,6 +233,21 @@ var (
 		Padding(0, 1)
 
 	BookSelectedStyle = BookStyle.Copy().
-		BorderForeground(PrimaryColor).
-		Background(lipgloss.Color("#374151"))
-)
\ No newline at end of file
+		BorderForeground(t.Primary).
+		Background(t.Surface).
+		Reverse(t.Mono)
+}
+
+// ShelfColor is the accent of a shelf's books.
+func ShelfColor(name string) lipgloss.TerminalColor {
+	switch name {
+	case "to_read":
+		return Current.ToRead
+	case "currently_reading":
+		return Current.Reading
+	case "read":
+		return Current.Read
+	default:
+		return Current.Muted
+	}
+}
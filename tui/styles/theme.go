@@ -0,0 +1,267 @@
+package styles
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Palette is a theme written down as colour strings, hex ("#2563EB") or
+// ANSI numbers ("12"). User theme files use the same keys:
+//
+//	base = "dark"          # built-in theme to take unset colours from
+//	primary = "#FF8800"
+//	to_read = "#5DA9E9"
+type Palette struct {
+	Base        string `toml:"base"`
+	Primary     string `toml:"primary"`    // header, focused borders, buttons
+	OnPrimary   string `toml:"on_primary"` // text on primary
+	Secondary   string `toml:"secondary"`  // selected nav item, review form
+	Success     string `toml:"success"`
+	Warning     string `toml:"warning"`
+	Danger      string `toml:"danger"`
+	Text        string `toml:"text"`
+	Background  string `toml:"background"` // footer
+	Surface     string `toml:"surface"`    // nav bar, cards, shelves
+	Border      string `toml:"border"`     // unfocused borders
+	Muted       string `toml:"muted"`      // input borders
+	Plank       string `toml:"plank"`      // shelf titles
+	PlankText   string `toml:"plank_text"`
+	Highlight   string `toml:"highlight"` // selected book
+	OnHighlight string `toml:"on_highlight"`
+	ToRead      string `toml:"to_read"`
+	Reading     string `toml:"currently_reading"`
+	Read        string `toml:"read"`
+}
+
+// Theme holds the colours every renderer draws with.
+type Theme struct {
+	Name string
+	Mono bool // no colours at all, selection is shown with reverse video
+
+	Primary     lipgloss.TerminalColor
+	OnPrimary   lipgloss.TerminalColor
+	Secondary   lipgloss.TerminalColor
+	Success     lipgloss.TerminalColor
+	Warning     lipgloss.TerminalColor
+	Danger      lipgloss.TerminalColor
+	Text        lipgloss.TerminalColor
+	Background  lipgloss.TerminalColor
+	Surface     lipgloss.TerminalColor
+	Border      lipgloss.TerminalColor
+	Muted       lipgloss.TerminalColor
+	Plank       lipgloss.TerminalColor
+	PlankText   lipgloss.TerminalColor
+	Highlight   lipgloss.TerminalColor
+	OnHighlight lipgloss.TerminalColor
+	ToRead      lipgloss.TerminalColor
+	Reading     lipgloss.TerminalColor
+	Read        lipgloss.TerminalColor
+}
+
+// The order of colors() and slots() must match.
+func (p *Palette) colors() []*string {
+	return []*string{
+		&p.Primary, &p.OnPrimary, &p.Secondary, &p.Success, &p.Warning, &p.Danger,
+		&p.Text, &p.Background, &p.Surface, &p.Border, &p.Muted, &p.Plank, &p.PlankText,
+		&p.Highlight, &p.OnHighlight, &p.ToRead, &p.Reading, &p.Read,
+	}
+}
+
+func (t *Theme) slots() []*lipgloss.TerminalColor {
+	return []*lipgloss.TerminalColor{
+		&t.Primary, &t.OnPrimary, &t.Secondary, &t.Success, &t.Warning, &t.Danger,
+		&t.Text, &t.Background, &t.Surface, &t.Border, &t.Muted, &t.Plank, &t.PlankText,
+		&t.Highlight, &t.OnHighlight, &t.ToRead, &t.Reading, &t.Read,
+	}
+}
+
+// Theme turns the palette into colours.
+func (p Palette) Theme(name string) Theme {
+	t := Theme{Name: name}
+	slots := t.slots()
+	for i, c := range p.colors() {
+		*slots[i] = lipgloss.Color(*c)
+	}
+	return t
+}
+
+var DarkPalette = Palette{
+	Primary:     "#2563EB",
+	OnPrimary:   "#F3F4F6",
+	Secondary:   "#7C3AED",
+	Success:     "#10B981",
+	Warning:     "#F59E0B",
+	Danger:      "#EF4444",
+	Text:        "#F3F4F6",
+	Background:  "#1F2937",
+	Surface:     "#374151",
+	Border:      "#4B5563",
+	Muted:       "#6B7280",
+	Plank:       "#5C4033",
+	PlankText:   "#F5DEB3",
+	Highlight:   "#3A86FF",
+	OnHighlight: "#FFFFFF",
+	ToRead:      "#5DA9E9",
+	Reading:     "#F4D35E",
+	Read:        "#7AE582",
+}
+
+var LightPalette = Palette{
+	Primary:     "#1D4ED8",
+	OnPrimary:   "#FFFFFF",
+	Secondary:   "#6D28D9",
+	Success:     "#047857",
+	Warning:     "#B45309",
+	Danger:      "#B91C1C",
+	Text:        "#111827",
+	Background:  "#E5E7EB",
+	Surface:     "#F3F4F6",
+	Border:      "#9CA3AF",
+	Muted:       "#6B7280",
+	Plank:       "#8B5E3C",
+	PlankText:   "#FFF8E7",
+	Highlight:   "#1D4ED8",
+	OnHighlight: "#FFFFFF",
+	ToRead:      "#1D4ED8",
+	Reading:     "#B45309",
+	Read:        "#047857",
+}
+
+// HighContrastPalette sticks to pure colours on black.
+var HighContrastPalette = Palette{
+	Primary:     "#00FFFF",
+	OnPrimary:   "#000000",
+	Secondary:   "#FF00FF",
+	Success:     "#00FF00",
+	Warning:     "#FFFF00",
+	Danger:      "#FF0000",
+	Text:        "#FFFFFF",
+	Background:  "#000000",
+	Surface:     "#000000",
+	Border:      "#FFFFFF",
+	Muted:       "#C0C0C0",
+	Plank:       "#FFFFFF",
+	PlankText:   "#000000",
+	Highlight:   "#FFFF00",
+	OnHighlight: "#000000",
+	ToRead:      "#00FFFF",
+	Reading:     "#FFFF00",
+	Read:        "#00FF00",
+}
+
+// ColorBlindPalette uses the Okabe-Ito colours, which stay apart under
+// the common forms of colour blindness. No meaning rests on red vs green.
+var ColorBlindPalette = Palette{
+	Primary:     "#0072B2",
+	OnPrimary:   "#FFFFFF",
+	Secondary:   "#CC79A7",
+	Success:     "#009E73",
+	Warning:     "#E69F00",
+	Danger:      "#D55E00",
+	Text:        "#F3F4F6",
+	Background:  "#1F2937",
+	Surface:     "#374151",
+	Border:      "#4B5563",
+	Muted:       "#9CA3AF",
+	Plank:       "#5C4033",
+	PlankText:   "#F5DEB3",
+	Highlight:   "#56B4E9",
+	OnHighlight: "#000000",
+	ToRead:      "#56B4E9",
+	Reading:     "#E69F00",
+	Read:        "#009E73",
+}
+
+var builtin = map[string]Palette{
+	"dark":          DarkPalette,
+	"light":         LightPalette,
+	"high-contrast": HighContrastPalette,
+	"colorblind":    ColorBlindPalette,
+}
+
+// ThemeNames lists the themes that can be chosen by name.
+var ThemeNames = []string{"auto", "dark", "light", "high-contrast", "colorblind"}
+
+// Auto follows the terminal background: the light palette on light
+// terminals, the dark one otherwise.
+func Auto() Theme {
+	t := Theme{Name: "auto"}
+	light, dark := LightPalette, DarkPalette
+	l, d := light.colors(), dark.colors()
+	for i, slot := range t.slots() {
+		*slot = lipgloss.AdaptiveColor{Light: *l[i], Dark: *d[i]}
+	}
+	return t
+}
+
+// NoColor is used when NO_COLOR is set.
+func NoColor() Theme {
+	t := Theme{Name: "no-color", Mono: true}
+	for _, slot := range t.slots() {
+		*slot = lipgloss.NoColor{}
+	}
+	return t
+}
+
+// Named returns a built-in theme, or loads a user theme from a .toml file.
+func Named(name string) (Theme, error) {
+	if name == "auto" {
+		return Auto(), nil
+	}
+	if p, ok := builtin[name]; ok {
+		return p.Theme(name), nil
+	}
+	if strings.HasSuffix(name, ".toml") {
+		return LoadTheme(name)
+	}
+	return Theme{}, fmt.Errorf("theme: %q is not one of %s or a .toml file", name, strings.Join(ThemeNames, ", "))
+}
+
+// Resolve is Named, except that NO_COLOR (https://no-color.org) wins
+// over any configured theme.
+func Resolve(name string) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return NoColor(), nil
+	}
+	return Named(name)
+}
+
+// LoadTheme reads a user theme. Colours it leaves out come from its base
+// theme, dark unless the file says otherwise.
+func LoadTheme(path string) (Theme, error) {
+	var p Palette
+	md, err := toml.DecodeFile(path, &p)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		return Theme{}, fmt.Errorf("theme %s: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+
+	baseName := p.Base
+	if baseName == "" {
+		baseName = "dark"
+	}
+	base, ok := builtin[baseName]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: base %q is not a built-in theme", path, baseName)
+	}
+
+	own, inherited := p.colors(), base.colors()
+	for i, c := range own {
+		if *c == "" {
+			*c = *inherited[i]
+		}
+	}
+	return p.Theme(path), nil
+}
@@ -0,0 +1,75 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestNamed(t *testing.T) {
+	for _, name := range ThemeNames {
+		theme, err := Named(name)
+		if err != nil || theme.Name != name || theme.Primary == nil || theme.Read == nil {
+			t.Errorf("Named(%q) = %+v, %v", name, theme, err)
+		}
+	}
+	if _, err := Named("solarized"); err == nil || !strings.Contains(err.Error(), "auto, dark, light") {
+		t.Errorf("unknown theme: %v", err)
+	}
+	if auto := Auto(); auto.Text != (lipgloss.AdaptiveColor{Light: LightPalette.Text, Dark: DarkPalette.Text}) {
+		t.Errorf("Auto text = %v", auto.Text)
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	theme, err := Named(write("mine.toml", "base = \"light\"\nprimary = \"#FF8800\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Primary != lipgloss.Color("#FF8800") || theme.Text != lipgloss.Color(LightPalette.Text) {
+		t.Errorf("primary %v, text %v", theme.Primary, theme.Text)
+	}
+	// Without a base the unset colours are the dark theme's
+	theme, err = LoadTheme(write("nobase.toml", "read = \"2\"\n"))
+	if err != nil || theme.Read != lipgloss.Color("2") || theme.Text != lipgloss.Color(DarkPalette.Text) {
+		t.Errorf("no base: read %v, text %v, %v", theme.Read, theme.Text, err)
+	}
+
+	errors := []struct{ content, want string }{
+		{"primry = \"#FFF\"\n", "unknown keys primry"},
+		{"base = \"mine\"\n", `base "mine" is not a built-in theme`},
+		{"primary = \n", "theme "},
+	}
+	for _, tt := range errors {
+		if _, err := LoadTheme(write("bad.toml", tt.content)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadTheme(%q) = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+	if _, err := Named(filepath.Join(dir, "missing.toml")); err == nil {
+		t.Error("a missing theme file loaded")
+	}
+}
+
+func TestResolveNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	theme, err := Resolve("dark")
+	if err != nil || !theme.Mono || theme.Primary != (lipgloss.NoColor{}) {
+		t.Fatalf("Resolve with NO_COLOR = %+v, %v", theme, err)
+	}
+	// NO_COLOR wins over a theme that would not even load
+	if _, err := Resolve("solarized"); err != nil {
+		t.Fatalf("Resolve with NO_COLOR: %v", err)
+	}
+}
@@ -1,5 +1,12 @@
 package types
 
+import (
+    "strings"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+)
+
 // View types
 type View int
 
@@ -11,6 +18,20 @@ const (
     ViewProfile
     ViewFriends
     ViewRecommendations
+    ViewDiscover
+    ViewImport
+    ViewNotes
+    ViewTimeline
+)
+
+// Connection status shown in the footer
+type ConnectionStatus int
+
+const (
+    ConnectionUnknown ConnectionStatus = iota
+    ConnectionOnline
+    ConnectionDegraded
+    ConnectionOffline
 )
 
 // Model types
@@ -27,11 +48,11 @@ type LoginForm struct {
 }
 
 func (f *LoginForm) UpdateUsername(msg interface{}) {
-    // Implement in your actual code
+    f.Username = EditText(f.Username, msg)
 }
 
 func (f *LoginForm) UpdatePassword(msg interface{}) {
-    // Implement in your actual code
+    f.Password = EditText(f.Password, msg)
 }
 
 func (f LoginForm) RenderUsername() string {
@@ -43,37 +64,148 @@ func (f LoginForm) RenderUsername() string {
 
 func (f LoginForm) RenderPassword() string {
     if f.Focused == "password" {
-        if f.Password == "" {
-            return "█"
+        return strings.Repeat("•", len([]rune(f.Password))) + "█"
+    }
+    return strings.Repeat("•", len([]rune(f.Password)))
+}
+
+// EditText applies a key press to a single-line text value.
+// Keys that don't edit text leave the value unchanged.
+func EditText(value string, msg interface{}) string {
+    key, ok := msg.(tea.KeyMsg)
+    if !ok {
+        return value
+    }
+
+    switch key.Type {
+    case tea.KeyRunes:
+        return value + string(key.Runes)
+    case tea.KeySpace:
+        return value + " "
+    case tea.KeyBackspace:
+        runes := []rune(value)
+        if len(runes) > 0 {
+            return string(runes[:len(runes)-1])
         }
-        return "•" + "█"
+    case tea.KeyCtrlU:
+        return ""
     }
-    return "••••••"
+    return value
 }
 
-type SearchBar struct {
+// IsTextKey reports whether a key press produces text when an input has focus.
+func IsTextKey(msg tea.KeyMsg) bool {
+    return msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace
+}
+
+type ReviewForm struct {
     Active  bool
-    Query   string
-    Results []Book
+    Text    string
+    Rating  int
+    Focused string // "text" or "rating"
+}
+
+func (f ReviewForm) Dirty() bool {
+    return f.Active && strings.TrimSpace(f.Text) != ""
+}
+
+// NoteForm writes a note, a quote or a named bookmark on a page of a book.
+// The page is text while it is typed, it starts as the page the book is
+// open at.
+type NoteForm struct {
+    Active  bool
+    BookID  int
+    Kind    string // "note", "quote" or "bookmark", as in the store
+    Text    string
+    Page    string
+    Focused string // "text" or "page"
+}
+
+func (f NoteForm) Dirty() bool {
+    return f.Active && strings.TrimSpace(f.Text) != ""
+}
+
+// NotesView lists the notes and highlights of every book, filtered by a
+// search typed in Query.
+type NotesView struct {
+    Query     string
+    Searching bool // the query has focus
+    Selected  int
+}
+
+// TimelineView shows how a book was read: its sittings, the pauses between
+// them and its bookmarks.
+type TimelineView struct {
+    BookID int
+    Scroll int // first sitting shown
+}
+
+type SearchBar struct {
+    Active   bool
+    Query    string
+    Results  []Book
+    Selected int
 }
 
+// BookList is the catalog as far as it has been loaded, page by page.
 type BookList struct {
     Books    []Book
     Selected int
-    Page     int
+    Page     int // pages loaded so far
     PageSize int
+    Total    int  // size of the whole catalog, -1 until known
+    Loading  bool // a page is on its way
+}
+
+// More reports whether there are pages left to load.
+func (l BookList) More() bool {
+    return l.Total < 0 || len(l.Books) < l.Total
+}
+
+// BookPage is one slice of the catalog.
+type BookPage struct {
+    Books  []Book
+    Offset int
+    Total  int // books in the whole catalog, -1 when the backend doesn't say
 }
 
 type ShelfView struct {
     Shelves       map[string][]Book
     SelectedShelf int
     SelectedBook  int
+    Bookcase      bool // draw books as spines on planks
+    Version       int  // bumped whenever Shelves is replaced, renders are cached by it
 }
 
 type ReadingView struct {
+    Sessions []ReadingSession
+    BookID   int // book of the selected session, kept across reloads
+}
+
+// Selected is the index of the selected session, -1 when there is none.
+func (rv ReadingView) Selected() int {
+    for i, s := range rv.Sessions {
+        if s.Book.ID == rv.BookID {
+            return i
+        }
+    }
+    return -1
+}
+
+// ReadingSession is a book being read and how far along it is.
+type ReadingSession struct {
     Book        Book
     CurrentPage int
-    Progress    float64
+    StartedAt   string
+    LastReadAt  string
+}
+
+// Progress is the share of the book read so far, 0..1.
+func (s ReadingSession) Progress() float64 {
+    if s.Book.Pages <= 0 {
+        return 0
+    }
+    return min(float64(s.CurrentPage)/float64(s.Book.Pages), 1)
 }
 
 type ProfileView struct {
@@ -146,6 +278,7 @@ type Activity struct {
 }
 
 type LibraryData struct {
+    LibraryID        int
     TotalBooks       int
     Shelves          map[string][]Book
     RecentAdds       []Book
@@ -176,8 +309,17 @@ type LoginErrorMsg struct {
 }
 
 type LoadLibraryMsg struct {
-    Books   []Book
-    Shelves map[string][]Book
+    Books     []Book // the books of the library, not the whole catalog
+    Shelves   map[string][]Book
+    LibraryID int // 0 when the user has no library yet
+}
+
+type LoadBookPageMsg struct {
+    Page BookPage
+}
+
+type LoadBookDetailsMsg struct {
+    Data BookData
 }
 
 type LoadUserMsg struct {
@@ -194,18 +336,51 @@ type LoadRecommendationsMsg struct {
 
 type LoadReadingSessionsMsg struct {
     Sessions []map[string]interface{}
+    Books    []Book // looked up for sessions of books not seen before
 }
 
 type ErrorMsg struct {
     Message string
 }
 
+type ReviewSubmittedMsg struct {
+    BookID int
+    Review Review
+}
+
 type SwitchToReadingMsg struct {
     BookID int
 }
 
+// FlushTurnsMsg closes a debounce window of page turns. Only the one
+// matching the latest turn sends them, older ones are stale.
+type FlushTurnsMsg struct {
+    Seq int
+}
+
+type PagesTurnedMsg struct {
+    BookID   int
+    Finished bool // the last page was reached, which ends the session
+}
+
 type ClearErrorMsg struct{}
 
+// NoticeMsg is good news for the footer, it goes away by itself.
+type NoticeMsg struct {
+    Text string
+}
+
+type ClearNoticeMsg struct {
+    Text string
+}
+
+type HealthTickMsg struct{}
+
+type HealthCheckMsg struct {
+    Latency time.Duration
+    Err     error
+}
+
 type ApiResponse struct {
     Success bool        `json:"success"`
     Data    interface{} `json:"data,omitempty"`
@@ -0,0 +1,178 @@
+package views
+
+import (
+    "strings"
+    "unicode/utf8"
+
+    "golang.org/x/text/unicode/bidi"
+)
+
+// Bidi turns on reordering of right-to-left text. Terminals that do their
+// own bidi (mlterm, Konsole with the option on) need it off, or text would
+// be flipped twice.
+var Bidi = true
+
+// Visual returns s in the order a terminal should draw it, so Arabic and
+// Hebrew read right to left even though cells are filled left to right.
+//
+// x/text's bidi package resolves the runs (paragraph direction, numbers,
+// neutrals and bracket pairs, UAX #9 up to the levels); the runs are then
+// reordered here (L2), which the package leaves to its caller. Grapheme
+// clusters are moved as a whole, so combining marks stay on their letter.
+func Visual(s string) string {
+    if !Bidi || !hasRTL(s) {
+        return s
+    }
+
+    var p bidi.Paragraph
+    if _, err := p.SetString(s); err != nil {
+        return s
+    }
+    order, err := p.Order()
+    if err != nil {
+        return s
+    }
+
+    // Rune positions to levels. The package only reports a direction per
+    // run, so a number that follows right-to-left text in a left-to-right
+    // paragraph, which sits a level higher (I1), is found here.
+    base := baseLevel(s)
+    runeLevels := make([]int, 0, len(s))
+    for i := 0; i < order.NumRuns(); i++ {
+        run := order.Run(i)
+        runes := []rune(run.String())
+        level, number := base, 0
+        switch {
+        case run.Direction() == bidi.RightToLeft:
+            level = 1
+        case base == 1:
+            level = 2
+        case i > 0:
+            number = leadingNumber(runes)
+        }
+        for j := range runes {
+            if j < number {
+                runeLevels = append(runeLevels, 2)
+            } else {
+                runeLevels = append(runeLevels, level)
+            }
+        }
+    }
+
+    // The package stops at a paragraph separator, the rest keeps the base level
+    for n := utf8.RuneCountInString(s); len(runeLevels) < n; {
+        runeLevels = append(runeLevels, base)
+    }
+
+    chars := Graphemes(s)
+    levels := make([]int, len(chars))
+    pos := 0
+    for i, c := range chars {
+        levels[i] = runeLevels[pos]
+        pos += utf8.RuneCountInString(c)
+    }
+
+    // Mirror brackets and quotes that end up in right-to-left runs
+    for i, c := range chars {
+        if levels[i]%2 == 1 {
+            if m, ok := mirrors[c]; ok {
+                chars[i] = m
+            }
+        }
+    }
+
+    reorder(chars, levels)
+    return strings.Join(chars, "")
+}
+
+// Fit truncates s to width cells, then puts it in visual order. Each field
+// goes through it on its own, so a Hebrew title inside an English label
+// keeps its own direction.
+func Fit(s string, width int) string {
+    return Visual(Truncate(s, width))
+}
+
+func hasRTL(s string) bool {
+    for _, r := range s {
+        p, _ := bidi.LookupRune(r)
+        if c := p.Class(); c == bidi.R || c == bidi.AL {
+            return true
+        }
+    }
+    return false
+}
+
+// baseLevel is the paragraph's level: 1 when its first strong character
+// is right-to-left (P2, P3).
+func baseLevel(s string) int {
+    for _, r := range s {
+        p, _ := bidi.LookupRune(r)
+        switch p.Class() {
+        case bidi.L:
+            return 0
+        case bidi.R, bidi.AL:
+            return 1
+        }
+    }
+    return 0
+}
+
+// leadingNumber is how many runes at the start of runes make up a number,
+// separators and currency signs included, e.g. 5 for "$12.5 more".
+func leadingNumber(runes []rune) int {
+    n := 0
+    for i, r := range runes {
+        p, _ := bidi.LookupRune(r)
+        switch p.Class() {
+        case bidi.EN, bidi.AN:
+            n = i + 1
+        case bidi.CS, bidi.ES, bidi.ET, bidi.NSM:
+        default:
+            return n
+        }
+    }
+    return n
+}
+
+// reorder reverses every run at or above each level, from the highest
+// level down to the lowest odd one (L2).
+func reorder(chars []string, levels []int) {
+    highest, lowestOdd := 0, -1
+    for _, l := range levels {
+        if l > highest {
+            highest = l
+        }
+        if l%2 == 1 && (lowestOdd == -1 || l < lowestOdd) {
+            lowestOdd = l
+        }
+    }
+    if lowestOdd == -1 {
+        return
+    }
+
+    for level := highest; level >= lowestOdd; level-- {
+        for i := 0; i < len(chars); {
+            if levels[i] < level {
+                i++
+                continue
+            }
+            j := i
+            for j < len(chars) && levels[j] >= level {
+                j++
+            }
+            for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+                chars[a], chars[b] = chars[b], chars[a]
+                levels[a], levels[b] = levels[b], levels[a]
+            }
+            i = j
+        }
+    }
+}
+
+var mirrors = map[string]string{
+    "(": ")", ")": "(",
+    "[": "]", "]": "[",
+    "{": "}", "}": "{",
+    "<": ">", ">": "<",
+    "«": "»", "»": "«",
+}
@@ -0,0 +1,100 @@
+package views
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestVisual(t *testing.T) {
+    tests := []struct {
+        name, logical, visual string
+    }{
+        {"latin only", "The Silent Horizon", "The Silent Horizon"},
+        {"hebrew", "שלום", "םולש"},
+        {"arabic", "كتاب", "باتك"},
+        {"hebrew words", "שלום עולם", "םלוע םולש"},
+
+        // Numbers keep their own order inside right-to-left text
+        {"hebrew and a year", "שנת 1984", "1984 תנש"},
+        {"decimal", "מחיר 12.5", "12.5 ריחמ"},
+        {"currency", "מחיר $30", "$30 ריחמ"},
+        {"arabic-indic digits", "الجزء ٢٣", "٢٣ ءزجلا"},
+
+        // Brackets are mirrored so they still open before what they enclose
+        {"year in brackets", "הספר (2019)", "(2019) רפסה"},
+        {"arabic brackets", "كتاب (الجزء 2)", "(2 ءزجلا) باتك"},
+        {"latin in brackets", "ספר (Dune)", "(Dune) רפס"},
+        {"hebrew in latin brackets", "Book (עולם)", "Book (םלוע)"},
+        {"guillemets", "«שלום»", "«םולש»"},
+
+        // Neutrals between runs of different directions take the paragraph's
+        {"comma and latin", "שלום, world!", "!world ,םולש"},
+        {"hebrew inside english", "Shalom שלום world", "Shalom םולש world"},
+        {"latin and a number", "ספר abc 123", "abc 123 רפס"},
+        {"number after hebrew in english", "abc שלום 12 x", "abc 12 םולש x"},
+        {"price after hebrew in english", "Buy ספר $12.5 now", "Buy $12.5 רפס now"},
+        {"dash between runs", "שלום - Peace", "Peace - םולש"},
+        {"trailing neutrals", "שלום...", "...םולש"},
+
+        // Marks stay on their letter
+        {"niqqud", "שָׁלוֹם", "םוֹלשָׁ"},
+    }
+    for _, tt := range tests {
+        if got := Visual(tt.logical); got != tt.visual {
+            t.Errorf("%s: Visual(%q) = %q, want %q", tt.name, tt.logical, got, tt.visual)
+        }
+    }
+}
+
+func TestVisualOff(t *testing.T) {
+    defer func(on bool) { Bidi = on }(Bidi)
+    Bidi = false
+    if got := Visual("הספר (2019)"); got != "הספר (2019)" {
+        t.Fatalf("Visual with Bidi off = %q", got)
+    }
+}
+
+// Text is cut in logical order, so the start of a title is kept and the
+// ellipsis ends up where a right-to-left reader finishes, on the left.
+func TestFitRTL(t *testing.T) {
+    tests := []struct {
+        s     string
+        width int
+        want  string
+    }{
+        {"שלום עולם", 20, "םלוע םולש"},
+        {"שלום עולם", 6, "…םולש"},
+        {"ספר 1984 חדש", 7, "…19 רפס"},
+        {"كتاب (الجزء 2)", 9, "…لا) باتك"}, // the bracket left open is still mirrored
+        {"Dune דיונה", 7, "Dune ד…"},
+    }
+    for _, tt := range tests {
+        got := Fit(tt.s, tt.width)
+        if got != tt.want {
+            t.Errorf("Fit(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+        }
+        if Width(got) > tt.width {
+            t.Errorf("Fit(%q, %d) is %d cells", tt.s, tt.width, Width(got))
+        }
+    }
+}
+
+// Books wrap a title before reordering each line, so the first words go
+// on the first line.
+func TestBookLinesRTL(t *testing.T) {
+    tests := []struct {
+        s    string
+        want []string
+    }{
+        {"Dune", []string{"Dune"}},
+        {"שלום", []string{"םולש"}},
+        {"שלום עולם", []string{"םולש", "…וע "}},
+        {"ספר 2", []string{" רפס", "2"}},
+    }
+    for _, tt := range tests {
+        got := bookLines(tt.s)
+        if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+            t.Errorf("bookLines(%q) = %q, want %q", tt.s, got, tt.want)
+        }
+    }
+}
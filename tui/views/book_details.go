@@ -4,21 +4,31 @@ import (
     "fmt"
     "strings"
     "github.com/charmbracelet/lipgloss"
+    "tui/store"
     "tui/styles"
     "tui/types"
+    "tui/zone"
 )
 
-func RenderBookDetails(book types.Book, reviews []types.Review) string {
-    header := styles.TitleStyle.Render(strings.ToUpper(book.Name))
+// reviewsShown is how many reviews fit on the card, the rest scroll.
+const reviewsShown = 5
+
+// highlightsShown is how many highlights fit on the card under the reviews.
+const highlightsShown = 4
+
+// RenderBookDetails draws a book's card, its reviews starting at reviewOffset
+// and the user's own notes and highlights, if any, listed in order.
+func RenderBookDetails(book types.Book, reviews []types.Review, reviewOffset int, highlights []store.Highlight, order store.Order) string {
+    header := styles.TitleStyle.Render(Fit(strings.ToUpper(book.Name), 52))
 
     meta := []string{
-        fmt.Sprintf("📖 Title: %s", book.Name),
-        fmt.Sprintf("✍️  Author: %s", book.Author),
+        fmt.Sprintf("📖 Title: %s", Fit(book.Name, 44)),
+        StableWidth(fmt.Sprintf("✍️  Author: %s", Fit(book.Author, 42))),
         fmt.Sprintf("📅 Year: %d", book.Year),
         fmt.Sprintf("📄 Pages: %d", book.Pages),
         fmt.Sprintf("⭐ Rating: %.1f/5", book.Rating),
         fmt.Sprintf("🌐 Language: %s", book.Language),
-        fmt.Sprintf("🏢 Publisher: %s", book.Publisher),
+        fmt.Sprintf("🏢 Publisher: %s", Fit(book.Publisher, 40)),
     }
 
     // Reviews section
@@ -26,17 +36,42 @@ func RenderBookDetails(book types.Book, reviews []types.Review) string {
     if len(reviews) == 0 {
         reviewsSection += "  No reviews yet\n"
     } else {
-        for _, review := range reviews {
-            reviewsSection += fmt.Sprintf("  %s: ⭐%d - %s\n", review.User, review.Rating, review.Text)
+        reviewOffset = ClampReviewOffset(reviewOffset, len(reviews))
+        end := min(reviewOffset+reviewsShown, len(reviews))
+        if reviewOffset > 0 {
+            reviewsSection += styles.HintStyle.Render(fmt.Sprintf("  ↑ %d more", reviewOffset)) + "\n"
+        }
+        for _, review := range reviews[reviewOffset:end] {
+            prefix := fmt.Sprintf("%s: ⭐%d - ", Fit(review.User, 16), review.Rating)
+            reviewsSection += "  " + prefix + Fit(review.Text, 52-Width(prefix)) + "\n"
+        }
+        if end < len(reviews) {
+            reviewsSection += styles.HintStyle.Render(fmt.Sprintf("  ↓ %d more", len(reviews)-end)) + "\n"
+        }
+    }
+
+    // Highlights section, only when there are some
+    highlightsSection := ""
+    if len(highlights) > 0 {
+        sorted := "by page"
+        if order == store.ByTime {
+            sorted = "by date"
+        }
+        highlightsSection = fmt.Sprintf("\n✨ Notes & highlights (%d, %s):\n", len(highlights), sorted)
+        for _, h := range highlights[:min(len(highlights), highlightsShown)] {
+            highlightsSection += "  " + highlightLine(h, 52) + "\n"
+        }
+        if len(highlights) > highlightsShown {
+            highlightsSection += styles.HintStyle.Render(fmt.Sprintf("  … and %d more", len(highlights)-highlightsShown)) + "\n"
         }
     }
 
     actions := lipgloss.JoinHorizontal(
         lipgloss.Top,
-        styles.ButtonStyle.Render("📖 Start Reading"),
-        styles.ButtonStyle.Render("➕ Add to Library"),
-        styles.ButtonStyle.Render("💬 Add Review"),
-        styles.ButtonStyle.Render("⭐ Rate Book"),
+        zone.Mark(ButtonStartReading, styles.ButtonStyle.Render("📖 Start Reading")),
+        zone.Mark(ButtonAddToLibrary, styles.ButtonStyle.Render("➕ Add to Library")),
+        zone.Mark(ButtonAddReview, styles.ButtonStyle.Render("💬 Add Review")),
+        zone.Mark(ButtonRate, styles.ButtonStyle.Render("⭐ Rate Book")),
     )
 
     content := lipgloss.JoinVertical(
@@ -44,10 +79,43 @@ func RenderBookDetails(book types.Book, reviews []types.Review) string {
         header,
         "\n",
         strings.Join(meta, "\n"),
-        reviewsSection,
+        "",
+        zone.Mark(ZoneReviews, lipgloss.NewStyle().Width(56).Render(strings.Trim(reviewsSection, "\n"))),
+        strings.TrimRight(highlightsSection, "\n"),
+        "",
         "\n",
         actions,
     )
 
     return styles.CardStyle.Width(60).Render(content)
-}
\ No newline at end of file
+}
+
+// ClampReviewOffset keeps a review scroll position within the list.
+func ClampReviewOffset(offset, count int) int {
+    return max(min(offset, count-reviewsShown), 0)
+}
+
+// highlightLine is one highlight on a single line of width cells: where it
+// is, then its text. Notes and quotes are marked, bookmarks have no text.
+func highlightLine(h store.Highlight, width int) string {
+    where := "p. " + fmt.Sprint(h.Page)
+    if h.Page == 0 {
+        where = "loc. " + h.Location
+        if h.Location == "" {
+            where = "—"
+        }
+    }
+    prefix := fmt.Sprintf("%-10s ", where)
+    switch h.Kind {
+    case store.KindBookmark:
+        if h.Text == "" {
+            return prefix + styles.HintStyle.Render("🔖 bookmark")
+        }
+        prefix += "🔖 "
+    case store.KindNote:
+        prefix += "✎ "
+    case store.KindQuote:
+        prefix += "❝ "
+    }
+    return prefix + Fit(strings.Join(strings.Fields(h.Text), " "), max(width-Width(prefix), 10))
+}
@@ -0,0 +1,72 @@
+package views
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/charmbracelet/lipgloss"
+    "tui/styles"
+    "tui/types"
+)
+
+func TestSpineHeight(t *testing.T) {
+    tests := []struct{ pages, want int }{
+        {0, minSpineHeight},
+        {120, minSpineHeight},
+        {300, 5},
+        {503, 8},
+        {5000, maxSpineHeight},
+    }
+    for _, tt := range tests {
+        if got := spineHeight(tt.pages); got != tt.want {
+            t.Errorf("spineHeight(%d) = %d, want %d", tt.pages, got, tt.want)
+        }
+    }
+
+    // The spine is as tall as its height plus the border, whatever the title
+    for _, book := range []types.Book{{Name: "It", Pages: 1138}, {Name: "The Silent Horizon", Pages: 0}} {
+        want := spineHeight(book.Pages) + 2
+        if got := lipgloss.Height(renderBook(book, styles.Current.Read, false)); got != want {
+            t.Errorf("%q is %d lines tall, want %d", book.Name, got, want)
+        }
+    }
+}
+
+func TestSpineColor(t *testing.T) {
+    c := styles.Current
+    dune := types.Book{Name: "Dune", Language: "English"}
+
+    // A language keeps its colour on every shelf, whatever the case
+    if spineColor("read", dune, "language") != spineColor("to_read", types.Book{Language: "english"}, "language") {
+        t.Error("a language changed colour between shelves")
+    }
+    if spineColor("read", dune, "shelf") != styles.ShelfColor("read") {
+        t.Error("shelf colouring does not use the shelf colour")
+    }
+
+    ratings := []struct {
+        rating float64
+        want   lipgloss.TerminalColor
+    }{
+        {0, c.Muted},
+        {1.5, c.Danger},
+        {3, c.Warning},
+        {4.2, c.ToRead},
+        {4.5, c.Success},
+    }
+    for _, tt := range ratings {
+        dune.Rating = tt.rating
+        if got := spineColor("read", dune, "rating"); got != tt.want {
+            t.Errorf("rating %.1f: %v, want %v", tt.rating, got, tt.want)
+        }
+    }
+}
+
+func TestRenderBookTitle(t *testing.T) {
+    spine := renderBook(types.Book{Name: "Dune", Pages: 412}, styles.Current.Read, false)
+    for _, letter := range []string{"D", "U", "N", "E"} {
+        if !strings.Contains(spine, letter) {
+            t.Errorf("%q missing from the spine\n%s", letter, spine)
+        }
+    }
+}
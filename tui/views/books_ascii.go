@@ -4,42 +4,66 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"tui/styles"
+	"tui/types"
 )
 
-func bookStyle(color lipgloss.Color, selected bool) lipgloss.Style {
+// Spine heights in lines of text, the thickest book in the seed catalog
+// (503 pages) gets close to the maximum.
+const (
+	minSpineHeight = 4
+	maxSpineHeight = 9
+	pagesPerLine   = 60
+)
+
+func bookStyle(color lipgloss.TerminalColor, height int, selected bool) lipgloss.Style {
 	style := lipgloss.NewStyle().
 		Foreground(color).
 		Border(lipgloss.ThickBorder()).
 		BorderForeground(color).
 		Width(5).
-		Height(7).
+		Height(height).
 		Align(lipgloss.Center)
 
 	if selected {
 		style = style.
-			BorderForeground(lipgloss.Color("#FFFFFF")).
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(lipgloss.Color("#3A86FF")).
-			Bold(true)
+			BorderForeground(styles.Current.OnHighlight).
+			Foreground(styles.Current.OnHighlight).
+			Background(styles.Current.Highlight).
+			Bold(true).
+			Reverse(styles.Current.Mono)
 	}
 
 	return style
 }
 
-func renderBook(name string, color lipgloss.Color, selected bool) string {
-	name = strings.ToUpper(name)
-	runes := []rune(name)
+// spineHeight grows with the page count, so thick books stand taller.
+func spineHeight(pages int) int {
+	height := pages / pagesPerLine
+	if height < minSpineHeight {
+		return minSpineHeight
+	}
+	if height > maxSpineHeight {
+		return maxSpineHeight
+	}
+	return height
+}
+
+func renderBook(book types.Book, color lipgloss.TerminalColor, selected bool) string {
+	height := spineHeight(book.Pages)
 
-	lines := make([]string, 0, 5)
-	for i := 0; i < len(runes) && i < 5; i++ {
-		lines = append(lines, string(runes[i]))
+	// One grapheme per line, so accents and wide characters stay whole
+	chars := Graphemes(strings.ToUpper(book.Name))
+
+	lines := make([]string, 0, height)
+	for i := 0; i < len(chars) && i < height; i++ {
+		lines = append(lines, chars[i])
 	}
-	for len(lines) < 5 {
+	for len(lines) < height {
 		lines = append(lines, " ")
 	}
 
 	content := strings.Join(lines, "\n")
 
-	return bookStyle(color, selected).Render(content)
+	return bookStyle(color, height, selected).Render(content)
 }
-
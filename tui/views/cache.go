@@ -0,0 +1,56 @@
+package views
+
+import (
+    "sync"
+
+    "tui/styles"
+)
+
+// Rendering a shelf means a lipgloss render per book, far too slow to redo
+// for every key press and timer tick. Shelves are kept rendered here and
+// only drawn again when something they show changes.
+
+type shelfKey struct {
+    shelf    string
+    version  int // types.ShelfView.Version, bumped when the shelves are replaced
+    selected int // the selected book, -1 when the shelf isn't focused
+    width    int
+    bookcase bool
+    colorBy  string
+
+    // Package settings, they rarely change but must not serve stale renders
+    theme string
+    wrap  bool
+    bidi  bool
+}
+
+// maxCached bounds the cache, a few entries per shelf are enough to move
+// the selection back and forth without re-rendering.
+const maxCached = 64
+
+var shelfCache = struct {
+    sync.Mutex
+    entries map[shelfKey]string
+}{entries: map[shelfKey]string{}}
+
+// cachedShelf returns the render stored under key, or renders and stores it.
+func cachedShelf(key shelfKey, render func() string) string {
+    key.theme, key.wrap, key.bidi = styles.Current.Name, WrapShelves, Bidi
+
+    shelfCache.Lock()
+    s, ok := shelfCache.entries[key]
+    shelfCache.Unlock()
+    if ok {
+        return s
+    }
+
+    s = render()
+
+    shelfCache.Lock()
+    if len(shelfCache.entries) >= maxCached {
+        shelfCache.entries = map[shelfKey]string{}
+    }
+    shelfCache.entries[key] = s
+    shelfCache.Unlock()
+    return s
+}
@@ -0,0 +1,111 @@
+package views
+
+import (
+    "strings"
+    "testing"
+
+    "tui/styles"
+    "tui/types"
+)
+
+func resetShelfCache() {
+    shelfCache.Lock()
+    shelfCache.entries = map[shelfKey]string{}
+    shelfCache.Unlock()
+}
+
+func TestShelfCacheInvalidation(t *testing.T) {
+    resetShelfCache()
+    theme := styles.Current
+    wrap, bidi := WrapShelves, Bidi
+    t.Cleanup(func() {
+        styles.Use(theme)
+        WrapShelves, Bidi = wrap, bidi
+        resetShelfCache()
+    })
+
+    renders := 0
+    render := func() string {
+        renders++
+        return "shelf"
+    }
+    key := shelfKey{shelf: "read", version: 1, selected: -1, width: 120}
+    cachedShelf(key, render)
+
+    tests := []struct {
+        name   string
+        change func(k *shelfKey)
+        render bool
+    }{
+        {"same frame", func(k *shelfKey) {}, false},
+        {"shelves replaced", func(k *shelfKey) { k.version++ }, true},
+        {"resized", func(k *shelfKey) { k.width = 80 }, true},
+        {"selection moved", func(k *shelfKey) { k.selected = 3 }, true},
+        {"back to a cached selection", func(k *shelfKey) { k.selected = -1 }, false},
+        {"bookcase", func(k *shelfKey) { k.bookcase = true }, true},
+        {"spine colours", func(k *shelfKey) { k.colorBy = "rating" }, true},
+        {"theme", func(k *shelfKey) { styles.Use(styles.LightPalette.Theme("light")) }, true},
+        {"wrapped shelves", func(k *shelfKey) { WrapShelves = !WrapShelves }, true},
+        {"bidi", func(k *shelfKey) { Bidi = !Bidi }, true},
+        {"same frame again", func(k *shelfKey) {}, false},
+    }
+    for _, tt := range tests {
+        tt.change(&key)
+        before := renders
+        cachedShelf(key, render)
+        if rendered := renders > before; rendered != tt.render {
+            t.Errorf("%s: rendered = %v, want %v", tt.name, rendered, tt.render)
+        }
+    }
+}
+
+// A library bumped to a new Version shows its new books, not the old render.
+func TestRenderLibraryNewVersion(t *testing.T) {
+    resetShelfCache()
+    sv := types.ShelfView{Shelves: map[string][]types.Book{"read": {{ID: 1, Name: "Dune", Author: "Herbert"}}}, Version: 1}
+    if got := RenderLibrary(sv, 100); !strings.Contains(got, "Dune") {
+        t.Fatalf("first render lacks the book:\n%s", got)
+    }
+
+    sv = types.ShelfView{Shelves: map[string][]types.Book{"read": {{ID: 2, Name: "Emma", Author: "Austen"}}}, Version: 2}
+    if got := RenderLibrary(sv, 100); !strings.Contains(got, "Emma") || strings.Contains(got, "Dune") {
+        t.Fatalf("render after a new version:\n%s", got)
+    }
+}
+
+// BenchmarkRenderLibrary draws a library of 10k books in 120 columns.
+// "uncached" redraws every shelf each frame as before the shelf cache,
+// "moving" re-renders the focused shelf only, "cached" is an idle frame.
+func BenchmarkRenderLibrary(b *testing.B) {
+    sv := bigLibrary(10000)
+    layouts := []struct {
+        name   string
+        render func(types.ShelfView) string
+    }{
+        {"boxes", func(sv types.ShelfView) string { return RenderLibrary(sv, 120) }},
+        {"bookcase", func(sv types.ShelfView) string { return RenderBookcase(sv, 120, "shelf") }},
+    }
+    for _, style := range layouts {
+        b.Run(style.name+"/uncached", func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                resetShelfCache()
+                style.render(sv)
+            }
+        })
+        b.Run(style.name+"/moving", func(b *testing.B) {
+            resetShelfCache()
+            for i := 0; i < b.N; i++ {
+                sv.SelectedBook = i % len(sv.Shelves[ShelfOrder[0]])
+                style.render(sv)
+            }
+        })
+        b.Run(style.name+"/cached", func(b *testing.B) {
+            resetShelfCache()
+            style.render(sv)
+            b.ResetTimer()
+            for i := 0; i < b.N; i++ {
+                style.render(sv)
+            }
+        })
+    }
+}
@@ -0,0 +1,54 @@
+package views
+
+import (
+    "fmt"
+    "strings"
+
+    "tui/styles"
+    "tui/types"
+)
+
+// RenderCatalog lists the loaded part of the catalog, rows lines around the
+// selected book. Only those lines are rendered, however much is loaded.
+func RenderCatalog(list types.BookList, rows int) string {
+    title := "📚 Catalog"
+    switch {
+    case list.Total >= 0:
+        title += fmt.Sprintf(" — %d of %d books", len(list.Books), list.Total)
+    default:
+        title += fmt.Sprintf(" — %d books", len(list.Books))
+    }
+    lines := []string{styles.TitleStyle.Render(title)}
+
+    if len(list.Books) == 0 {
+        if list.Loading {
+            return strings.Join(append(lines, styles.LoadingStyle.Render("Loading...")), "\n")
+        }
+        return strings.Join(append(lines, "No books."), "\n")
+    }
+
+    start := max(min(list.Selected-rows/2, len(list.Books)-rows), 0)
+    end := min(start+rows, len(list.Books))
+    for i := start; i < end; i++ {
+        book := list.Books[i]
+        line := fmt.Sprintf("%s  %s  %4d",
+            pad(Fit(book.Name, 40), 40), pad(Fit(book.Author, 24), 24), book.Year)
+        if i == list.Selected {
+            lines = append(lines, styles.SelectedStyle.Render("▸ "+line))
+        } else {
+            lines = append(lines, "  "+line)
+        }
+    }
+
+    if list.Loading {
+        lines = append(lines, styles.LoadingStyle.Render("Loading more..."))
+    } else if list.More() {
+        lines = append(lines, styles.HintStyle.Render("More books load as you scroll down"))
+    }
+    return strings.Join(lines, "\n")
+}
+
+// pad fills s with spaces up to width cells, fmt pads by bytes.
+func pad(s string, width int) string {
+    return s + strings.Repeat(" ", max(width-Width(s), 0))
+}
@@ -0,0 +1,84 @@
+package views
+
+import (
+    "fmt"
+
+    "github.com/charmbracelet/lipgloss"
+    "tui/styles"
+)
+
+// WrapShelves lays books that don't fit on one line out in more rows.
+// Otherwise a shelf scrolls sideways, keeping the selected book in view.
+var WrapShelves = false
+
+// maxRows is how many rows a wrapped shelf shows at once.
+const maxRows = 3
+
+// rowBooks lays out count books, each cell columns wide, in width cells, by
+// wrapping or scrolling. Only the books that end up on screen are rendered,
+// so a shelf of thousands costs no more than one of ten. selected is the
+// book to keep visible, -1 when the shelf isn't focused. A width of 0 or
+// less means there is no limit.
+func rowBooks(count, cell, selected, width int, pos lipgloss.Position, render func(i int) string) string {
+    if width <= 0 || count*cell <= width {
+        return joinBooks(0, count, pos, render)
+    }
+    if selected < 0 {
+        selected = 0
+    }
+    if WrapShelves {
+        return wrapBooks(count, cell, selected, width, pos, render)
+    }
+    return scrollBooks(count, cell, selected, width, pos, render)
+}
+
+func joinBooks(start, end int, pos lipgloss.Position, render func(i int) string) string {
+    books := make([]string, 0, end-start)
+    for i := start; i < end; i++ {
+        books = append(books, render(i))
+    }
+    return lipgloss.JoinHorizontal(pos, books...)
+}
+
+// wrapBooks shows up to maxRows rows, starting low enough to include the
+// row of the selected book.
+func wrapBooks(count, cell, selected, width int, pos lipgloss.Position, render func(i int) string) string {
+    perRow := max(width/cell, 1)
+    rows := (count + perRow - 1) / perRow
+    first := max(selected/perRow-maxRows+1, 0)
+    last := min(first+maxRows, rows)
+
+    var lines []string
+    if first > 0 {
+        lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("↑ %d more rows", first)))
+    }
+    for row := first; row < last; row++ {
+        lines = append(lines, joinBooks(row*perRow, min((row+1)*perRow, count), pos, render))
+    }
+    if last < rows {
+        lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("↓ %d more rows", rows-last)))
+    }
+    return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// scrollBooks shows the window of books around the selected one, with the
+// number of books hidden on each side.
+func scrollBooks(count, cell, selected, width int, pos lipgloss.Position, render func(i int) string) string {
+    // Room for "‹12" or "12›" with padding, as wide as the largest count
+    marker := lipgloss.Width(fmt.Sprintf("‹%d", count)) + 1
+    visible := max((width-2*marker)/cell, 1)
+    start := max(selected-visible+1, 0)
+    end := min(start+visible, count)
+
+    hint := styles.HintStyle.Copy().Width(marker).Align(lipgloss.Center)
+    left, right := hint.Render(""), hint.Render("")
+    if start > 0 {
+        left = hint.Render(fmt.Sprintf("‹%d", start))
+    }
+    if end < count {
+        right = hint.Render(fmt.Sprintf("%d›", count-end))
+    }
+
+    row := joinBooks(start, end, pos, render)
+    return lipgloss.JoinHorizontal(lipgloss.Center, left, row, right)
+}
@@ -0,0 +1,107 @@
+package views
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/charmbracelet/lipgloss"
+    "tui/types"
+)
+
+func manyBooks(n int) []types.Book {
+    books := make([]types.Book, n)
+    for i := range books {
+        books[i] = types.Book{ID: i + 1, Name: fmt.Sprintf("Book number %d", i+1), Author: "Ava Mitchell"}
+    }
+    return books
+}
+
+// bigLibrary is n books spread over the three shelves.
+func bigLibrary(n int) types.ShelfView {
+    sv := types.ShelfView{Shelves: map[string][]types.Book{}, Version: 1}
+    for i, book := range manyBooks(n) {
+        shelf := ShelfOrder[i%len(ShelfOrder)]
+        sv.Shelves[shelf] = append(sv.Shelves[shelf], book)
+    }
+    return sv
+}
+
+// cellOf renders book i as a cell exactly 8 columns wide and counts it.
+func cellOf(rendered *int) func(i int) string {
+    return func(i int) string {
+        *rendered++
+        return fmt.Sprintf("[%06d]", i)
+    }
+}
+
+func TestRowBooks(t *testing.T) {
+    defer func(wrap bool) { WrapShelves = wrap }(WrapShelves)
+
+    tests := []struct {
+        name                   string
+        wrap                   bool
+        count, selected, width int
+        rendered               int
+        contains               []string
+    }{
+        {"fits", false, 10, 3, 120, 10, []string{"[000000]", "[000009]"}},
+        {"no limit", false, 10000, 0, 0, 10000, []string{"[009999]"}},
+        {"scroll a short shelf", false, 20, 19, 40, 4, []string{"‹16", "[000016]", "[000019]"}},
+        // (120 - two 7 column markers for "‹10000") / 8 = 13 books on screen
+        {"scroll from the start", false, 10000, -1, 120, 13, []string{"[000000]", "[000012]", "9987›"}},
+        {"scroll to the selection", false, 10000, 5000, 120, 13, []string{"‹4988", "[004988]", "[005000]", "4999›"}},
+        {"scroll to the end", false, 10000, 9999, 120, 13, []string{"‹9987", "[009999]"}},
+        // 15 books a row, 667 rows, the selection's row is the last shown
+        {"wrap from the start", true, 10000, -1, 120, 45, []string{"[000044]", "↓ 664 more rows"}},
+        {"wrap to the selection", true, 10000, 5000, 120, 45, []string{"↑ 331 more rows", "[005000]", "↓ 333 more rows"}},
+        {"wrap to the end", true, 10000, 9999, 120, 40, []string{"↑ 664 more rows", "[009999]"}},
+    }
+    for _, tt := range tests {
+        WrapShelves = tt.wrap
+        rendered := 0
+        got := rowBooks(tt.count, 8, tt.selected, tt.width, lipgloss.Top, cellOf(&rendered))
+
+        if rendered != tt.rendered {
+            t.Errorf("%s: rendered %d books, want %d", tt.name, rendered, tt.rendered)
+        }
+        if !tt.wrap && strings.Contains(got, "\n") {
+            t.Errorf("%s: scrolled shelf takes more than one line:\n%s", tt.name, got)
+        }
+        if tt.width > 0 && lipgloss.Width(got) > tt.width {
+            t.Errorf("%s: %d columns wide, want at most %d", tt.name, lipgloss.Width(got), tt.width)
+        }
+        for _, want := range tt.contains {
+            if !strings.Contains(got, want) {
+                t.Errorf("%s: %q not in\n%s", tt.name, want, got)
+            }
+        }
+    }
+}
+
+// BenchmarkRowBooks lays out a shelf of 10k real book cards. "every book"
+// is what the layout cost before only the visible books were rendered.
+func BenchmarkRowBooks(b *testing.B) {
+    defer func(wrap bool) { WrapShelves = wrap }(WrapShelves)
+    books := manyBooks(10000)
+    cell := lipgloss.Width(RenderBook(books[0], false))
+    render := func(i int) string { return RenderBook(books[i], i == 5000) }
+
+    b.Run("every book", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            joinBooks(0, len(books), lipgloss.Top, render)
+        }
+    })
+    for _, wrap := range []bool{false, true} {
+        name := "scroll"
+        if wrap {
+            name = "wrap"
+        }
+        b.Run(name, func(b *testing.B) {
+            WrapShelves = wrap
+            for i := 0; i < b.N; i++ {
+                rowBooks(len(books), cell, 5000, 120, lipgloss.Top, render)
+            }
+        })
+    }
+}
@@ -1,38 +1,43 @@
 package views
 
 import (
+    "tui/styles"
     "tui/types"
+    "tui/zone"
     "github.com/charmbracelet/lipgloss"
 )
 
-func RenderLibrary(shelves map[string][]types.Book, selectedShelf, selectedBook int) string {
-    shelfNames := []string{"to_read", "currently_reading", "read"}
-
+// RenderLibrary draws every shelf in width cells, 0 for no limit.
+func RenderLibrary(sv types.ShelfView, width int) string {
     var renderedShelves []string
 
-    for i, shelfName := range shelfNames {
-        books := shelves[shelfName]
-        isSelected := i == selectedShelf
+    for i, shelfName := range ShelfOrder {
+        books := sv.Shelves[shelfName]
+        isSelected := i == sv.SelectedShelf
 
-        shelfContent := RenderShelf(shelfName, books, selectedBook, isSelected)
+        key := shelfKey{shelf: shelfName, version: sv.Version, selected: -1, width: width}
         if isSelected {
-            shelfContent = lipgloss.NewStyle().
-                Padding(1, 2).
-                Border(lipgloss.RoundedBorder()).
-                BorderForeground(lipgloss.Color("#2563EB")).
-                Background(lipgloss.Color("#374151")).
-                Render(shelfContent)
-        } else {
-            shelfContent = lipgloss.NewStyle().
-                Padding(1, 2).
-                Border(lipgloss.RoundedBorder()).
-                BorderForeground(lipgloss.Color("#4B5563")).
-                Background(lipgloss.Color("#374151")).
-                Render(shelfContent)
+            key.selected = sv.SelectedBook
         }
+        shelfContent := cachedShelf(key, func() string {
+            frame := styles.ShelfFrameStyle
+            if isSelected {
+                frame = styles.ShelfFrameSelectedStyle
+            }
+            shelf := RenderShelf(shelfName, books, sv.SelectedBook, isSelected, innerWidth(frame, width))
+            return zone.Mark(ShelfZone(shelfName), frame.Render(shelf))
+        })
 
         renderedShelves = append(renderedShelves, shelfContent)
     }
 
     return lipgloss.JoinVertical(lipgloss.Top, renderedShelves...)
-}
\ No newline at end of file
+}
+
+// innerWidth is what is left of width inside frame, 0 stays "no limit".
+func innerWidth(frame lipgloss.Style, width int) int {
+    if width <= 0 {
+        return 0
+    }
+    return max(width-frame.GetHorizontalFrameSize(), 1)
+}
@@ -0,0 +1,53 @@
+package views
+
+import (
+    "fmt"
+    "strings"
+
+    "tui/store"
+    "tui/styles"
+    "tui/types"
+)
+
+// RenderNotes lists the notes and highlights of every book that match the
+// view's query, rows at most, keeping the selected one in sight.
+func RenderNotes(nv types.NotesView, notes []store.Highlight, books map[int]types.Book, rows, width int) string {
+    title := styles.TitleStyle.Render(fmt.Sprintf("📝 Notes — %d", len(notes)))
+
+    search := "/ to search"
+    switch {
+    case nv.Searching:
+        search = styles.InputStyle.Render("🔍 " + nv.Query + "█")
+    case nv.Query != "":
+        search = "🔍 " + nv.Query + styles.HintStyle.Render("  (/ to change)")
+    }
+    lines := []string{title, search, ""}
+
+    if len(notes) == 0 {
+        hint := "No notes yet. Press n in the reading view or on a book to write one."
+        if nv.Query != "" {
+            hint = "Nothing matches."
+        }
+        return strings.Join(append(lines, styles.HintStyle.Render(hint)), "\n")
+    }
+
+    start := max(min(nv.Selected-rows/2, len(notes)-rows), 0)
+    end := min(start+rows, len(notes))
+    for i := start; i < end; i++ {
+        n := notes[i]
+        name := fmt.Sprintf("Book %d", n.BookID)
+        if b, ok := books[n.BookID]; ok {
+            name = b.Name
+        }
+        line := pad(Fit(name, 24), 24) + "  " + highlightLine(n, max(width-32, 20))
+        if i == nv.Selected {
+            lines = append(lines, styles.SelectedStyle.Render("▸ "+line))
+        } else {
+            lines = append(lines, "  "+line)
+        }
+    }
+    if end < len(notes) || start > 0 {
+        lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("  %d–%d of %d", start+1, end, len(notes))))
+    }
+    return strings.Join(lines, "\n")
+}
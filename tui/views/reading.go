@@ -0,0 +1,66 @@
+package views
+
+import (
+    "fmt"
+    "strings"
+
+    "tui/styles"
+    "tui/types"
+)
+
+// RenderReading lists the books being read with their progress, and the
+// selected one in full. unsaved marks page turns not sent to the backend yet.
+func RenderReading(rv types.ReadingView, unsaved bool, width int) string {
+    title := styles.TitleStyle.Render(fmt.Sprintf("📖 Reading — %d books", len(rv.Sessions)))
+    if len(rv.Sessions) == 0 {
+        return title + "\n" + styles.HintStyle.Render("Nothing on the go. Open a book and start reading it.")
+    }
+
+    lines := []string{title}
+    for i, s := range rv.Sessions {
+        line := fmt.Sprintf("%s  %s  %s",
+            pad(Fit(s.Book.Name, 36), 36), progressBar(s.Progress(), 16), pageOf(s))
+        if i == rv.Selected() {
+            lines = append(lines, styles.SelectedStyle.Render("▸ "+line))
+        } else {
+            lines = append(lines, "  "+line)
+        }
+    }
+
+    if i := rv.Selected(); i >= 0 {
+        s := rv.Sessions[i]
+        status := "Started " + shortDate(s.StartedAt)
+        if s.LastReadAt != "" {
+            status += ", last read " + shortDate(s.LastReadAt)
+        }
+        if unsaved {
+            status += " · saving…"
+        }
+        lines = append(lines, "",
+            styles.SubtitleStyle.Render(Fit(s.Book.Name+" by "+s.Book.Author, max(width-4, 10))),
+            fmt.Sprintf("%s  %3.0f%%", progressBar(s.Progress(), min(max(width-16, 10), 60)), s.Progress()*100),
+            pageOf(s),
+            styles.HintStyle.Render(status),
+        )
+    }
+    return strings.Join(lines, "\n")
+}
+
+// progressBar draws share, 0..1, as a bar width cells long.
+func progressBar(share float64, width int) string {
+    filled := min(max(int(share*float64(width)+0.5), 0), width)
+    return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func pageOf(s types.ReadingSession) string {
+    if s.Book.Pages <= 0 {
+        return fmt.Sprintf("p. %d", s.CurrentPage)
+    }
+    return fmt.Sprintf("p. %d of %d", s.CurrentPage, s.Book.Pages)
+}
+
+// shortDate keeps the day of a "2006-01-02T15:04:05" timestamp.
+func shortDate(ts string) string {
+    day, _, _ := strings.Cut(ts, "T")
+    return day
+}
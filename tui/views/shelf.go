@@ -5,25 +5,25 @@ import (
     "github.com/charmbracelet/lipgloss"
     "tui/styles"
     "tui/types"
+    "tui/zone"
 )
 
-func RenderShelf(name string, books []types.Book, selectedBook int, isSelected bool) string {
-    var renderedBooks []string
-
-    for i, book := range books {
-        bookSelected := isSelected && i == selectedBook
-        renderedBooks = append(renderedBooks, RenderBook(book, bookSelected))
+// RenderShelf draws a shelf in width cells, see rowBooks for how books that
+// don't fit are handled.
+func RenderShelf(name string, books []types.Book, selectedBook int, isSelected bool, width int) string {
+    focus := -1
+    if isSelected {
+        focus = selectedBook
     }
 
-    if len(renderedBooks) == 0 {
-        renderedBooks = []string{styles.BookStyle.Render("Empty\nShelf")}
+    bookRow := styles.BookStyle.Render("Empty\nShelf")
+    if len(books) > 0 {
+        cell := lipgloss.Width(bookRow)
+        bookRow = rowBooks(len(books), cell, focus, width, lipgloss.Top, func(i int) string {
+            return zone.Mark(BookZone(name, i), RenderBook(books[i], i == focus))
+        })
     }
 
-    bookRow := lipgloss.JoinHorizontal(
-        lipgloss.Top,
-        renderedBooks...,
-    )
-
     title := styles.ShelfPlankStyle.Render(" " + strings.ReplaceAll(name, "_", " ") + " ")
 
     return lipgloss.JoinVertical(
@@ -35,17 +35,10 @@ func RenderShelf(name string, books []types.Book, selectedBook int, isSelected b
 }
 
 func RenderBook(book types.Book, selected bool) string {
-    title := book.Name
-    if len(title) > 8 {
-        title = title[:8] + ".."
-    }
-
-    lines := []string{
-        "",
-        title,
-        "─────",
-        book.Author,
-    }
+    lines := []string{""}
+    lines = append(lines, bookLines(book.Name)...)
+    lines = append(lines, "────")
+    lines = append(lines, bookLines(book.Author)...)
 
     content := strings.Join(lines, "\n")
 
@@ -55,4 +48,14 @@ func RenderBook(book types.Book, selected bool) string {
     }
 
     return style.Render(content)
+}
+
+// bookLines fits s on two lines of a book. It wraps before reordering,
+// lipgloss would start right-to-left text on the wrong line.
+func bookLines(s string) []string {
+    lines := Wrap(Truncate(s, 8), 4)
+    for i, line := range lines {
+        lines[i] = Visual(line)
+    }
+    return lines
 }
\ No newline at end of file
@@ -1,63 +1,100 @@
 package views
 
 import (
+	"hash/fnv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"tui/styles"
+	"tui/types"
+	"tui/zone"
 )
 
-var shelfPlank = lipgloss.NewStyle().
-	Background(lipgloss.Color("#5C4033")).
-	Foreground(lipgloss.Color("#F5DEB3")).
-	Bold(true).
-	Padding(0, 1)
-
 var shelfFrame = lipgloss.NewStyle().
 	Padding(1, 1)
 
-func shelfColor(name string) lipgloss.Color {
-	switch name {
-	case "to_read":
-		return lipgloss.Color("#5DA9E9")
-	case "currently_reading":
-		return lipgloss.Color("#F4D35E")
-	case "read":
-		return lipgloss.Color("#7AE582")
+// RenderBookcase draws the library as a bookcase. Selection and width work
+// as in RenderLibrary.
+func RenderBookcase(sv types.ShelfView, width int, colorBy string) string {
+	var renderedShelves []string
+
+	for i, shelfName := range ShelfOrder {
+		key := shelfKey{shelf: shelfName, version: sv.Version, selected: -1, width: width, bookcase: true, colorBy: colorBy}
+		if i == sv.SelectedShelf {
+			key.selected = sv.SelectedBook
+		}
+
+		renderedShelves = append(renderedShelves, cachedShelf(key, func() string {
+			frame := styles.ShelfFrameStyle
+			if i == sv.SelectedShelf {
+				frame = styles.ShelfFrameSelectedStyle
+			}
+			inner := innerWidth(shelfFrame, innerWidth(frame, width))
+			shelf := renderShelf(shelfName, sv.Shelves[shelfName], i, sv.SelectedShelf, sv.SelectedBook, inner, colorBy)
+			return zone.Mark(ShelfZone(shelfName), frame.Render(shelf))
+		}))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, renderedShelves...)
+}
+
+// spineColor picks a book's colour: by shelf, by language (every language
+// keeps its colour across shelves) or by average rating.
+func spineColor(shelf string, book types.Book, colorBy string) lipgloss.TerminalColor {
+	t := styles.Current
+
+	switch colorBy {
+	case "language":
+		palette := []lipgloss.TerminalColor{t.ToRead, t.Reading, t.Read, t.Secondary, t.Warning, t.Danger, t.Primary, t.Success}
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(book.Language)))
+		return palette[h.Sum32()%uint32(len(palette))]
+	case "rating":
+		switch {
+		case book.Rating == 0:
+			return t.Muted
+		case book.Rating < 2.5:
+			return t.Danger
+		case book.Rating < 3.5:
+			return t.Warning
+		case book.Rating < 4.5:
+			return t.ToRead
+		default:
+			return t.Success
+		}
 	default:
-		return lipgloss.Color("#CCCCCC")
+		return styles.ShelfColor(shelf)
 	}
 }
 
 func renderShelf(
 	name string,
-	books []string,
+	books []types.Book,
 	shelfIndex int,
 	selectedShelf int,
 	selectedBook int,
+	width int,
+	colorBy string,
 ) string {
 
-	color := shelfColor(name)
-	var renderedBooks []string
-
-	for i, b := range books {
-		isSelected := shelfIndex == selectedShelf && i == selectedBook
-		renderedBooks = append(
-			renderedBooks,
-			renderBook(b, color, isSelected),
-		)
+	focus := -1
+	if shelfIndex == selectedShelf {
+		focus = selectedBook
 	}
 
-	bookRow := lipgloss.JoinHorizontal(
-		lipgloss.Bottom,
-		renderedBooks...,
-	)
+	bookRow := styles.HintStyle.Render("  (empty)  ")
+	if len(books) > 0 {
+		cell := lipgloss.Width(bookStyle(styles.Current.Muted, minSpineHeight, false).Render(""))
+		bookRow = rowBooks(len(books), cell, focus, width, lipgloss.Bottom, func(i int) string {
+			return zone.Mark(BookZone(name, i), renderBook(books[i], spineColor(name, books[i], colorBy), i == focus))
+		})
+	}
 
-	title := shelfPlank.Render(" " + strings.ReplaceAll(name, "_", " ") + " ")
+	title := styles.ShelfPlankStyle.Render(" " + strings.ReplaceAll(name, "_", " ") + " ")
 
 	return shelfFrame.Render(
 		title + "\n" +
 			bookRow + "\n" +
-			shelfPlank.Render(strings.Repeat(" ", lipgloss.Width(bookRow))),
+			styles.ShelfPlankStyle.Render(strings.Repeat(" ", lipgloss.Width(bookRow))),
 	)
 }
-
@@ -0,0 +1,79 @@
+package views
+
+import (
+    "strings"
+
+    "github.com/mattn/go-runewidth"
+    "github.com/rivo/uniseg"
+)
+
+const ellipsis = "…"
+
+// StableWidth drops emoji presentation selectors (U+FE0F). "✍️" is drawn
+// two cells wide by most terminals but measured as one by lipgloss, which
+// shifts everything after it; without the selector both agree on one.
+func StableWidth(s string) string {
+    return strings.ReplaceAll(s, "\uFE0F", "")
+}
+
+// Width is the number of terminal cells s takes, counting grapheme clusters
+// and east asian wide characters.
+func Width(s string) int {
+    return runewidth.StringWidth(StableWidth(s))
+}
+
+// Truncate shortens s to at most width cells, ending with "…" when cut.
+// It never splits a grapheme cluster, so accents and emoji stay whole.
+func Truncate(s string, width int) string {
+    s = StableWidth(s)
+    if runewidth.StringWidth(s) <= width {
+        return s
+    }
+    if width <= 0 {
+        return ""
+    }
+
+    budget := width - runewidth.StringWidth(ellipsis)
+    var b strings.Builder
+    used := 0
+    g := uniseg.NewGraphemes(s)
+    for g.Next() {
+        cluster := g.Str()
+        w := runewidth.StringWidth(cluster)
+        if used+w > budget {
+            break
+        }
+        b.WriteString(cluster)
+        used += w
+    }
+    return strings.TrimRight(b.String(), " ") + ellipsis
+}
+
+// Wrap breaks s into lines of at most width cells, between graphemes.
+func Wrap(s string, width int) []string {
+    var lines []string
+    line, used := "", 0
+    for _, c := range Graphemes(s) {
+        w := runewidth.StringWidth(c)
+        if used+w > width && line != "" {
+            lines = append(lines, line)
+            line, used = "", 0
+        }
+        line += c
+        used += w
+    }
+    if line != "" {
+        lines = append(lines, line)
+    }
+    return lines
+}
+
+// Graphemes splits s into user-perceived characters.
+func Graphemes(s string) []string {
+    var out []string
+    g := uniseg.NewGraphemes(StableWidth(s))
+    for g.Next() {
+        out = append(out, g.Str())
+    }
+    return out
+}
@@ -0,0 +1,99 @@
+package views
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestWidth(t *testing.T) {
+    tests := []struct {
+        s    string
+        want int
+    }{
+        {"", 0},
+        {"Dune", 4},
+        {"三体", 4},
+        {"Cafe\u0301", 4}, // e and a combining accent
+        {"✍️ Notes", 7},   // the emoji selector is dropped
+        {"ノルウェイの森", 14},
+    }
+    for _, tt := range tests {
+        if got := Width(tt.s); got != tt.want {
+            t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+        }
+    }
+}
+
+func TestTruncate(t *testing.T) {
+    tests := []struct {
+        s     string
+        width int
+        want  string
+    }{
+        {"Dune", 10, "Dune"},
+        {"Dune", 4, "Dune"},
+        {"The Silent Horizon", 10, "The Silen…"},
+        {"The Silent Horizon", 5, "The…"}, // no space before the ellipsis
+        {"Dune", 1, "…"},
+        {"Dune", 0, ""},
+        {"Dune", -3, ""},
+        // Wide characters are never cut in half
+        {"三体问题", 6, "三体…"},
+        {"三体问题", 5, "三体…"},
+        {"三体问题", 4, "三…"},
+        // Accents stay on their letter
+        {"Cafe\u0301 society", 5, "Cafe\u0301…"},
+        {"Cafe\u0301", 4, "Cafe\u0301"},
+        {"✍️✍️✍️✍️✍️", 3, "✍✍…"},
+    }
+    for _, tt := range tests {
+        got := Truncate(tt.s, tt.width)
+        if got != tt.want {
+            t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+        }
+        if tt.width >= 0 && Width(got) > tt.width {
+            t.Errorf("Truncate(%q, %d) is %d cells", tt.s, tt.width, Width(got))
+        }
+    }
+}
+
+func TestWrap(t *testing.T) {
+    tests := []struct {
+        s     string
+        width int
+        want  []string
+    }{
+        {"Dune", 4, []string{"Dune"}},
+        {"Dune Messiah", 4, []string{"Dune", " Mes", "siah"}},
+        {"三体问题", 3, []string{"三", "体", "问", "题"}},
+        {"三体问题", 4, []string{"三体", "问题"}},
+        {"Cafe\u0301s", 4, []string{"Cafe\u0301", "s"}},
+        {"", 4, nil},
+        // A character wider than the line still gets a line of its own
+        {"三", 1, []string{"三"}},
+    }
+    for _, tt := range tests {
+        got := Wrap(tt.s, tt.width)
+        if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+            t.Errorf("Wrap(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+        }
+    }
+}
+
+func TestGraphemes(t *testing.T) {
+    tests := []struct {
+        s    string
+        want int
+    }{
+        {"Dune", 4},
+        {"Cafe\u0301", 4},
+        {"👨‍👩‍👧", 1},
+        {"🇫🇷🇩🇪", 2},
+        {"שָׁלוֹם", 4},
+    }
+    for _, tt := range tests {
+        if got := Graphemes(tt.s); len(got) != tt.want {
+            t.Errorf("Graphemes(%q) = %q, want %d clusters", tt.s, got, tt.want)
+        }
+    }
+}
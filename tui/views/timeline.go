@@ -0,0 +1,102 @@
+package views
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/charmbracelet/lipgloss"
+    "tui/store"
+    "tui/styles"
+    "tui/types"
+)
+
+// RenderTimeline shows how a book was read: a summary, its bookmarks, then
+// its sittings from the scroll position on, rows at most, with the pauses
+// between them. startedAt is when the backend's session began, "" if none.
+func RenderTimeline(book types.Book, startedAt string, entries []store.Entry, bookmarks []store.Highlight, scroll, rows int) string {
+    lines := []string{styles.TitleStyle.Render("📖 " + Fit(book.Name, 48) + " — how it was read")}
+
+    var read time.Duration
+    pages := 0
+    for _, e := range entries {
+        read += e.End.Sub(e.Start)
+        pages += max(e.Pages(), 0)
+    }
+    summary := []string{}
+    if startedAt != "" {
+        summary = append(summary, "started "+shortDate(startedAt))
+    }
+    summary = append(summary, fmt.Sprintf("%d sitting(s)", len(entries)))
+    if len(entries) > 0 {
+        summary = append(summary, Duration(read), fmt.Sprintf("%d pages", pages))
+        if read >= time.Minute {
+            summary = append(summary, fmt.Sprintf("%.0f pages/hour", float64(pages)/read.Hours()))
+        }
+    }
+    lines = append(lines, styles.HintStyle.Render(strings.Join(summary, " · ")), "")
+
+    if len(bookmarks) > 0 {
+        lines = append(lines, "🔖 Bookmarks:")
+        for _, b := range bookmarks {
+            name := b.Text
+            if name == "" {
+                name = styles.HintStyle.Render("(unnamed)")
+            }
+            lines = append(lines, fmt.Sprintf("  %-9s %s", fmt.Sprintf("p. %d", b.Page), Fit(name, 44)))
+        }
+        lines = append(lines, "")
+    }
+
+    lines = append(lines, "🕰  Sittings:")
+    if len(entries) == 0 {
+        lines = append(lines, styles.HintStyle.Render("  None yet. Sittings are kept as you turn pages in the reading view."))
+        return strings.Join(lines, "\n")
+    }
+
+    scroll = ClampScroll(scroll, len(entries), rows)
+    if scroll > 0 {
+        lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("  ↑ %d earlier", scroll)))
+    }
+    end := min(scroll+rows, len(entries))
+    for _, e := range entries[scroll:end] {
+        if e.Pause >= time.Minute {
+            lines = append(lines, styles.HintStyle.Render("    ⏸ "+Duration(e.Pause)))
+        }
+        line := fmt.Sprintf("  %s–%s  %6s   p. %d → %d",
+            e.Start.Local().Format("Mon 2 Jan 15:04"), e.End.Local().Format("15:04"),
+            Duration(e.End.Sub(e.Start)), e.StartPage, e.EndPage)
+        switch {
+        case e.Reread:
+            line += lipgloss.NewStyle().Foreground(styles.WarningColor).Render("   ↺ reread")
+        case e.Pages() > 0:
+            line += fmt.Sprintf("   +%d", e.Pages())
+        }
+        lines = append(lines, line)
+    }
+    if end < len(entries) {
+        lines = append(lines, styles.HintStyle.Render(fmt.Sprintf("  ↓ %d later", len(entries)-end)))
+    }
+    return strings.Join(lines, "\n")
+}
+
+// ClampScroll keeps a scroll position within a list of count items
+// shown rows at a time.
+func ClampScroll(scroll, count, rows int) int {
+    return max(min(scroll, count-rows), 0)
+}
+
+// Duration is a short human duration: "44m", "2h 10m", "3 days".
+func Duration(d time.Duration) string {
+    d = d.Round(time.Minute)
+    switch {
+    case d < time.Hour:
+        return fmt.Sprintf("%dm", int(d.Minutes()))
+    case d < 48*time.Hour:
+        if m := int(d.Minutes()) % 60; m != 0 {
+            return fmt.Sprintf("%dh %dm", int(d.Hours()), m)
+        }
+        return fmt.Sprintf("%dh", int(d.Hours()))
+    }
+    return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
@@ -0,0 +1,57 @@
+package views
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "tui/store"
+    "tui/types"
+)
+
+func TestDuration(t *testing.T) {
+    tests := []struct {
+        d    time.Duration
+        want string
+    }{
+        {20 * time.Second, "0m"},
+        {44*time.Minute + 40*time.Second, "45m"},
+        {2*time.Hour + 10*time.Minute, "2h 10m"},
+        {3 * time.Hour, "3h"},
+        {50 * time.Hour, "2 days"},
+    }
+    for _, tt := range tests {
+        if got := Duration(tt.d); got != tt.want {
+            t.Errorf("Duration(%v) = %q, want %q", tt.d, got, tt.want)
+        }
+    }
+}
+
+func TestRenderTimeline(t *testing.T) {
+    start := time.Date(2024, 3, 2, 20, 0, 0, 0, time.Local)
+    var entries []store.Entry
+    for i := 0; i < 5; i++ {
+        s := store.Sitting{BookID: 1, StartPage: i * 10, EndPage: i*10 + 10, Start: start.Add(time.Duration(i) * 24 * time.Hour)}
+        s.End = s.Start.Add(30 * time.Minute)
+        entries = append(entries, store.Entry{Sitting: s})
+    }
+    book := types.Book{ID: 1, Name: "Dune"}
+
+    out := RenderTimeline(book, "2024-03-01T09:00:00", entries, nil, 1, 2)
+    for _, want := range []string{"5 sitting(s)", "2h 30m", "50 pages", "20 pages/hour", "↑ 1 earlier", "p. 10 → 20", "↓ 2 later"} {
+        if !strings.Contains(out, want) {
+            t.Errorf("%q not in\n%s", want, out)
+        }
+    }
+    if strings.Contains(out, "p. 0 → 10") {
+        t.Errorf("scrolled past sitting shown:\n%s", out)
+    }
+
+    // The scroll position is clamped to the last page of sittings
+    if out := RenderTimeline(book, "", entries, nil, 9, 2); !strings.Contains(out, "↑ 3 earlier") {
+        t.Errorf("scroll not clamped:\n%s", out)
+    }
+    if out := RenderTimeline(book, "", nil, nil, 0, 2); !strings.Contains(out, "None yet") {
+        t.Errorf("no sittings:\n%s", out)
+    }
+}
@@ -0,0 +1,46 @@
+package views
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// Zone ids of the parts of the screen that react to the mouse.
+const (
+    ZoneReviews = "reviews"
+
+    ButtonStartReading = "button:start_reading"
+    ButtonAddToLibrary = "button:add_to_library"
+    ButtonAddReview    = "button:add_review"
+    ButtonRate         = "button:rate"
+)
+
+func NavZone(i int) string {
+    return fmt.Sprintf("nav:%d", i)
+}
+
+func ShelfZone(shelf string) string {
+    return "shelf:" + shelf
+}
+
+func BookZone(shelf string, i int) string {
+    return fmt.Sprintf("book:%s:%d", shelf, i)
+}
+
+// ParseBookZone is the reverse of BookZone.
+func ParseBookZone(id string) (shelf string, i int, ok bool) {
+    rest, found := strings.CutPrefix(id, "book:")
+    if !found {
+        return "", 0, false
+    }
+    sep := strings.LastIndex(rest, ":")
+    if sep < 0 {
+        return "", 0, false
+    }
+    i, err := strconv.Atoi(rest[sep+1:])
+    if err != nil {
+        return "", 0, false
+    }
+    return rest[:sep], i, true
+}
@@ -0,0 +1,147 @@
+package zone
+
+import (
+    "strconv"
+    "strings"
+    "unicode/utf8"
+
+    "github.com/mattn/go-runewidth"
+)
+
+// Zones tie mouse events to what was drawn. A renderer wraps a region in
+// Mark, which adds two invisible escape sequences around it. Scan, run on a
+// finished frame, finds the rectangle each region ended up in, wherever
+// lipgloss moved it while joining and padding; Strip takes the markers out
+// before the frame goes to the terminal. The id travels inside the marker,
+// so marking keeps no state and the same frame always scans the same.
+
+// Zone is a marked region of a frame, in terminal cells.
+type Zone struct {
+    ID     string
+    X0, Y0 int // top left
+    X1, Y1 int // bottom right, inclusive
+}
+
+// Contains reports whether the cell x, y is inside the zone.
+func (z Zone) Contains(x, y int) bool {
+    return x >= z.X0 && x <= z.X1 && y >= z.Y0 && y <= z.Y1
+}
+
+func (z Zone) area() int {
+    return (z.X1 - z.X0 + 1) * (z.Y1 - z.Y0 + 1)
+}
+
+// Map holds the zones of one frame by id.
+type Map map[string]Zone
+
+// Mark wraps s so that Scan can find it under id.
+func Mark(id, s string) string {
+    // The id's bytes as CSI parameters, which lipgloss measures as zero width
+    params := make([]string, len(id))
+    for i := 0; i < len(id); i++ {
+        params[i] = strconv.Itoa(int(id[i]))
+    }
+    marker := "\x1b[" + strings.Join(params, ";") + "z"
+    return marker + s + marker
+}
+
+// Scan returns the zones marked in frame. It must see the whole screen,
+// positions count from its top left.
+func Scan(frame string) Map {
+    found := Map{}
+    walk(frame, func(id string, x, y int, open bool) {
+        if open {
+            found[id] = Zone{ID: id, X0: x, Y0: y}
+            return
+        }
+        z := found[id]
+        z.X1, z.Y1 = x-1, y
+        found[id] = z
+    }, nil)
+    return found
+}
+
+// Strip returns frame without the markers.
+func Strip(frame string) string {
+    var out strings.Builder
+    walk(frame, nil, &out)
+    return out.String()
+}
+
+// walk goes through frame keeping track of the cell it is at, reporting
+// each marker to mark and copying everything else to out.
+func walk(frame string, mark func(id string, x, y int, open bool), out *strings.Builder) {
+    write := func(s string) {
+        if out != nil {
+            out.WriteString(s)
+        }
+    }
+    open := map[string]bool{}
+    x, y := 0, 0
+
+    for i := 0; i < len(frame); {
+        if frame[i] == '\x1b' && i+1 < len(frame) && frame[i+1] == '[' {
+            j := i + 2
+            for j < len(frame) && frame[j] >= 0x20 && frame[j] <= 0x3f {
+                j++
+            }
+            if j == len(frame) {
+                write(frame[i:])
+                break
+            }
+            if frame[j] == 'z' {
+                if id, ok := decode(frame[i+2 : j]); ok {
+                    if mark != nil {
+                        mark(id, x, y, !open[id])
+                    }
+                    open[id] = !open[id]
+                    i = j + 1
+                    continue
+                }
+            }
+            write(frame[i : j+1])
+            i = j + 1
+            continue
+        }
+
+        r, size := utf8.DecodeRuneInString(frame[i:])
+        write(frame[i : i+size])
+        i += size
+        if r == '\n' {
+            x, y = 0, y+1
+            continue
+        }
+        x += runewidth.RuneWidth(r)
+    }
+}
+
+// decode reads the id back out of a marker's parameters.
+func decode(params string) (string, bool) {
+    var id []byte
+    for _, p := range strings.Split(params, ";") {
+        b, err := strconv.Atoi(p)
+        if err != nil || b < 0 || b > 255 {
+            return "", false
+        }
+        id = append(id, byte(b))
+    }
+    return string(id), true
+}
+
+// Get returns the zone drawn under id.
+func (m Map) Get(id string) (Zone, bool) {
+    z, ok := m[id]
+    return z, ok
+}
+
+// Find returns the innermost zone under x, y whose id starts with prefix.
+func (m Map) Find(prefix string, x, y int) (Zone, bool) {
+    var best Zone
+    ok := false
+    for id, z := range m {
+        if strings.HasPrefix(id, prefix) && z.Contains(x, y) && (!ok || z.area() < best.area()) {
+            best, ok = z, true
+        }
+    }
+    return best, ok
+}
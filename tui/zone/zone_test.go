@@ -0,0 +1,59 @@
+package zone
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/charmbracelet/lipgloss"
+)
+
+func TestScan(t *testing.T) {
+    box := lipgloss.NewStyle().Padding(0, 2).Render(Mark("inner", "ab") + "\n" + "cdef")
+    frame := "title\n" + lipgloss.JoinHorizontal(lipgloss.Top, Mark("left", "Ω界"), " ", Mark("box", box))
+
+    zones := Scan(frame)
+    tests := []struct {
+        id   string
+        want Zone
+    }{
+        {"left", Zone{ID: "left", X0: 0, Y0: 1, X1: 2, Y1: 1}}, // 界 takes two cells
+        {"box", Zone{ID: "box", X0: 4, Y0: 1, X1: 11, Y1: 2}},
+        {"inner", Zone{ID: "inner", X0: 6, Y0: 1, X1: 7, Y1: 1}},
+    }
+    for _, tt := range tests {
+        if got, ok := zones.Get(tt.id); !ok || got != tt.want {
+            t.Errorf("zone %q = %+v, %v, want %+v", tt.id, got, ok, tt.want)
+        }
+    }
+
+    // The innermost zone wins, the prefix narrows the search
+    if z, ok := zones.Find("", 6, 1); !ok || z.ID != "inner" {
+        t.Errorf("Find at 6,1 = %+v, %v", z, ok)
+    }
+    if z, ok := zones.Find("bo", 6, 1); !ok || z.ID != "box" {
+        t.Errorf("Find box at 6,1 = %+v, %v", z, ok)
+    }
+    if _, ok := zones.Find("", 3, 1); ok {
+        t.Error("found a zone in the gap")
+    }
+
+    stripped := Strip(frame)
+    if strings.Contains(stripped, "z") || lipgloss.Width(stripped) != lipgloss.Width(frame) {
+        t.Errorf("Strip left markers in %q", stripped)
+    }
+}
+
+// Marking keeps nothing, the same frame scans the same every time.
+func TestMarkIsPure(t *testing.T) {
+    frame := Mark("book:read:0", "Dune")
+    if Mark("book:read:0", "Dune") != frame {
+        t.Fatal("Mark gave two markers for one id")
+    }
+    a, b := Scan(frame), Scan(frame)
+    if len(a) != 1 || a["book:read:0"] != b["book:read:0"] {
+        t.Fatalf("Scan = %+v, then %+v", a, b)
+    }
+    if got := Strip(frame + "\x1b[1mbold\x1b[0m"); got != "Dune\x1b[1mbold\x1b[0m" {
+        t.Fatalf("Strip = %q", got)
+    }
+}